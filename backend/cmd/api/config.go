@@ -6,6 +6,8 @@ import (
 	"github.com/caarlos0/env/v10"
 	"github.com/hay-kot/hookfeed/backend/internal/data/db"
 	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/xapps/smtpd"
+	"github.com/hay-kot/hookfeed/backend/internal/xapps/syslogd"
 	"github.com/hay-kot/hookfeed/backend/internal/xapps/webapi"
 )
 
@@ -13,6 +15,8 @@ type Config struct {
 	Web        webapi.Config
 	Postgres   db.Config
 	ServiceCfg services.Config
+	SMTP       smtpd.Config
+	Syslog     syslogd.Config
 }
 
 var EnvPrefix = "PC_"
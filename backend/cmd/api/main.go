@@ -9,6 +9,9 @@ import (
 	"github.com/hay-kot/hookfeed/backend/internal/data/db/migrations"
 	"github.com/hay-kot/hookfeed/backend/internal/services"
 	"github.com/hay-kot/hookfeed/backend/internal/xapps/intervalbot"
+	"github.com/hay-kot/hookfeed/backend/internal/xapps/mqttsub"
+	"github.com/hay-kot/hookfeed/backend/internal/xapps/smtpd"
+	"github.com/hay-kot/hookfeed/backend/internal/xapps/syslogd"
 	"github.com/hay-kot/hookfeed/backend/internal/xapps/tasker"
 	"github.com/hay-kot/hookfeed/backend/internal/xapps/webapi"
 	"github.com/hay-kot/plugs/plugs"
@@ -69,7 +72,7 @@ func run() error {
 
 	webAPI := webapi.New(log.Logger, build(), cfg.Web, services)
 
-	intervalBot := intervalbot.New(log.Logger)
+	intervalBot := intervalbot.New(log.Logger, services)
 
 	mgr := plugs.New(
 		plugs.WithPrintln(log.Logger.Print),
@@ -79,5 +82,20 @@ func run() error {
 	mgr.AddFunc("task_runner", taskRunner.Start)
 	mgr.AddFunc("web_api", webAPI.Start)
 
+	if cfg.SMTP.Enabled {
+		smtpServer := smtpd.New(log.Logger, cfg.SMTP, services)
+		mgr.AddFunc("smtp_server", smtpServer.Start)
+	}
+
+	if cfg.Syslog.Enabled {
+		syslogServer := syslogd.New(log.Logger, cfg.Syslog, services)
+		mgr.AddFunc("syslog_server", syslogServer.Start)
+	}
+
+	if services.Feeds != nil && services.Feeds.GetCache().MQTT().Enabled() {
+		mqttSubscriber := mqttsub.New(log.Logger, services.Feeds.GetCache().MQTT(), services.Webhooks.ProcessMessage)
+		mgr.AddFunc("mqtt_subscriber", mqttSubscriber.Start)
+	}
+
 	return mgr.Start(context.Background())
 }
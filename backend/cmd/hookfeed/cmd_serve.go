@@ -8,6 +8,9 @@ import (
 	"github.com/hay-kot/hookfeed/backend/internal/data/db/migrations"
 	"github.com/hay-kot/hookfeed/backend/internal/services"
 	"github.com/hay-kot/hookfeed/backend/internal/xapps/intervalbot"
+	"github.com/hay-kot/hookfeed/backend/internal/xapps/mqttsub"
+	"github.com/hay-kot/hookfeed/backend/internal/xapps/smtpd"
+	"github.com/hay-kot/hookfeed/backend/internal/xapps/syslogd"
 	"github.com/hay-kot/hookfeed/backend/internal/xapps/tasker"
 	"github.com/hay-kot/hookfeed/backend/internal/xapps/webapi"
 	"github.com/hay-kot/plugs/plugs"
@@ -66,11 +69,16 @@ func (s *ServeCmd) serve(ctx context.Context, cmd *cli.Command) error {
 		cfg.ServiceCfg.FeedFile = s.flags.config
 	}
 
+	if s.flags.middlewareDir != "" {
+		cfg.ServiceCfg.MiddlewareDir = s.flags.middlewareDir
+	}
+
 	log.Info().
 		Str("host", cfg.Web.Host).
 		Str("port", cfg.Web.Port).
 		Str("database", cfg.Postgres.Host).
 		Str("feedFile", cfg.ServiceCfg.FeedFile).
+		Str("middlewareDir", cfg.ServiceCfg.MiddlewareDir).
 		Msg("configuration loaded")
 
 	// Initialize database connection with migrations
@@ -92,7 +100,7 @@ func (s *ServeCmd) serve(ctx context.Context, cmd *cli.Command) error {
 	webAPI := webapi.New(log.Logger, build(), cfg.Web, svcs)
 
 	// Initialize interval bot for scheduled tasks
-	intervalBot := intervalbot.New(log.Logger)
+	intervalBot := intervalbot.New(log.Logger, svcs)
 
 	// Create plugs manager to orchestrate all services
 	mgr := plugs.New(
@@ -104,6 +112,21 @@ func (s *ServeCmd) serve(ctx context.Context, cmd *cli.Command) error {
 	mgr.AddFunc("task_runner", taskRunner.Start)
 	mgr.AddFunc("web_api", webAPI.Start)
 
+	if cfg.SMTP.Enabled {
+		smtpServer := smtpd.New(log.Logger, cfg.SMTP, svcs)
+		mgr.AddFunc("smtp_server", smtpServer.Start)
+	}
+
+	if cfg.Syslog.Enabled {
+		syslogServer := syslogd.New(log.Logger, cfg.Syslog, svcs)
+		mgr.AddFunc("syslog_server", syslogServer.Start)
+	}
+
+	if svcs.Feeds != nil && svcs.Feeds.GetCache().MQTT().Enabled() {
+		mqttSubscriber := mqttsub.New(log.Logger, svcs.Feeds.GetCache().MQTT(), svcs.Webhooks.ProcessMessage)
+		mgr.AddFunc("mqtt_subscriber", mqttSubscriber.Start)
+	}
+
 	log.Info().Msg("starting all services")
 
 	// Start all services and block until context is cancelled
@@ -0,0 +1,193 @@
+package hookfeed
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// DefaultPoolSize is the number of idle Lua states kept per script
+const DefaultPoolSize = 16
+
+// ScriptCache compiles Lua scripts once and keeps a bounded pool of ready
+// LStates for each script. A script is recompiled, and its pool discarded, when
+// the modification time or size of the file changes. All states are sandboxed
+// and bounded by the cache limits.
+//
+// Pooled states are reused between calls, so globals assigned by a script
+// persist across invocations. Scripts should keep per-request data in locals.
+type ScriptCache struct {
+	poolSize int
+	limits   Limits
+
+	mu      sync.Mutex
+	scripts map[string]*compiledScript
+}
+
+// NewScriptCache creates a new ScriptCache that keeps up to poolSize idle
+// states per script
+func NewScriptCache(poolSize int, limits Limits) *ScriptCache {
+	if poolSize < 1 {
+		poolSize = 1
+	}
+
+	return &ScriptCache{
+		poolSize: poolSize,
+		limits:   limits,
+		scripts:  make(map[string]*compiledScript),
+	}
+}
+
+// compiledScript is a compiled script and the pool of states it has been loaded into
+type compiledScript struct {
+	path    string
+	proto   *lua.FunctionProto
+	modTime time.Time
+	size    int64
+	states  chan *lua.LState
+	stale   atomic.Bool
+}
+
+// acquire returns a state with the script at path loaded. The state must be
+// returned with release.
+func (sc *ScriptCache) acquire(path string) (*compiledScript, *lua.LState, error) {
+	script, err := sc.get(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	select {
+	case L := <-script.states:
+		return script, L, nil
+	default:
+	}
+
+	L, err := script.newState(sc.limits)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return script, L, nil
+}
+
+// release resets the stack of L and returns it to the pool of script. The state
+// is closed when the pool is full or the script has been invalidated.
+func (sc *ScriptCache) release(script *compiledScript, L *lua.LState) {
+	L.RemoveContext()
+	L.SetTop(0)
+
+	if script.stale.Load() {
+		L.Close()
+		return
+	}
+
+	select {
+	case script.states <- L:
+	default:
+		L.Close()
+	}
+}
+
+// discard closes a state that must not be reused, such as one interrupted by a
+// sandbox limit part way through a run
+func (sc *ScriptCache) discard(L *lua.LState) {
+	L.RemoveContext()
+	L.Close()
+}
+
+// get returns the compiled script for path, compiling it when it is missing or
+// the file has changed since it was compiled
+func (sc *ScriptCache) get(path string) (*compiledScript, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, newScriptError(ErrorKindLoad, "failed to load lua script: %w", err)
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	script, ok := sc.scripts[path]
+	if ok && script.modTime.Equal(info.ModTime()) && script.size == info.Size() {
+		return script, nil
+	}
+
+	proto, err := compileFile(path)
+	if err != nil {
+		return nil, newScriptError(ErrorKindLoad, "failed to load lua script: %w", err)
+	}
+
+	if ok {
+		script.invalidate()
+	}
+
+	script = &compiledScript{
+		path:    path,
+		proto:   proto,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		states:  make(chan *lua.LState, sc.poolSize),
+	}
+	sc.scripts[path] = script
+
+	return script, nil
+}
+
+// invalidate marks the script stale and closes all idle states
+func (cs *compiledScript) invalidate() {
+	cs.stale.Store(true)
+
+	for {
+		select {
+		case L := <-cs.states:
+			L.Close()
+		default:
+			return
+		}
+	}
+}
+
+// newState creates a new sandboxed state and executes the compiled script in it
+// so the functions it declares are available as globals
+func (cs *compiledScript) newState(limits Limits) (*lua.LState, error) {
+	L := newSandboxState(limits)
+
+	ctx, cancel := limits.runContext(context.Background())
+	defer cancel()
+
+	L.SetContext(ctx)
+	L.Push(L.NewFunctionFromProto(cs.proto))
+	if err := L.PCall(0, lua.MultRet, nil); err != nil {
+		L.Close()
+		serr := limits.classifyError(ctx, err)
+		if serr.Kind == ErrorKindRuntime {
+			serr.Kind = ErrorKindLoad
+		}
+		return nil, serr
+	}
+	L.RemoveContext()
+	L.SetTop(0)
+
+	return L, nil
+}
+
+// compileFile parses and compiles the Lua script at path
+func compileFile(path string) (*lua.FunctionProto, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	chunk, err := parse.Parse(bufio.NewReader(file), path)
+	if err != nil {
+		return nil, err
+	}
+
+	return lua.Compile(chunk, path)
+}
@@ -0,0 +1,200 @@
+package hookfeed
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const benchTransformScript = `
+function transform(input)
+    local out = {}
+    out.title = "Build " .. input.build.id
+    out.branch = input.build.branch
+    out.steps = {}
+    for i, step in ipairs(input.build.steps) do
+        out.steps[i] = string.upper(step)
+    end
+    return out
+end
+`
+
+var benchTransformInput = []byte(`{
+  "build": {
+    "id": "42",
+    "branch": "main",
+    "steps": ["checkout", "test", "build", "deploy"]
+  }
+}`)
+
+func Test_ScriptCache_Reuse(t *testing.T) {
+	dir := writeScripts(t, map[string]string{
+		"counter.lua": `
+calls = 0
+function process(context)
+    calls = calls + 1
+    context.payload.title = tostring(calls)
+    return context
+end
+`,
+	})
+
+	cache := NewScriptCache(1, DefaultLimits)
+	mw := NewMiddleware(filepath.Join(dir, "counter.lua"), cache)
+
+	for _, want := range []string{"1", "2", "3"} {
+		c := NewContext(nil, nil, nil)
+		require.NoError(t, mw.Process(context.Background(), c))
+		assert.Equal(t, want, c.Payload.Title, "pooled state should be reused")
+	}
+}
+
+func Test_ScriptCache_PoolBound(t *testing.T) {
+	dir := writeScripts(t, map[string]string{"noop.lua": appendLog("noop", "continue")})
+	path := filepath.Join(dir, "noop.lua")
+
+	cache := NewScriptCache(2, DefaultLimits)
+
+	type acquired struct {
+		script *compiledScript
+		state  *lua.LState
+	}
+
+	held := make([]acquired, 0, 4)
+	for range 4 {
+		script, L, err := cache.acquire(path)
+		require.NoError(t, err)
+		held = append(held, acquired{script, L})
+	}
+
+	for _, a := range held {
+		cache.release(a.script, a.state)
+	}
+
+	script, err := cache.get(path)
+	require.NoError(t, err)
+	assert.Len(t, script.states, 2)
+}
+
+func Test_ScriptCache_InvalidatesOnChange(t *testing.T) {
+	dir := writeScripts(t, map[string]string{"transform.lua": `function transform(input) return { v = 1 } end`})
+	path := filepath.Join(dir, "transform.lua")
+
+	transformer := NewTransformer(path)
+
+	out, err := transformer.Transform([]byte(`{}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"v": 1}`, string(out))
+
+	old, err := transformer.cache.get(path)
+	require.NoError(t, err)
+
+	err = os.WriteFile(path, []byte(`function transform(input) return { v = 22 } end`), 0o644)
+	require.NoError(t, err)
+
+	out, err = transformer.Transform([]byte(`{}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"v": 22}`, string(out))
+
+	assert.True(t, old.stale.Load(), "previous compiled script should be invalidated")
+	assert.Empty(t, old.states)
+}
+
+// transformPerCall is the uncached transform path, a fresh state is created and
+// the script is read and parsed on every call. It is used as the baseline for
+// the benchmarks below.
+func transformPerCall(scriptPath string, input []byte) ([]byte, error) {
+	L := lua.NewState()
+	defer L.Close()
+
+	if err := L.DoFile(scriptPath); err != nil {
+		return nil, err
+	}
+
+	var inputData map[string]interface{}
+	if err := json.Unmarshal(input, &inputData); err != nil {
+		return nil, err
+	}
+
+	L.Push(L.GetGlobal("transform"))
+	L.Push(jsonToLuaTable(L, inputData))
+	if err := L.PCall(1, 1, nil); err != nil {
+		return nil, err
+	}
+
+	result := L.Get(-1)
+	L.Pop(1)
+
+	v, err := luaValueToGo(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func benchScript(b *testing.B) string {
+	b.Helper()
+
+	path := filepath.Join(b.TempDir(), "transform.lua")
+	err := os.WriteFile(path, []byte(benchTransformScript), 0o644)
+	require.NoError(b, err)
+
+	return path
+}
+
+func BenchmarkTransform_PerCall(b *testing.B) {
+	path := benchScript(b)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := transformPerCall(path, benchTransformInput); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTransform_Pooled(b *testing.B) {
+	path := benchScript(b)
+	transformer := NewTransformer(path)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := transformer.Transform(benchTransformInput); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTransform_PerCallParallel(b *testing.B) {
+	path := benchScript(b)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := transformPerCall(path, benchTransformInput); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkTransform_PooledParallel(b *testing.B) {
+	path := benchScript(b)
+	transformer := NewTransformer(path)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := transformer.Transform(benchTransformInput); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
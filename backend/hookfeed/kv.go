@@ -0,0 +1,172 @@
+package hookfeed
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// KVLibName is the name of the global table holding the key/value functions
+const KVLibName = "kv"
+
+// kvRegistryKey is the registry field holding the kvScope of the current run
+const kvRegistryKey = "hookfeed.kv"
+
+// KVStore persists values between runs. Keys are scoped to a feed and values
+// are JSON encoded. A ttl of zero means the key never expires. Implementations
+// must be safe for concurrent use.
+type KVStore interface {
+	// Get returns the value of key, and false when it is missing or expired
+	Get(ctx context.Context, feed, key string) ([]byte, bool, error)
+	// Set creates or replaces key
+	Set(ctx context.Context, feed, key string, value []byte, ttl time.Duration) error
+	// Incr atomically adds delta to key and returns the new value. Missing keys
+	// start from zero and expire after ttl, existing keys keep their expiry.
+	Incr(ctx context.Context, feed, key string, delta int64, ttl time.Duration) (int64, error)
+	// Delete removes key and reports whether it existed
+	Delete(ctx context.Context, feed, key string) (bool, error)
+}
+
+// kvScope binds a store to the feed of the current run
+type kvScope struct {
+	store KVStore
+	feed  string
+}
+
+var kvFuncs = map[string]lua.LGFunction{
+	"get":    kvGet,
+	"set":    kvSet,
+	"incr":   kvIncr,
+	"delete": kvDelete,
+}
+
+// openKV registers the kv table
+func openKV(L *lua.LState) {
+	L.RegisterModule(KVLibName, kvFuncs)
+}
+
+// setKVScope makes scope the target of the kv functions. Passing nil clears it.
+func setKVScope(L *lua.LState, scope *kvScope) {
+	var value lua.LValue = lua.LNil
+	if scope != nil {
+		ud := L.NewUserData()
+		ud.Value = scope
+		value = ud
+	}
+
+	L.SetField(L.Get(lua.RegistryIndex), kvRegistryKey, value)
+}
+
+// currentKV returns the scope of the current run and its context, or raises an
+// error when no store is configured
+func currentKV(L *lua.LState) (*kvScope, context.Context) {
+	ud, ok := L.GetField(L.Get(lua.RegistryIndex), kvRegistryKey).(*lua.LUserData)
+	if !ok {
+		L.RaiseError("kv store is not available")
+		return nil, nil
+	}
+
+	ctx := L.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return ud.Value.(*kvScope), ctx
+}
+
+// optTTL reads an optional ttl in seconds from argument n
+func optTTL(L *lua.LState, n int) time.Duration {
+	secs := float64(L.OptNumber(n, 0))
+	if secs < 0 {
+		L.ArgError(n, "ttl must not be negative")
+		return 0
+	}
+
+	return time.Duration(secs * float64(time.Second))
+}
+
+// kvGet implements kv.get(key) and returns the stored value or nil
+func kvGet(L *lua.LState) int {
+	key := L.CheckString(1)
+	scope, ctx := currentKV(L)
+
+	data, ok, err := scope.store.Get(ctx, scope.feed, key)
+	if err != nil {
+		L.RaiseError("kv.get: %s", err.Error())
+		return 0
+	}
+
+	if !ok {
+		L.Push(lua.LNil)
+		return 1
+	}
+
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		L.RaiseError("kv.get: %s", err.Error())
+		return 0
+	}
+
+	L.Push(goToLuaValue(L, v))
+	return 1
+}
+
+// kvSet implements kv.set(key, value[, ttl])
+func kvSet(L *lua.LState) int {
+	key := L.CheckString(1)
+	value := L.CheckAny(2)
+	ttl := optTTL(L, 3)
+	scope, ctx := currentKV(L)
+
+	v, err := luaValueToGo(value)
+	if err != nil {
+		L.RaiseError("kv.set: %s", err.Error())
+		return 0
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		L.RaiseError("kv.set: %s", err.Error())
+		return 0
+	}
+
+	if err := scope.store.Set(ctx, scope.feed, key, data, ttl); err != nil {
+		L.RaiseError("kv.set: %s", err.Error())
+	}
+
+	return 0
+}
+
+// kvIncr implements kv.incr(key[, delta[, ttl]]) and returns the new value
+func kvIncr(L *lua.LState) int {
+	key := L.CheckString(1)
+	delta := L.OptInt64(2, 1)
+	ttl := optTTL(L, 3)
+	scope, ctx := currentKV(L)
+
+	n, err := scope.store.Incr(ctx, scope.feed, key, delta, ttl)
+	if err != nil {
+		L.RaiseError("kv.incr: %s", err.Error())
+		return 0
+	}
+
+	L.Push(lua.LNumber(n))
+	return 1
+}
+
+// kvDelete implements kv.delete(key) and returns whether the key existed
+func kvDelete(L *lua.LState) int {
+	key := L.CheckString(1)
+	scope, ctx := currentKV(L)
+
+	ok, err := scope.store.Delete(ctx, scope.feed, key)
+	if err != nil {
+		L.RaiseError("kv.delete: %s", err.Error())
+		return 0
+	}
+
+	L.Push(lua.LBool(ok))
+	return 1
+}
@@ -0,0 +1,175 @@
+package hookfeed
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memKV is an in-memory KVStore used by tests. Expiry is recorded but not enforced.
+type memKV struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	ttls map[string]time.Duration
+}
+
+func newMemKV() *memKV {
+	return &memKV{data: map[string][]byte{}, ttls: map[string]time.Duration{}}
+}
+
+func (m *memKV) Get(_ context.Context, feed, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.data[feed+"/"+key]
+	return v, ok, nil
+}
+
+func (m *memKV) Set(_ context.Context, feed, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.data[feed+"/"+key] = value
+	m.ttls[feed+"/"+key] = ttl
+	return nil
+}
+
+func (m *memKV) Incr(_ context.Context, feed, key string, delta int64, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	if v, ok := m.data[feed+"/"+key]; ok {
+		var err error
+		if n, err = strconv.ParseInt(string(v), 10, 64); err != nil {
+			return 0, err
+		}
+	} else {
+		m.ttls[feed+"/"+key] = ttl
+	}
+
+	n += delta
+	m.data[feed+"/"+key] = []byte(strconv.FormatInt(n, 10))
+	return n, nil
+}
+
+func (m *memKV) Delete(_ context.Context, feed, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.data[feed+"/"+key]
+	delete(m.data, feed+"/"+key)
+	return ok, nil
+}
+
+func Test_KV_Scripts(t *testing.T) {
+	dir := writeScripts(t, map[string]string{
+		"heartbeat.lua": `
+function process(context)
+    local n = kv.incr("heartbeats")
+    if n % 10 ~= 0 then
+        context.action = "abort"
+    end
+    return context
+end
+`,
+		"dedupe.lua": `
+function process(context)
+    local key = "build:" .. context.payload.raw.build
+    if kv.get(key) then
+        context.action = "abort"
+        return context
+    end
+    kv.set(key, { seen = true }, 3600)
+    return context
+end
+`,
+		"cycle.lua": `
+function process(context)
+    local t = {}
+    t.self = t
+    kv.set("loop", t)
+    return context
+end
+`,
+		"roundtrip.lua": `
+function process(context)
+    kv.set("doc", { name = "hookfeed", tags = { "a", "b" } })
+    local doc = kv.get("doc")
+    context.payload.title = doc.name .. ":" .. doc.tags[2]
+    context.payload.metadata.deleted = kv.delete("doc")
+    context.payload.metadata.missing = kv.get("doc") == nil
+    return context
+end
+`,
+	})
+
+	t.Run("keep every 10th heartbeat", func(t *testing.T) {
+		store := newMemKV()
+		p := NewPipeline(dir, nil, DefaultLimits, store)
+
+		kept := 0
+		for range 30 {
+			if p.Run(context.Background(), "feed", []string{"heartbeat.lua"}, NewContext(nil, nil, nil)) != ActionAbort {
+				kept++
+			}
+		}
+
+		assert.Equal(t, 3, kept)
+	})
+
+	t.Run("dedupe with ttl", func(t *testing.T) {
+		store := newMemKV()
+		p := NewPipeline(dir, nil, DefaultLimits, store)
+		body := map[string]any{"build": "42"}
+
+		assert.Equal(t, ActionContinue, p.Run(context.Background(), "feed", []string{"dedupe.lua"}, NewContext(body, nil, nil)))
+		assert.Equal(t, ActionAbort, p.Run(context.Background(), "feed", []string{"dedupe.lua"}, NewContext(body, nil, nil)))
+		assert.Equal(t, time.Hour, store.ttls["feed/build:42"])
+
+		// keys are scoped per feed
+		assert.Equal(t, ActionContinue, p.Run(context.Background(), "other", []string{"dedupe.lua"}, NewContext(body, nil, nil)))
+	})
+
+	t.Run("values round trip as json", func(t *testing.T) {
+		c := NewContext(nil, nil, nil)
+		err := NewMiddleware(filepath.Join(dir, "roundtrip.lua"), NewScriptCache(1, DefaultLimits)).
+			WithKV(newMemKV(), "feed").
+			Process(context.Background(), c)
+		require.NoError(t, err)
+
+		assert.Equal(t, "hookfeed:b", c.Payload.Title)
+		assert.Equal(t, map[string]any{"deleted": true, "missing": true}, c.Payload.Metadata)
+	})
+
+	t.Run("cyclic value is rejected", func(t *testing.T) {
+		store := newMemKV()
+		c := NewContext(nil, nil, nil)
+		err := NewMiddleware(filepath.Join(dir, "cycle.lua"), NewScriptCache(1, DefaultLimits)).
+			WithKV(store, "feed").
+			Process(context.Background(), c)
+
+		var serr *ScriptError
+		require.ErrorAs(t, err, &serr)
+		assert.Equal(t, ErrorKindRuntime, serr.Kind)
+		assert.Contains(t, err.Error(), "kv.set: "+ErrTableCycle.Error())
+		assert.Empty(t, store.data)
+	})
+
+	t.Run("no store configured", func(t *testing.T) {
+		c := NewContext(nil, nil, nil)
+		err := NewMiddleware(filepath.Join(dir, "heartbeat.lua"), NewScriptCache(1, DefaultLimits)).
+			Process(context.Background(), c)
+
+		var serr *ScriptError
+		require.ErrorAs(t, err, &serr)
+		assert.Equal(t, ErrorKindRuntime, serr.Kind)
+		assert.Contains(t, err.Error(), "kv store is not available")
+	})
+}
@@ -0,0 +1,281 @@
+package hookfeed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Action controls how the pipeline proceeds after a middleware script returns
+type Action string
+
+const (
+	ActionContinue Action = "continue" // Proceed to the next middleware (default)
+	ActionAbort    Action = "abort"    // Stop processing immediately, don't save the message
+	ActionSkip     Action = "skip"     // Skip the remaining middleware in the current stage
+	ActionBypass   Action = "bypass"   // Skip the remaining middleware and adapters, proceed to save
+)
+
+// Payload is the message data exposed to middleware scripts as context.payload
+type Payload struct {
+	Raw      map[string]any    `json:"raw"`
+	Body     string            `json:"body"` // unparsed request body, used for signature checks
+	Headers  map[string]string `json:"headers"`
+	Query    map[string]string `json:"query"`
+	Title    string            `json:"title"`
+	Message  string            `json:"message"`
+	Priority int32             `json:"priority"`
+	Logs     []string          `json:"logs"`
+	Metadata map[string]any    `json:"metadata"`
+}
+
+// Context is the value passed to, and returned from, the process function of a
+// middleware script
+type Context struct {
+	Action  Action  `json:"action"`
+	Error   string  `json:"error"`
+	Payload Payload `json:"payload"`
+}
+
+// NewContext creates a Context for an incoming HTTP request. Multi-value headers
+// and query parameters are reduced to their first value.
+func NewContext(body map[string]any, headers http.Header, query url.Values) *Context {
+	if body == nil {
+		body = map[string]any{}
+	}
+
+	return &Context{
+		Action: ActionContinue,
+		Payload: Payload{
+			Raw:      body,
+			Headers:  firstValues(headers),
+			Query:    firstValues(query),
+			Logs:     []string{},
+			Metadata: map[string]any{},
+		},
+	}
+}
+
+func firstValues(values map[string][]string) map[string]string {
+	result := make(map[string]string, len(values))
+	for k, v := range values {
+		if len(v) > 0 {
+			result[k] = v[0]
+		}
+	}
+	return result
+}
+
+// Middleware executes the process function of a single Lua middleware script
+type Middleware struct {
+	scriptPath string
+	cache      *ScriptCache
+	kv         *kvScope
+}
+
+// NewMiddleware creates a new Middleware for the given Lua script path. States
+// are taken from cache, which may be shared between middleware.
+func NewMiddleware(scriptPath string, cache *ScriptCache) *Middleware {
+	return &Middleware{
+		scriptPath: scriptPath,
+		cache:      cache,
+	}
+}
+
+// WithKV exposes store to the script through the kv table, with keys scoped to
+// feed
+func (m *Middleware) WithKV(store KVStore, feed string) *Middleware {
+	if store != nil {
+		m.kv = &kvScope{store: store, feed: feed}
+	}
+	return m
+}
+
+// Process calls the script's process function with c and writes the returned
+// context back into c. On error c is left unmodified.
+//
+// The run is bound to ctx and the limits of the cache. All errors returned are
+// of type *ScriptError.
+func (m *Middleware) Process(ctx context.Context, c *Context) error {
+	script, L, err := m.cache.acquire(m.scriptPath)
+	if err != nil {
+		return err
+	}
+
+	processFn := L.GetGlobal("process")
+	if processFn.Type() != lua.LTFunction {
+		m.cache.release(script, L)
+		return newScriptError(ErrorKindRuntime, "process function not found in lua script")
+	}
+
+	runCtx, cancel := m.cache.limits.runContext(ctx)
+	defer cancel()
+
+	tbl := contextToTable(L, c)
+	setCurrentContext(L, tbl)
+	setKVScope(L, m.kv)
+	defer func() {
+		setCurrentContext(L, lua.LNil)
+		setKVScope(L, nil)
+	}()
+
+	L.SetContext(runCtx)
+	L.Push(processFn)
+	L.Push(tbl)
+	if err := L.PCall(1, 1, nil); err != nil {
+		serr := m.cache.limits.classifyError(runCtx, err)
+		if serr.Kind == ErrorKindRuntime || serr.Kind == ErrorKindForbidden {
+			m.cache.release(script, L)
+		} else {
+			m.cache.discard(L)
+		}
+
+		if serr.Kind == ErrorKindRuntime {
+			serr.Err = fmt.Errorf("failed to execute process function: %w", serr.Err)
+		}
+		return serr
+	}
+
+	result := L.Get(-1)
+	m.cache.release(script, L)
+
+	tbl, ok := result.(*lua.LTable)
+	if !ok {
+		return newScriptError(ErrorKindRuntime, "process function must return a context table, got %s", result.Type())
+	}
+
+	if err := tableToContext(tbl, c); err != nil {
+		return &ScriptError{Kind: ErrorKindRuntime, Err: err}
+	}
+
+	return nil
+}
+
+// contextToTable converts a Context into the Lua table passed to process
+func contextToTable(l *lua.LState, c *Context) *lua.LTable {
+	payload := l.NewTable()
+	payload.RawSetString("raw", jsonToLuaTable(l, c.Payload.Raw))
+	payload.RawSetString("body", lua.LString(c.Payload.Body))
+	payload.RawSetString("headers", stringMapToLuaTable(l, c.Payload.Headers))
+	payload.RawSetString("query", stringMapToLuaTable(l, c.Payload.Query))
+	payload.RawSetString("title", lua.LString(c.Payload.Title))
+	payload.RawSetString("message", lua.LString(c.Payload.Message))
+	payload.RawSetString("priority", lua.LNumber(c.Payload.Priority))
+	payload.RawSetString("metadata", jsonToLuaTable(l, c.Payload.Metadata))
+
+	logs := l.NewTable()
+	for _, line := range c.Payload.Logs {
+		logs.Append(lua.LString(line))
+	}
+	payload.RawSetString("logs", logs)
+
+	table := l.NewTable()
+	table.RawSetString("action", lua.LString(c.Action))
+	if c.Error != "" {
+		table.RawSetString("error", lua.LString(c.Error))
+	}
+	table.RawSetString("payload", payload)
+
+	return table
+}
+
+func stringMapToLuaTable(l *lua.LState, data map[string]string) *lua.LTable {
+	table := l.NewTable()
+	for key, value := range data {
+		table.RawSetString(key, lua.LString(value))
+	}
+	return table
+}
+
+// tableToContext reads the table returned from process back into c. Fields are
+// validated before c is modified so a malformed result leaves c untouched.
+func tableToContext(tbl *lua.LTable, c *Context) error {
+	action := ActionContinue
+	if v, ok := tbl.RawGetString("action").(lua.LString); ok && v != "" {
+		action = Action(v)
+	}
+
+	switch action {
+	case ActionContinue, ActionAbort, ActionSkip, ActionBypass:
+	default:
+		return fmt.Errorf("invalid action %q", action)
+	}
+
+	var errMsg string
+	if v, ok := tbl.RawGetString("error").(lua.LString); ok {
+		errMsg = string(v)
+	}
+
+	payloadTbl, ok := tbl.RawGetString("payload").(*lua.LTable)
+	if !ok {
+		return fmt.Errorf("context.payload must be a table")
+	}
+
+	raw, err := luaTableToMap(payloadTbl.RawGetString("raw"))
+	if err != nil {
+		return fmt.Errorf("context.payload.raw: %w", err)
+	}
+
+	metadata, err := luaTableToMap(payloadTbl.RawGetString("metadata"))
+	if err != nil {
+		return fmt.Errorf("context.payload.metadata: %w", err)
+	}
+
+	payload := Payload{
+		Raw:      raw,
+		Body:     lua.LVAsString(payloadTbl.RawGetString("body")),
+		Headers:  luaTableToStringMap(payloadTbl.RawGetString("headers")),
+		Query:    luaTableToStringMap(payloadTbl.RawGetString("query")),
+		Title:    lua.LVAsString(payloadTbl.RawGetString("title")),
+		Message:  lua.LVAsString(payloadTbl.RawGetString("message")),
+		Priority: int32(lua.LVAsNumber(payloadTbl.RawGetString("priority"))),
+		Logs:     []string{},
+		Metadata: metadata,
+	}
+
+	if logs, ok := payloadTbl.RawGetString("logs").(*lua.LTable); ok {
+		for i := 1; i <= logs.MaxN(); i++ {
+			payload.Logs = append(payload.Logs, lua.LVAsString(logs.RawGetInt(i)))
+		}
+	}
+
+	c.Action = action
+	c.Error = errMsg
+	c.Payload = payload
+
+	return nil
+}
+
+// luaTableToMap converts a Lua table into a Go map. Non-table values and
+// array-like tables result in an empty map.
+func luaTableToMap(lv lua.LValue) (map[string]any, error) {
+	v, err := luaValueToGo(lv)
+	if err != nil {
+		return nil, err
+	}
+
+	if m, ok := v.(map[string]any); ok {
+		return m, nil
+	}
+	return map[string]any{}, nil
+}
+
+func luaTableToStringMap(lv lua.LValue) map[string]string {
+	result := map[string]string{}
+
+	tbl, ok := lv.(*lua.LTable)
+	if !ok {
+		return result
+	}
+
+	tbl.ForEach(func(key, value lua.LValue) {
+		if keyStr, ok := key.(lua.LString); ok {
+			result[string(keyStr)] = lua.LVAsString(value)
+		}
+	})
+
+	return result
+}
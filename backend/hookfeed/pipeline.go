@@ -0,0 +1,87 @@
+package hookfeed
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// Pipeline runs the global middleware followed by the middleware of a feed. Script
+// names are resolved relative to the middleware directory.
+type Pipeline struct {
+	dir    string
+	global []string
+	cache  *ScriptCache
+	kv     KVStore
+}
+
+// NewPipeline creates a new Pipeline for the middleware directory and the global
+// middleware scripts in execution order. Every script run is bound by limits.
+// When kv is nil the kv table raises an error when used.
+func NewPipeline(dir string, global []string, limits Limits, kv KVStore) *Pipeline {
+	return &Pipeline{
+		dir:    dir,
+		global: global,
+		cache:  NewScriptCache(DefaultPoolSize, limits),
+		kv:     kv,
+	}
+}
+
+// Run executes the global middleware and then the middleware of the feed against
+// c and returns the action that ended the pipeline. Both stages share the kv
+// keys of the feed. The result is one of:
+//
+//   - ActionContinue when all stages completed
+//   - ActionAbort when a script requested the message be dropped
+//   - ActionBypass when a script requested adapters be skipped
+//
+// Script errors, and errors reported by a script through context.error, are
+// appended to the payload logs and processing continues with the next script.
+// Failed runs are logged as "middleware <script>: <kind>: <detail>" where kind
+// is one of the ErrorKind values.
+func (p *Pipeline) Run(ctx context.Context, feed string, middleware []string, c *Context) Action {
+	for _, stage := range [][]string{p.global, middleware} {
+		action := p.runStage(ctx, feed, stage, c)
+		switch action {
+		case ActionAbort, ActionBypass:
+			return action
+		default:
+			// skip only ends the current stage
+		}
+	}
+
+	c.Action = ActionContinue
+	return ActionContinue
+}
+
+func (p *Pipeline) runStage(ctx context.Context, feed string, scripts []string, c *Context) Action {
+	for _, script := range scripts {
+		c.Action = ActionContinue
+		c.Error = ""
+
+		err := NewMiddleware(p.resolve(script), p.cache).WithKV(p.kv, feed).Process(ctx, c)
+		if err != nil {
+			c.Payload.Logs = append(c.Payload.Logs, fmt.Sprintf("middleware %s: %v", script, err))
+			continue
+		}
+
+		if c.Error != "" {
+			c.Payload.Logs = append(c.Payload.Logs, fmt.Sprintf("middleware %s: %s", script, c.Error))
+		}
+
+		switch c.Action {
+		case ActionAbort, ActionBypass, ActionSkip:
+			return c.Action
+		default:
+		}
+	}
+
+	return ActionContinue
+}
+
+func (p *Pipeline) resolve(script string) string {
+	if filepath.IsAbs(script) || p.dir == "" {
+		return script
+	}
+	return filepath.Join(p.dir, script)
+}
@@ -0,0 +1,201 @@
+package hookfeed
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScripts writes each script into a temporary directory and returns the path
+func writeScripts(t *testing.T, scripts map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, src := range scripts {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644)
+		require.NoError(t, err)
+	}
+
+	return dir
+}
+
+// appendLog returns a script that appends name to the payload logs and sets the action
+func appendLog(name, action string) string {
+	return `
+function process(context)
+    table.insert(context.payload.logs, "` + name + `")
+    context.action = "` + action + `"
+    return context
+end
+`
+}
+
+func Test_Pipeline_Run(t *testing.T) {
+	t.Run("global then feed middleware in order", func(t *testing.T) {
+		dir := writeScripts(t, map[string]string{
+			"g1.lua": appendLog("g1", "continue"),
+			"g2.lua": appendLog("g2", "continue"),
+			"f1.lua": appendLog("f1", "continue"),
+		})
+
+		c := NewContext(nil, nil, nil)
+		action := NewPipeline(dir, []string{"g1.lua", "g2.lua"}, DefaultLimits, nil).Run(context.Background(), "feed", []string{"f1.lua"}, c)
+
+		assert.Equal(t, ActionContinue, action)
+		assert.Equal(t, []string{"g1", "g2", "f1"}, c.Payload.Logs)
+	})
+
+	t.Run("abort stops the pipeline", func(t *testing.T) {
+		dir := writeScripts(t, map[string]string{
+			"g1.lua": appendLog("g1", "abort"),
+			"f1.lua": appendLog("f1", "continue"),
+		})
+
+		c := NewContext(nil, nil, nil)
+		action := NewPipeline(dir, []string{"g1.lua"}, DefaultLimits, nil).Run(context.Background(), "feed", []string{"f1.lua"}, c)
+
+		assert.Equal(t, ActionAbort, action)
+		assert.Equal(t, []string{"g1"}, c.Payload.Logs)
+	})
+
+	t.Run("skip ends the current stage only", func(t *testing.T) {
+		dir := writeScripts(t, map[string]string{
+			"g1.lua": appendLog("g1", "skip"),
+			"g2.lua": appendLog("g2", "continue"),
+			"f1.lua": appendLog("f1", "continue"),
+		})
+
+		c := NewContext(nil, nil, nil)
+		action := NewPipeline(dir, []string{"g1.lua", "g2.lua"}, DefaultLimits, nil).Run(context.Background(), "feed", []string{"f1.lua"}, c)
+
+		assert.Equal(t, ActionContinue, action)
+		assert.Equal(t, []string{"g1", "f1"}, c.Payload.Logs)
+	})
+
+	t.Run("bypass ends all stages", func(t *testing.T) {
+		dir := writeScripts(t, map[string]string{
+			"g1.lua": appendLog("g1", "bypass"),
+			"f1.lua": appendLog("f1", "continue"),
+		})
+
+		c := NewContext(nil, nil, nil)
+		action := NewPipeline(dir, []string{"g1.lua"}, DefaultLimits, nil).Run(context.Background(), "feed", []string{"f1.lua"}, c)
+
+		assert.Equal(t, ActionBypass, action)
+		assert.Equal(t, []string{"g1"}, c.Payload.Logs)
+	})
+
+	t.Run("script errors are logged and processing continues", func(t *testing.T) {
+		dir := writeScripts(t, map[string]string{
+			"broken.lua":  `function process(context) error("boom") end`,
+			"missing.lua": `local x = 1`,
+			"reports.lua": `
+function process(context)
+    context.error = "bad payload"
+    return context
+end
+`,
+			"f1.lua": appendLog("f1", "continue"),
+		})
+
+		c := NewContext(nil, nil, nil)
+		action := NewPipeline(dir, []string{"broken.lua", "missing.lua", "reports.lua", "nope.lua"}, DefaultLimits, nil).
+			Run(context.Background(), "feed", []string{"f1.lua"}, c)
+
+		assert.Equal(t, ActionContinue, action)
+		require.Len(t, c.Payload.Logs, 5)
+		assert.Contains(t, c.Payload.Logs[0], "middleware broken.lua: runtime: failed to execute process function")
+		assert.Contains(t, c.Payload.Logs[1], "middleware missing.lua: runtime: process function not found")
+		assert.Equal(t, "middleware reports.lua: bad payload", c.Payload.Logs[2])
+		assert.Contains(t, c.Payload.Logs[3], "middleware nope.lua: load: failed to load lua script")
+		assert.Equal(t, "f1", c.Payload.Logs[4])
+	})
+}
+
+func Test_Middleware_Process(t *testing.T) {
+	dir := writeScripts(t, map[string]string{
+		"transform.lua": `
+function process(context)
+    local payload = context.payload
+    payload.title = "Build " .. payload.raw.build.id
+    payload.message = payload.headers["X-Event"]
+    payload.priority = 4
+    payload.metadata.branch = payload.raw.build.branch
+    return context
+end
+`,
+	})
+
+	body := map[string]any{
+		"build": map[string]any{"id": "42", "branch": "main"},
+	}
+	headers := http.Header{"X-Event": []string{"build.finished"}}
+
+	c := NewContext(body, headers, nil)
+	err := NewMiddleware(filepath.Join(dir, "transform.lua"), NewScriptCache(1, DefaultLimits)).Process(context.Background(), c)
+	require.NoError(t, err)
+
+	assert.Equal(t, ActionContinue, c.Action)
+	assert.Equal(t, "Build 42", c.Payload.Title)
+	assert.Equal(t, "build.finished", c.Payload.Message)
+	assert.Equal(t, int32(4), c.Payload.Priority)
+	assert.Equal(t, map[string]any{"branch": "main"}, c.Payload.Metadata)
+	assert.Equal(t, body, c.Payload.Raw)
+}
+
+func Test_Middleware_Process_TableCycle(t *testing.T) {
+	dir := writeScripts(t, map[string]string{
+		"cycle.lua": `
+function process(context)
+    local m = context.payload.metadata
+    m.self = m
+    return context
+end
+`,
+		"shared.lua": `
+function process(context)
+    local shared = { id = 1 }
+    context.payload.metadata.a = shared
+    context.payload.metadata.b = shared
+    return context
+end
+`,
+		"deep.lua": `
+function process(context)
+    local t = context.payload.metadata
+    for i = 1, 200 do
+        t.next = {}
+        t = t.next
+    end
+    return context
+end
+`,
+	})
+
+	cache := NewScriptCache(1, DefaultLimits)
+
+	for _, name := range []string{"cycle.lua", "deep.lua"} {
+		t.Run(name, func(t *testing.T) {
+			c := NewContext(map[string]any{}, http.Header{}, nil)
+			err := NewMiddleware(filepath.Join(dir, name), cache).Process(context.Background(), c)
+
+			var serr *ScriptError
+			require.ErrorAs(t, err, &serr)
+			assert.Equal(t, ErrorKindRuntime, serr.Kind)
+			assert.Empty(t, c.Payload.Metadata, "context should be left unmodified")
+		})
+	}
+
+	t.Run("shared table", func(t *testing.T) {
+		c := NewContext(map[string]any{}, http.Header{}, nil)
+		err := NewMiddleware(filepath.Join(dir, "shared.lua"), cache).Process(context.Background(), c)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"id": float64(1)}, c.Payload.Metadata["a"])
+		assert.Equal(t, map[string]any{"id": float64(1)}, c.Payload.Metadata["b"])
+	})
+}
@@ -0,0 +1,476 @@
+package hookfeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Limits bounds the resources a single script run may use. A zero value for any
+// field disables that limit.
+//
+// Lua does not expose an allocator hook, so allocation is bounded indirectly:
+// MaxMemory caps the growth of the Go heap while a script runs, MaxStackSize and
+// MaxCallDepth cap the size of the Lua stack, and MaxStringSize caps strings
+// built in a single call such as string.rep, which cannot be interrupted. The
+// heap is shared by the process, so concurrent work counts against MaxMemory too.
+type Limits struct {
+	Timeout         time.Duration // wall-clock limit for a single run
+	MaxInstructions int64         // VM instructions per run
+	MaxMemory       uint64        // bytes the heap may grow by during a run
+	MaxStackSize    int           // values held on the Lua stack
+	MaxCallDepth    int           // nested function calls
+	MaxStringSize   int           // bytes produced by a single string.rep, string.format or table.concat call
+}
+
+// DefaultLimits are the limits applied when none are configured
+var DefaultLimits = Limits{
+	Timeout:         2 * time.Second,
+	MaxInstructions: 10_000_000,
+	MaxMemory:       128 << 20,
+	MaxStackSize:    256 * 1024,
+	MaxCallDepth:    200,
+	MaxStringSize:   1 << 20,
+}
+
+// ErrorKind classifies errors returned from running a script
+type ErrorKind string
+
+const (
+	ErrorKindLoad             ErrorKind = "load"              // script could not be read or compiled
+	ErrorKindRuntime          ErrorKind = "runtime"           // script raised an error or returned an invalid value
+	ErrorKindTimeout          ErrorKind = "timeout"           // run exceeded Limits.Timeout or the request was cancelled
+	ErrorKindInstructionLimit ErrorKind = "instruction_limit" // run exceeded Limits.MaxInstructions
+	ErrorKindMemoryLimit      ErrorKind = "memory_limit"      // run exceeded a memory, stack, call depth or string size limit
+	ErrorKindForbidden        ErrorKind = "forbidden"         // script called a function removed by the sandbox
+)
+
+// ScriptError is returned when a script fails to load or run
+type ScriptError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *ScriptError) Error() string {
+	return string(e.Kind) + ": " + e.Err.Error()
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+func newScriptError(kind ErrorKind, format string, args ...any) *ScriptError {
+	return &ScriptError{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+var (
+	// ErrInstructionBudget is the context error of a run that exceeded its instruction budget
+	ErrInstructionBudget = errors.New("instruction budget exceeded")
+	// ErrMemoryBudget is the context error of a run that exceeded its memory budget
+	ErrMemoryBudget = errors.New("memory budget exceeded")
+)
+
+// memoryCheckInterval is how often the heap is sampled during a run. Building a
+// string takes time proportional to its size, so a run cannot grow the heap far
+// past its budget between two samples.
+const memoryCheckInterval = time.Millisecond
+
+// sandboxLibs are the libraries opened in every state. io, package, debug,
+// channel and coroutine are never opened.
+var sandboxLibs = []struct {
+	name string
+	fn   lua.LGFunction
+}{
+	{lua.BaseLibName, lua.OpenBase},
+	{lua.TabLibName, lua.OpenTable},
+	{lua.StringLibName, lua.OpenString},
+	{lua.MathLibName, lua.OpenMath},
+	{lua.OsLibName, lua.OpenOs},
+}
+
+// removedGlobals are replaced with functions that raise a forbidden error
+var removedGlobals = []string{"dofile", "load", "loadfile", "loadstring", "require", "module", "_printregs"}
+
+// removedCoroutineFuncs are the functions of the coroutine table, all of them
+// raise a forbidden error. gopher-lua runs a coroutine under its own context
+// derived from the state's, so its instructions and allocations would not
+// count against the budgets of the run.
+var removedCoroutineFuncs = []string{"create", "resume", "running", "status", "wrap", "yield"}
+
+// allowedOsFuncs are the functions kept in the os table
+var allowedOsFuncs = map[string]bool{"clock": true, "date": true, "difftime": true, "time": true}
+
+// newSandboxState creates a state with only the whitelisted libraries and the
+// hookfeed helpers opened, and the stack bounded by limits
+func newSandboxState(limits Limits) *lua.LState {
+	opts := lua.Options{
+		SkipOpenLibs:    true,
+		CallStackSize:   limits.MaxCallDepth,
+		RegistryMaxSize: limits.MaxStackSize,
+	}
+	if opts.RegistryMaxSize > 0 {
+		opts.RegistrySize = min(lua.RegistrySize, opts.RegistryMaxSize)
+	}
+
+	L := lua.NewState(opts)
+
+	for _, lib := range sandboxLibs {
+		L.Push(L.NewFunction(lib.fn))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	for _, name := range removedGlobals {
+		L.SetGlobal(name, forbidden(L, name))
+	}
+
+	coTbl := L.NewTable()
+	for _, name := range removedCoroutineFuncs {
+		coTbl.RawSetString(name, forbidden(L, "coroutine."+name))
+	}
+	L.SetGlobal(lua.CoroutineLibName, coTbl)
+
+	if osTbl, ok := L.GetGlobal(lua.OsLibName).(*lua.LTable); ok {
+		removed := []string{}
+		osTbl.ForEach(func(key, _ lua.LValue) {
+			if !allowedOsFuncs[key.String()] {
+				removed = append(removed, key.String())
+			}
+		})
+
+		for _, name := range removed {
+			osTbl.RawSetString(name, forbidden(L, "os."+name))
+		}
+	}
+
+	openLib(L)
+	openKV(L)
+
+	if limits.MaxStringSize > 0 {
+		if strTbl, ok := L.GetGlobal(lua.StringLibName).(*lua.LTable); ok {
+			strTbl.RawSetString("rep", L.NewFunction(limitedStringRep(limits.MaxStringSize)))
+			if format, ok := strTbl.RawGetString("format").(*lua.LFunction); ok {
+				strTbl.RawSetString("format", L.NewFunction(limitedStringFormat(limits.MaxStringSize, format.GFunction)))
+			}
+		}
+
+		if tabTbl, ok := L.GetGlobal(lua.TabLibName).(*lua.LTable); ok {
+			if concat, ok := tabTbl.RawGetString("concat").(*lua.LFunction); ok {
+				tabTbl.RawSetString("concat", L.NewFunction(limitedTableConcat(limits.MaxStringSize, concat.GFunction)))
+			}
+		}
+	}
+
+	return L
+}
+
+// forbidden returns a function that raises a forbidden error when called
+func forbidden(L *lua.LState, name string) *lua.LFunction {
+	return L.NewFunction(func(L *lua.LState) int {
+		raiseViolation(L, newScriptError(ErrorKindForbidden, "%s is not available in the sandbox", name))
+		return 0
+	})
+}
+
+// limitedStringRep is string.rep with the size of the result capped at limit bytes
+func limitedStringRep(limit int) lua.LGFunction {
+	return func(L *lua.LState) int {
+		str := L.CheckString(1)
+		n := L.CheckInt(2)
+		sep := L.OptString(3, "")
+
+		if n <= 0 {
+			L.Push(lua.LString(""))
+			return 1
+		}
+
+		if size := len(str)*n + len(sep)*(n-1); size > limit || size < 0 {
+			raiseViolation(L, newScriptError(ErrorKindMemoryLimit, "string.rep result exceeds %d bytes", limit))
+			return 0
+		}
+
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = str
+		}
+
+		L.Push(lua.LString(strings.Join(parts, sep)))
+		return 1
+	}
+}
+
+// limitedStringFormat is string.format with the size of the result capped at
+// limit bytes. The size is estimated from the format before fn builds it.
+func limitedStringFormat(limit int, fn lua.LGFunction) lua.LGFunction {
+	return func(L *lua.LState) int {
+		format := L.CheckString(1)
+
+		args := make([]lua.LValue, L.GetTop()-1)
+		for i := range args {
+			args[i] = L.Get(i + 2)
+		}
+
+		if size := formatSize(format, args); size > limit || size < 0 {
+			raiseViolation(L, newScriptError(ErrorKindMemoryLimit, "string.format result exceeds %d bytes", limit))
+			return 0
+		}
+
+		return fn(L)
+	}
+}
+
+// formatSize returns an upper bound of the length of a Go format applied to
+// args, as string.format does
+func formatSize(format string, args []lua.LValue) int {
+	size, next := 0, 0
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			size++
+			continue
+		}
+
+		i++
+		for i < len(format) && strings.IndexByte("+- #0", format[i]) >= 0 {
+			i++
+		}
+
+		// Explicit argument indexes select the argument of the verb
+		if i < len(format) && format[i] == '[' {
+			end := strings.IndexByte(format[i:], ']')
+			if end < 0 {
+				break
+			}
+			if n, err := strconv.Atoi(format[i+1 : i+end]); err == nil {
+				next = n - 1
+			}
+			i += end + 1
+		}
+
+		var width, precision int
+		width, i = formatNumber(format, i)
+		if i < len(format) && format[i] == '.' {
+			precision, i = formatNumber(format, i+1)
+		}
+		size += width + precision
+
+		if i >= len(format) {
+			break
+		}
+
+		verb := format[i]
+		if verb == '%' {
+			size++
+			continue
+		}
+
+		// Numbers and the names of tables and functions are short
+		argSize := 32
+		if next >= 0 && next < len(args) {
+			if str, ok := args[next].(lua.LString); ok {
+				argSize = len(str)
+			}
+		}
+		next++
+
+		switch verb {
+		case 'q':
+			argSize = argSize*4 + 2
+		case 'x', 'X':
+			argSize *= 2
+		}
+		size += argSize
+	}
+
+	return size
+}
+
+// formatNumber parses the width or precision of a format verb at i, fmt
+// rejects values above one million
+func formatNumber(format string, i int) (int, int) {
+	n := 0
+	for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+		if n <= 1_000_000 {
+			n = n*10 + int(format[i]-'0')
+		}
+		i++
+	}
+	return n, i
+}
+
+// limitedTableConcat is table.concat with the size of the result capped at
+// limit bytes. The size is summed over the same range fn joins.
+func limitedTableConcat(limit int, fn lua.LGFunction) lua.LGFunction {
+	return func(L *lua.LState) int {
+		tbl := L.CheckTable(1)
+		sep := L.OptString(2, "")
+		first := max(L.OptInt(3, 1), 1)
+		last := min(L.OptInt(4, tbl.Len()), tbl.Len())
+
+		size := 0
+		for i := first; i <= last; i++ {
+			size += len(lua.LVAsString(tbl.RawGetInt(i)))
+			if i != last {
+				size += len(sep)
+			}
+
+			if size > limit {
+				raiseViolation(L, newScriptError(ErrorKindMemoryLimit, "table.concat result exceeds %d bytes", limit))
+				return 0
+			}
+		}
+
+		return fn(L)
+	}
+}
+
+// raiseViolation raises err as a Lua error so that it can be recovered by
+// classifyError after PCall returns
+func raiseViolation(L *lua.LState, err *ScriptError) {
+	ud := L.NewUserData()
+	ud.Value = err
+	L.Error(ud, 1)
+}
+
+// budgetContext is a context that is done once Done has been called more than
+// budget times or a budget was exceeded otherwise. gopher-lua checks Done once
+// per VM instruction when a context is set, which makes it a cheap instruction
+// counter.
+type budgetContext struct {
+	context.Context
+	remaining atomic.Int64 // instructions left, unlimited when limited is false
+	limited   bool
+	err       atomic.Pointer[error]
+	exceeded  chan struct{}
+	once      sync.Once
+}
+
+func newBudgetContext(ctx context.Context, instructions int64) *budgetContext {
+	b := &budgetContext{Context: ctx, limited: instructions > 0, exceeded: make(chan struct{})}
+	b.remaining.Store(instructions)
+	return b
+}
+
+func (b *budgetContext) Done() <-chan struct{} {
+	if b.err.Load() != nil {
+		return b.exceeded
+	}
+
+	if b.limited && b.remaining.Add(-1) < 0 {
+		b.exceed(ErrInstructionBudget)
+		return b.exceeded
+	}
+
+	return b.Context.Done()
+}
+
+func (b *budgetContext) Err() error {
+	if err := b.err.Load(); err != nil {
+		return *err
+	}
+	return b.Context.Err()
+}
+
+// exceed marks the context done with err, the first error is kept
+func (b *budgetContext) exceed(err error) {
+	b.once.Do(func() {
+		b.err.Store(&err)
+		close(b.exceeded)
+	})
+}
+
+// watchMemory exceeds the context once the heap grew by more than limit bytes
+// since the call, until stop is called
+func (b *budgetContext) watchMemory(limit uint64) (stop func()) {
+	start := heapBytes()
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(memoryCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if heap := heapBytes(); heap > start && heap-start > limit {
+					b.exceed(ErrMemoryBudget)
+					return
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// heapBytes returns the size of the objects on the heap, including those not
+// yet freed by the garbage collector
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	return sample[0].Value.Uint64()
+}
+
+// runContext derives the context a single run executes under from the request
+// context
+func (l Limits) runContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+	if l.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, l.Timeout)
+	}
+
+	if l.MaxInstructions > 0 || l.MaxMemory > 0 {
+		bctx := newBudgetContext(ctx, l.MaxInstructions)
+		if l.MaxMemory > 0 {
+			stop, cancelTimeout := bctx.watchMemory(l.MaxMemory), cancel
+			cancel = func() {
+				stop()
+				cancelTimeout()
+			}
+		}
+		ctx = bctx
+	}
+
+	return ctx, cancel
+}
+
+// classifyError converts an error from PCall into a ScriptError using the state
+// of the run context
+func (l Limits) classifyError(ctx context.Context, err error) *ScriptError {
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) {
+		if ud, ok := apiErr.Object.(*lua.LUserData); ok {
+			if serr, ok := ud.Value.(*ScriptError); ok {
+				return serr
+			}
+		}
+	}
+
+	switch ctxErr := ctx.Err(); {
+	case errors.Is(ctxErr, ErrInstructionBudget):
+		return newScriptError(ErrorKindInstructionLimit, "exceeded %d instructions", l.MaxInstructions)
+	case errors.Is(ctxErr, ErrMemoryBudget):
+		return newScriptError(ErrorKindMemoryLimit, "heap grew by more than %d bytes", l.MaxMemory)
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		return newScriptError(ErrorKindTimeout, "exceeded %s", l.Timeout)
+	case errors.Is(ctxErr, context.Canceled):
+		return newScriptError(ErrorKindTimeout, "request cancelled")
+	}
+
+	msg := err.Error()
+	if strings.Contains(msg, "stack overflow") || strings.Contains(msg, "registry overflow") {
+		return newScriptError(ErrorKindMemoryLimit, "%w", err)
+	}
+
+	return &ScriptError{Kind: ErrorKindRuntime, Err: err}
+}
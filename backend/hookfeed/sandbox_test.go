@@ -0,0 +1,167 @@
+package hookfeed
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Sandbox_Violations(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		limits Limits
+		kind   ErrorKind
+	}{
+		{
+			name:   "infinite loop hits instruction budget",
+			script: `function process(context) while true do end end`,
+			limits: Limits{MaxInstructions: 10_000},
+			kind:   ErrorKindInstructionLimit,
+		},
+		{
+			name:   "infinite loop hits timeout",
+			script: `function process(context) while true do end end`,
+			limits: Limits{Timeout: 20 * time.Millisecond},
+			kind:   ErrorKindTimeout,
+		},
+		{
+			name:   "pcall does not swallow limits",
+			script: `function process(context) while true do pcall(function() while true do end end) end end`,
+			limits: Limits{MaxInstructions: 10_000},
+			kind:   ErrorKindInstructionLimit,
+		},
+		{
+			name:   "unbounded recursion hits call depth",
+			script: `local function f() return 1 + f() end function process(context) return f() end`,
+			limits: Limits{MaxCallDepth: 50},
+			kind:   ErrorKindMemoryLimit,
+		},
+		{
+			name:   "large string.rep",
+			script: `function process(context) local s = string.rep("x", 1024 * 1024) return context end`,
+			limits: Limits{MaxStringSize: 1024},
+			kind:   ErrorKindMemoryLimit,
+		},
+		{
+			name:   "large string.format width",
+			script: `function process(context) local s = string.format("%999999s%999999s", "a", "b") return context end`,
+			limits: Limits{MaxStringSize: 1024},
+			kind:   ErrorKindMemoryLimit,
+		},
+		{
+			name:   "large table.concat",
+			script: `function process(context) local t = {} for i = 1, 100 do t[i] = string.rep("x", 100) end table.concat(t) return context end`,
+			limits: Limits{MaxStringSize: 1024},
+			kind:   ErrorKindMemoryLimit,
+		},
+		{
+			name:   "concatenation loop hits memory budget",
+			script: `function process(context) local s = "x" for i = 1, 40 do s = s .. s end return context end`,
+			limits: Limits{MaxMemory: 16 << 20},
+			kind:   ErrorKindMemoryLimit,
+		},
+		{
+			name:   "os.execute is removed",
+			script: `function process(context) os.execute("true") return context end`,
+			limits: DefaultLimits,
+			kind:   ErrorKindForbidden,
+		},
+		{
+			name:   "loadfile is removed",
+			script: `function process(context) loadfile("/etc/passwd") return context end`,
+			limits: DefaultLimits,
+			kind:   ErrorKindForbidden,
+		},
+		{
+			name:   "load is removed",
+			script: `function process(context) load("return 1") return context end`,
+			limits: DefaultLimits,
+			kind:   ErrorKindForbidden,
+		},
+		{
+			name:   "loadstring is removed",
+			script: `function process(context) loadstring("return 1") return context end`,
+			limits: DefaultLimits,
+			kind:   ErrorKindForbidden,
+		},
+		{
+			name:   "looping coroutine is removed",
+			script: `function process(context) coroutine.wrap(function() while true do end end)() return context end`,
+			limits: Limits{MaxInstructions: 10_000},
+			kind:   ErrorKindForbidden,
+		},
+		{
+			name:   "allocating coroutine is removed",
+			script: `function process(context) local co = coroutine.create(function() local s = "x" for i = 1, 40 do s = s .. s end end) coroutine.resume(co) return context end`,
+			limits: Limits{MaxMemory: 16 << 20},
+			kind:   ErrorKindForbidden,
+		},
+		{
+			name:   "io is not opened",
+			script: `function process(context) io.open("/etc/passwd") return context end`,
+			limits: DefaultLimits,
+			kind:   ErrorKindRuntime,
+		},
+		{
+			name:   "top level loop fails to load",
+			script: `while true do end`,
+			limits: Limits{MaxInstructions: 10_000},
+			kind:   ErrorKindInstructionLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeScripts(t, map[string]string{"script.lua": tt.script})
+			mw := NewMiddleware(filepath.Join(dir, "script.lua"), NewScriptCache(1, tt.limits))
+
+			c := NewContext(nil, nil, nil)
+			err := mw.Process(context.Background(), c)
+			require.Error(t, err)
+
+			var serr *ScriptError
+			require.ErrorAs(t, err, &serr)
+			assert.Equal(t, tt.kind, serr.Kind, err.Error())
+		})
+	}
+}
+
+func Test_Sandbox_RequestCancelled(t *testing.T) {
+	dir := writeScripts(t, map[string]string{"script.lua": `function process(context) while true do end end`})
+	mw := NewMiddleware(filepath.Join(dir, "script.lua"), NewScriptCache(1, Limits{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := mw.Process(ctx, NewContext(nil, nil, nil))
+
+	var serr *ScriptError
+	require.ErrorAs(t, err, &serr)
+	assert.Equal(t, ErrorKindTimeout, serr.Kind)
+}
+
+func Test_Sandbox_AllowedFunctions(t *testing.T) {
+	dir := writeScripts(t, map[string]string{
+		"script.lua": `
+function process(context)
+    local t = os.time()
+    local d = os.date("!%Y", 0)
+    context.payload.title = string.format("%s-%s", table.concat({ string.rep("a", 3) }), d)
+    context.payload.priority = math.floor(t / t)
+    return context
+end
+`,
+	})
+
+	mw := NewMiddleware(filepath.Join(dir, "script.lua"), NewScriptCache(1, DefaultLimits))
+
+	c := NewContext(nil, nil, nil)
+	require.NoError(t, mw.Process(context.Background(), c))
+	assert.Equal(t, "aaa-1970", c.Payload.Title)
+	assert.Equal(t, int32(1), c.Payload.Priority)
+}
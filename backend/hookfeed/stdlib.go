@@ -0,0 +1,390 @@
+package hookfeed
+
+import (
+	"container/list"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// LibName is the name of the global table holding the hookfeed helper functions
+const LibName = "hookfeed"
+
+// contextRegistryKey is the registry field holding the context table of the
+// current run. The registry is not reachable from sandboxed scripts.
+const contextRegistryKey = "hookfeed.context"
+
+// levelPriorities maps the message levels accepted by set_level to priorities
+var levelPriorities = map[string]int{
+	"debug":   1,
+	"info":    3,
+	"success": 3,
+	"warning": 4,
+	"error":   5,
+}
+
+var libFuncs = map[string]lua.LGFunction{
+	"json_decode":    libJSONDecode,
+	"json_encode":    libJSONEncode,
+	"add_log":        libAddLog,
+	"set_level":      libSetLevel,
+	"base64_encode":  libBase64Encode,
+	"base64_decode":  libBase64Decode,
+	"hex_encode":     libHexEncode,
+	"hex_decode":     libHexDecode,
+	"hmac_sha256":    libHMACSHA256,
+	"secure_compare": libSecureCompare,
+	"regex_match":    libRegexMatch,
+	"regex_replace":  libRegexReplace,
+	"time_parse":     libTimeParse,
+	"time_format":    libTimeFormat,
+	"url_parse":      libURLParse,
+	"url_encode":     libURLEncode,
+	"url_decode":     libURLDecode,
+}
+
+// globalFuncs are also exposed as globals for the helpers documented in DESIGN.md
+var globalFuncs = []string{"json_decode", "json_encode", "add_log", "set_level"}
+
+// openLib registers the hookfeed table and the global helper functions
+func openLib(L *lua.LState) {
+	mod := L.RegisterModule(LibName, libFuncs).(*lua.LTable)
+
+	for _, name := range globalFuncs {
+		L.SetGlobal(name, mod.RawGetString(name))
+	}
+}
+
+// setCurrentContext makes tbl the target of add_log and set_level. Passing
+// lua.LNil clears it.
+func setCurrentContext(L *lua.LState, tbl lua.LValue) {
+	L.SetField(L.Get(lua.RegistryIndex), contextRegistryKey, tbl)
+}
+
+// currentPayload returns the payload table of the current run or raises an error
+func currentPayload(L *lua.LState) *lua.LTable {
+	ctx, ok := L.GetField(L.Get(lua.RegistryIndex), contextRegistryKey).(*lua.LTable)
+	if !ok {
+		L.RaiseError("no active middleware context")
+		return nil
+	}
+
+	payload, ok := ctx.RawGetString("payload").(*lua.LTable)
+	if !ok {
+		L.RaiseError("context.payload must be a table")
+		return nil
+	}
+
+	return payload
+}
+
+func libJSONDecode(L *lua.LState) int {
+	var v any
+	if err := json.Unmarshal([]byte(L.CheckString(1)), &v); err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	L.Push(goToLuaValue(L, v))
+	return 1
+}
+
+func libJSONEncode(L *lua.LState) int {
+	v, err := luaValueToGo(L.CheckAny(1))
+	if err != nil {
+		L.RaiseError("json_encode: %s", err.Error())
+		return 0
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	L.Push(lua.LString(data))
+	return 1
+}
+
+func libAddLog(L *lua.LState) int {
+	line := L.CheckString(1)
+	payload := currentPayload(L)
+
+	logs, ok := payload.RawGetString("logs").(*lua.LTable)
+	if !ok {
+		logs = L.NewTable()
+		payload.RawSetString("logs", logs)
+	}
+
+	logs.Append(lua.LString(line))
+	return 0
+}
+
+func libSetLevel(L *lua.LState) int {
+	level := strings.ToLower(L.CheckString(1))
+
+	priority, ok := levelPriorities[level]
+	if !ok {
+		L.ArgError(1, "unknown level '"+level+"'")
+		return 0
+	}
+
+	currentPayload(L).RawSetString("priority", lua.LNumber(priority))
+	return 0
+}
+
+func libBase64Encode(L *lua.LState) int {
+	enc := base64.StdEncoding
+	if L.OptBool(2, false) {
+		enc = base64.URLEncoding
+	}
+
+	L.Push(lua.LString(enc.EncodeToString([]byte(L.CheckString(1)))))
+	return 1
+}
+
+func libBase64Decode(L *lua.LState) int {
+	enc := base64.StdEncoding
+	if L.OptBool(2, false) {
+		enc = base64.URLEncoding
+	}
+
+	data, err := enc.DecodeString(L.CheckString(1))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	L.Push(lua.LString(data))
+	return 1
+}
+
+func libHexEncode(L *lua.LState) int {
+	L.Push(lua.LString(hex.EncodeToString([]byte(L.CheckString(1)))))
+	return 1
+}
+
+func libHexDecode(L *lua.LState) int {
+	data, err := hex.DecodeString(L.CheckString(1))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	L.Push(lua.LString(data))
+	return 1
+}
+
+// libHMACSHA256 returns the HMAC-SHA256 of message using key. The digest is hex
+// encoded unless the encoding argument is "base64" or "raw".
+func libHMACSHA256(L *lua.LState) int {
+	key := L.CheckString(1)
+	message := L.CheckString(2)
+	encoding := L.OptString(3, "hex")
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	sum := mac.Sum(nil)
+
+	switch encoding {
+	case "hex":
+		L.Push(lua.LString(hex.EncodeToString(sum)))
+	case "base64":
+		L.Push(lua.LString(base64.StdEncoding.EncodeToString(sum)))
+	case "raw":
+		L.Push(lua.LString(sum))
+	default:
+		L.ArgError(3, "encoding must be one of hex, base64 or raw")
+	}
+
+	return 1
+}
+
+// libSecureCompare compares two strings in constant time
+func libSecureCompare(L *lua.LState) int {
+	a := L.CheckString(1)
+	b := L.CheckString(2)
+
+	L.Push(lua.LBool(subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1))
+	return 1
+}
+
+// regexCacheSize is the number of compiled patterns kept by regexCache
+const regexCacheSize = 256
+
+// regexCache holds compiled patterns shared across states. Scripts can build
+// patterns from payload data, so the least recently used pattern is evicted
+// once the cache is full.
+var regexCache = newRegexLRU(regexCacheSize)
+
+// regexLRU is a fixed size cache of compiled patterns
+type regexLRU struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List               // most recently used first, values are *regexEntry
+	entries map[string]*list.Element // pattern => element of order
+}
+
+type regexEntry struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newRegexLRU(size int) *regexLRU {
+	return &regexLRU{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *regexLRU) get(pattern string) (*regexp.Regexp, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[pattern]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	return el.Value.(*regexEntry).re, true
+}
+
+func (c *regexLRU) add(pattern string, re *regexp.Regexp) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[pattern]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[pattern] = c.order.PushFront(&regexEntry{pattern: pattern, re: re})
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*regexEntry).pattern)
+	}
+}
+
+func compileRegex(L *lua.LState, pattern string) *regexp.Regexp {
+	if re, ok := regexCache.get(pattern); ok {
+		return re
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		L.ArgError(1, err.Error())
+		return nil
+	}
+
+	regexCache.add(pattern, re)
+	return re
+}
+
+// libRegexMatch returns a table holding the full match followed by the capture
+// groups, or nil when the pattern does not match. Patterns use Go RE2 syntax.
+func libRegexMatch(L *lua.LState) int {
+	re := compileRegex(L, L.CheckString(1))
+
+	matches := re.FindStringSubmatch(L.CheckString(2))
+	if matches == nil {
+		L.Push(lua.LNil)
+		return 1
+	}
+
+	tbl := L.NewTable()
+	for _, m := range matches {
+		tbl.Append(lua.LString(m))
+	}
+
+	L.Push(tbl)
+	return 1
+}
+
+func libRegexReplace(L *lua.LState) int {
+	re := compileRegex(L, L.CheckString(1))
+	L.Push(lua.LString(re.ReplaceAllString(L.CheckString(2), L.CheckString(3))))
+	return 1
+}
+
+// libTimeParse parses an RFC3339 timestamp and returns the unix time in seconds
+func libTimeParse(L *lua.LState) int {
+	t, err := time.Parse(time.RFC3339Nano, L.CheckString(1))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	L.Push(lua.LNumber(float64(t.UnixNano()) / float64(time.Second)))
+	return 1
+}
+
+// libTimeFormat formats a unix time in seconds as an RFC3339 timestamp in UTC
+func libTimeFormat(L *lua.LState) int {
+	secs := float64(L.CheckNumber(1))
+	t := time.Unix(0, int64(secs*float64(time.Second))).UTC()
+
+	L.Push(lua.LString(t.Format(time.RFC3339)))
+	return 1
+}
+
+// libURLParse parses a URL into a table with scheme, user, host, hostname,
+// port, path, query, raw_query and fragment fields. Query values are reduced to
+// their first value.
+func libURLParse(L *lua.LState) int {
+	u, err := url.Parse(L.CheckString(1))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	tbl := L.NewTable()
+	tbl.RawSetString("scheme", lua.LString(u.Scheme))
+	tbl.RawSetString("user", lua.LString(u.User.Username()))
+	tbl.RawSetString("host", lua.LString(u.Host))
+	tbl.RawSetString("hostname", lua.LString(u.Hostname()))
+	tbl.RawSetString("port", lua.LString(u.Port()))
+	tbl.RawSetString("path", lua.LString(u.Path))
+	tbl.RawSetString("raw_query", lua.LString(u.RawQuery))
+	tbl.RawSetString("query", stringMapToLuaTable(L, firstValues(u.Query())))
+	tbl.RawSetString("fragment", lua.LString(u.Fragment))
+
+	L.Push(tbl)
+	return 1
+}
+
+func libURLEncode(L *lua.LState) int {
+	L.Push(lua.LString(url.QueryEscape(L.CheckString(1))))
+	return 1
+}
+
+func libURLDecode(L *lua.LState) int {
+	s, err := url.QueryUnescape(L.CheckString(1))
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+
+	L.Push(lua.LString(s))
+	return 1
+}
@@ -0,0 +1,150 @@
+package hookfeed
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// evalLib runs src in a sandboxed state and returns the value of its first return
+func evalLib(t *testing.T, src string) lua.LValue {
+	t.Helper()
+
+	L := newSandboxState(DefaultLimits)
+	defer L.Close()
+
+	require.NoError(t, L.DoString(src))
+	return L.Get(1)
+}
+
+func Test_Lib_Functions(t *testing.T) {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("hello"))
+	wantHMAC := hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name string
+		src  string
+		want lua.LValue
+	}{
+		{"base64 round trip", `return hookfeed.base64_decode(hookfeed.base64_encode("hi?>"))`, lua.LString("hi?>")},
+		{"base64 url", `return hookfeed.base64_encode("hi?>", true)`, lua.LString("aGk_Pg==")},
+		{"base64 invalid", `local v, err = hookfeed.base64_decode("!!"); return v == nil and err ~= nil`, lua.LTrue},
+		{"hex encode", `return hookfeed.hex_encode("hi")`, lua.LString("6869")},
+		{"hex decode", `return hookfeed.hex_decode("6869")`, lua.LString("hi")},
+		{"hmac hex", `return hookfeed.hmac_sha256("secret", "hello")`, lua.LString(wantHMAC)},
+		{"hmac raw", `return hookfeed.hex_encode(hookfeed.hmac_sha256("secret", "hello", "raw"))`, lua.LString(wantHMAC)},
+		{"secure compare equal", `return hookfeed.secure_compare("abc", "abc")`, lua.LTrue},
+		{"secure compare differs", `return hookfeed.secure_compare("abc", "abd")`, lua.LFalse},
+		{"regex match groups", `local m = hookfeed.regex_match("^v(\\d+)\\.(\\d+)$", "v1.2"); return m[1] .. "|" .. m[2] .. "|" .. m[3]`, lua.LString("v1.2|1|2")},
+		{"regex no match", `return hookfeed.regex_match("^x", "abc")`, lua.LNil},
+		{"regex replace", `return hookfeed.regex_replace("\\s+", "a   b  c", " ")`, lua.LString("a b c")},
+		{"time parse", `return hookfeed.time_parse("2024-01-02T15:04:05Z")`, lua.LNumber(1704207845)},
+		{"time format", `return hookfeed.time_format(1704207845)`, lua.LString("2024-01-02T15:04:05Z")},
+		{"time parse invalid", `local v, err = hookfeed.time_parse("yesterday"); return v == nil and err ~= nil`, lua.LTrue},
+		{"url parse", `local u = hookfeed.url_parse("https://bob@example.com:8080/a/b?x=1&x=2#top"); return table.concat({u.scheme, u.user, u.hostname, u.port, u.path, u.query.x, u.fragment}, "|")`, lua.LString("https|bob|example.com|8080|/a/b|1|top")},
+		{"url encode", `return hookfeed.url_encode("a b&c")`, lua.LString("a+b%26c")},
+		{"url decode", `return hookfeed.url_decode("a+b%26c")`, lua.LString("a b&c")},
+		{"json round trip", `return json_decode(json_encode({ n = 1 })).n`, lua.LNumber(1)},
+		{"json decode invalid", `local v, err = json_decode("{"); return v == nil and err ~= nil`, lua.LTrue},
+		{"globals alias module", `return json_decode == hookfeed.json_decode`, lua.LTrue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, evalLib(t, tt.src))
+		})
+	}
+}
+
+func Test_Lib_JSONEncodeCycle(t *testing.T) {
+	L := newSandboxState(DefaultLimits)
+	defer L.Close()
+
+	err := L.DoString(`local t = {} t.self = t return json_encode(t)`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "json_encode: "+ErrTableCycle.Error())
+}
+
+func Test_Lib_ContextHelpers(t *testing.T) {
+	dir := writeScripts(t, map[string]string{
+		"helpers.lua": `
+function process(context)
+    add_log("checked")
+    set_level("warning")
+    local sig = hookfeed.hmac_sha256("secret", context.payload.body)
+    context.payload.metadata.valid = hookfeed.secure_compare(sig, context.payload.headers["X-Signature"])
+    return context
+end
+`,
+		"badlevel.lua": `
+function process(context)
+    set_level("loud")
+    return context
+end
+`,
+	})
+
+	cache := NewScriptCache(1, DefaultLimits)
+
+	body := `{"hello":"world"}`
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body))
+
+	c := NewContext(nil, map[string][]string{"X-Signature": {hex.EncodeToString(mac.Sum(nil))}}, nil)
+	c.Payload.Body = body
+
+	err := NewMiddleware(filepath.Join(dir, "helpers.lua"), cache).Process(context.Background(), c)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"checked"}, c.Payload.Logs)
+	assert.Equal(t, int32(4), c.Payload.Priority)
+	assert.Equal(t, true, c.Payload.Metadata["valid"])
+	assert.Equal(t, body, c.Payload.Body)
+
+	err = NewMiddleware(filepath.Join(dir, "badlevel.lua"), cache).Process(context.Background(), NewContext(nil, nil, nil))
+	var serr *ScriptError
+	require.ErrorAs(t, err, &serr)
+	assert.Equal(t, ErrorKindRuntime, serr.Kind)
+	assert.Contains(t, serr.Error(), "unknown level 'loud'")
+
+	t.Run("outside a run", func(t *testing.T) {
+		L := newSandboxState(DefaultLimits)
+		defer L.Close()
+
+		err := L.DoString(`add_log("x")`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no active middleware context")
+	})
+}
+
+func Test_RegexLRU(t *testing.T) {
+	c := newRegexLRU(2)
+
+	c.add("a", regexp.MustCompile("a"))
+	c.add("b", regexp.MustCompile("b"))
+
+	// Using a moves b to the back, so adding c evicts b
+	_, ok := c.get("a")
+	require.True(t, ok)
+	c.add("c", regexp.MustCompile("c"))
+
+	_, ok = c.get("b")
+	assert.False(t, ok)
+
+	re, ok := c.get("a")
+	require.True(t, ok)
+	assert.Equal(t, "a", re.String())
+
+	_, ok = c.get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.order.Len())
+}
@@ -1,7 +1,9 @@
 package hookfeed
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	lua "github.com/yuin/gopher-lua"
@@ -15,24 +17,25 @@ type WebhookRequest struct {
 // Transformer handles Lua script execution for JSON transformation
 type Transformer struct {
 	scriptPath string
+	cache      *ScriptCache
 }
 
 // NewTransformer creates a new Transformer with the given Lua script path
 func NewTransformer(scriptPath string) *Transformer {
 	return &Transformer{
 		scriptPath: scriptPath,
+		cache:      NewScriptCache(DefaultPoolSize, DefaultLimits),
 	}
 }
 
 // Transform executes the Lua script's transform function with the given JSON input
 func (t *Transformer) Transform(input []byte) ([]byte, error) {
-	L := lua.NewState()
-	defer L.Close()
-
-	// Load the Lua script
-	if err := L.DoFile(t.scriptPath); err != nil {
-		return nil, fmt.Errorf("failed to load lua script: %w", err)
+	// Get a state with the compiled script loaded
+	script, L, err := t.cache.acquire(t.scriptPath)
+	if err != nil {
+		return nil, err
 	}
+	defer t.cache.release(script, L)
 
 	// Parse input JSON
 	var inputData map[string]interface{}
@@ -49,11 +52,15 @@ func (t *Transformer) Transform(input []byte) ([]byte, error) {
 		return nil, fmt.Errorf("transform function not found in lua script")
 	}
 
-	// Call the transform function
+	// Call the transform function within the sandbox limits
+	ctx, cancel := t.cache.limits.runContext(context.Background())
+	defer cancel()
+
+	L.SetContext(ctx)
 	L.Push(transformFn)
 	L.Push(inputTable)
 	if err := L.PCall(1, 1, nil); err != nil {
-		return nil, fmt.Errorf("failed to execute transform function: %w", err)
+		return nil, fmt.Errorf("failed to execute transform function: %w", t.cache.limits.classifyError(ctx, err))
 	}
 
 	// Get the result
@@ -61,7 +68,11 @@ func (t *Transformer) Transform(input []byte) ([]byte, error) {
 	L.Pop(1)
 
 	// Convert Lua result back to JSON
-	resultData := luaValueToGo(result)
+	resultData, err := luaValueToGo(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert transform result: %w", err)
+	}
+
 	output, err := json.MarshalIndent(resultData, "", "  ")
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal output JSON: %w", err)
@@ -103,37 +114,75 @@ func goToLuaValue(l *lua.LState, value interface{}) lua.LValue {
 	}
 }
 
-// luaValueToGo converts Lua values to Go values
-func luaValueToGo(lv lua.LValue) interface{} {
+// maxTableDepth is the deepest nesting of Lua tables converted to Go values
+const maxTableDepth = 100
+
+// ErrTableCycle is returned when a Lua table converted to a Go value contains
+// itself
+var ErrTableCycle = errors.New("table contains a reference to itself")
+
+// luaValueToGo converts Lua values to Go values. Tables that contain
+// themselves or are nested deeper than maxTableDepth return an error.
+func luaValueToGo(lv lua.LValue) (interface{}, error) {
+	return convertLuaValue(lv, map[*lua.LTable]struct{}{}, 0)
+}
+
+// convertLuaValue converts lv with the tables of the current path in visiting
+func convertLuaValue(lv lua.LValue, visiting map[*lua.LTable]struct{}, depth int) (interface{}, error) {
 	switch v := lv.(type) {
 	case *lua.LNilType:
-		return nil
+		return nil, nil
 	case lua.LBool:
-		return bool(v)
+		return bool(v), nil
 	case lua.LNumber:
-		return float64(v)
+		return float64(v), nil
 	case lua.LString:
-		return string(v)
+		return string(v), nil
 	case *lua.LTable:
+		if depth >= maxTableDepth {
+			return nil, fmt.Errorf("table nested deeper than %d levels", maxTableDepth)
+		}
+		if _, ok := visiting[v]; ok {
+			return nil, ErrTableCycle
+		}
+
+		// Tables on other branches may be shared, only the current path is tracked
+		visiting[v] = struct{}{}
+		defer delete(visiting, v)
+
 		// Check if it's an array or a map
 		maxn := v.MaxN()
 		if maxn > 0 {
 			// It's an array
 			arr := make([]interface{}, 0, maxn)
 			for i := 1; i <= maxn; i++ {
-				arr = append(arr, luaValueToGo(v.RawGetInt(i)))
+				item, err := convertLuaValue(v.RawGetInt(i), visiting, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, item)
 			}
-			return arr
+			return arr, nil
 		}
+
 		// It's a map
+		var err error
 		m := make(map[string]interface{})
 		v.ForEach(func(key, value lua.LValue) {
-			if keyStr, ok := key.(lua.LString); ok {
-				m[string(keyStr)] = luaValueToGo(value)
+			keyStr, ok := key.(lua.LString)
+			if !ok || err != nil {
+				return
 			}
+
+			var item interface{}
+			item, err = convertLuaValue(value, visiting, depth+1)
+			m[string(keyStr)] = item
 		})
-		return m
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
 	default:
-		return nil
+		return nil, nil
 	}
 }
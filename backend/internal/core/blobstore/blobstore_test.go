@@ -0,0 +1,48 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Local(t *testing.T) {
+	var (
+		ctx = context.Background()
+		key = "feed/0192f5a8-2c3e-7a1b-8c2d-3e4f5a6b7c8d"
+	)
+
+	store, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	n, err := store.Put(ctx, key, strings.NewReader("crash dump"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), n)
+
+	rc, err := store.Open(ctx, key)
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Equal(t, "crash dump", string(data))
+
+	require.NoError(t, store.Delete(ctx, key))
+	require.NoError(t, store.Delete(ctx, key), "deleting a missing blob is not an error")
+
+	_, err = store.Open(ctx, key)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func Test_Local_InvalidKey(t *testing.T) {
+	store, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../escape", "/abs/path", "feed/../../escape", "feed/.hidden"} {
+		_, err := store.Put(context.Background(), key, strings.NewReader("x"))
+		assert.Error(t, err, key)
+	}
+}
@@ -5,6 +5,9 @@ type Cache struct {
 	allFeeds   []FeedParsed          // stored copy of the original feeds to ensure consistent ordering
 	cacheByID  map[string]FeedParsed // id => Feed
 	cacheByKey map[string]string     // key => id
+	syslog     SyslogRouting
+	mqtt       MQTT
+	rateLimit  *RateLimit
 }
 
 func NewCache(config *Config) *Cache {
@@ -12,6 +15,13 @@ func NewCache(config *Config) *Cache {
 		allFeeds:   make([]FeedParsed, 0, len(config.Feeds)),
 		cacheByID:  make(map[string]FeedParsed),
 		cacheByKey: make(map[string]string),
+		syslog:     config.Syslog,
+		mqtt:       config.MQTT,
+	}
+
+	if config.RateLimit != nil {
+		rl := config.RateLimit.IntoParsed()
+		cache.rateLimit = &rl
 	}
 
 	for _, feed := range config.Feeds {
@@ -43,3 +53,19 @@ func (c *Cache) GetByID(id string) (ok bool, feed FeedParsed) {
 func (c *Cache) GetAll() []FeedParsed {
 	return c.allFeeds
 }
+
+// SyslogRouting returns the routing table of syslog messages
+func (c *Cache) SyslogRouting() SyslogRouting {
+	return c.syslog
+}
+
+// MQTT returns the broker and subscriptions of the MQTT client
+func (c *Cache) MQTT() MQTT {
+	return c.mqtt
+}
+
+// RateLimit returns the limit of webhook requests per source IP, nil when
+// requests are not limited
+func (c *Cache) RateLimit() *RateLimit {
+	return c.rateLimit
+}
@@ -4,25 +4,46 @@ const (
 	DefaultRetentionCount   = 10_000
 	DefaultRetentionMaxDays = 10_000
 	DefaultAdaptersEnabled  = true
+
+	DefaultAttachmentMaxSize = 15 << 20  // 15 MiB, the ntfy default
+	DefaultAttachmentQuota   = 100 << 20 // 100 MiB
+
+	// BodyAllowance is the room in a request body for the message next to an
+	// attachment, and the limit of requests that cannot carry one
+	BodyAllowance = 1 << 20 // 1 MiB
+
+	// DefaultMaxBodySize is the body limit of feeds with the default attachment
+	// limits, see [AttachmentsParsed.MaxBodySize]
+	DefaultMaxBodySize = DefaultAttachmentMaxSize + BodyAllowance
 )
 
 // Config represents the complete HookFeed configuration
 type Config struct {
-	Middleware []string `yaml:"middleware"` // Filenames in execution order
-	Feeds      []Feed   `yaml:"feeds"`
+	Middleware []string      `yaml:"middleware"` // Filenames in execution order
+	Feeds      []Feed        `yaml:"feeds"`
+	Syslog     SyslogRouting `yaml:"syslog"`     // Routing of syslog messages to feeds
+	MQTT       MQTT          `yaml:"mqtt"`       // Subscriptions to an MQTT broker
+	RateLimit  *RateLimit    `yaml:"rate_limit"` // Limit of webhook requests per source IP, disabled when nil
 }
 
 // Feed represents a webhook feed configuration
 type Feed struct {
-	Name            string     `yaml:"name"`
-	Category        string     `yaml:"category"`
-	ID              string     `yaml:"id"`   // used as the unique identifier
-	Keys            []string   `yaml:"keys"` // used as the :key value in url path to resolve feed
-	Description     string     `yaml:"description"`
-	Middleware      []string   `yaml:"middleware"` // Filenames of middleware scripts
-	AdaptersEnabled *bool      `yaml:"adapters_enabled"`
-	Adapters        []string   `yaml:"adapters"` // pointer to distinguish between null, empty array, and populated array
-	Retention       *Retention `yaml:"retention"`
+	Name            string            `yaml:"name"`
+	Category        string            `yaml:"category"`
+	ID              string            `yaml:"id"`   // used as the unique identifier
+	Keys            []string          `yaml:"keys"` // used as the :key value in url path to resolve feed
+	Description     string            `yaml:"description"`
+	Middleware      []string          `yaml:"middleware"` // Filenames of middleware scripts
+	AdaptersEnabled *bool             `yaml:"adapters_enabled"`
+	AdaptersDetect  *bool             `yaml:"adapters_auto_detect"` // detect the adapter when none of the listed adapters match, defaults to true when none are listed
+	Adapters        []string          `yaml:"adapters"`             // tried in order, an empty list is the same as none
+	AdapterSecrets  map[string]string `yaml:"adapter_secrets"`      // adapter reference => secret used to verify requests
+	Retention       *Retention        `yaml:"retention"`
+	Attachments     *Attachments      `yaml:"attachments"`
+	RateLimit       *RateLimit        `yaml:"rate_limit"`     // limit of webhook requests, disabled when nil
+	Signature       *Signature        `yaml:"signature"`      // HMAC verification of requests, disabled when nil
+	Form            *Form             `yaml:"form"`           // handling of HTML form posts
+	NtfySubscribe   bool              `yaml:"ntfy_subscribe"` // allow reading messages with the ntfy subscribe endpoints, the key is the credential
 }
 
 func (f Feed) IntoParsed() FeedParsed {
@@ -34,17 +55,42 @@ func (f Feed) IntoParsed() FeedParsed {
 		Description:     f.Description,
 		Middleware:      f.Middleware,
 		AdaptersEnabled: DefaultAdaptersEnabled,
+		AdaptersDetect:  len(f.Adapters) == 0,
 		Adapters:        f.Adapters,
+		AdapterSecrets:  f.AdapterSecrets,
+		NtfySubscribe:   f.NtfySubscribe,
 		Retention: RetentionParsed{
 			MaxCount:   DefaultRetentionCount,
 			MaxAgeDays: DefaultRetentionMaxDays,
 		},
+		Attachments: AttachmentsParsed{
+			MaxSize: DefaultAttachmentMaxSize,
+			Quota:   DefaultAttachmentQuota,
+		},
 	}
 
 	if f.AdaptersEnabled != nil {
 		fp.AdaptersEnabled = *f.AdaptersEnabled
 	}
 
+	if f.AdaptersDetect != nil {
+		fp.AdaptersDetect = *f.AdaptersDetect
+	}
+
+	if f.RateLimit != nil {
+		rl := f.RateLimit.IntoParsed()
+		fp.RateLimit = &rl
+	}
+
+	if f.Signature != nil {
+		sig := f.Signature.IntoParsed()
+		fp.Signature = &sig
+	}
+
+	if f.Form != nil {
+		fp.Form = f.Form.IntoParsed()
+	}
+
 	if f.Retention != nil {
 		if f.Retention.MaxCount != nil {
 			fp.Retention.MaxCount = *f.Retention.MaxCount
@@ -55,21 +101,38 @@ func (f Feed) IntoParsed() FeedParsed {
 		}
 	}
 
+	if f.Attachments != nil {
+		if f.Attachments.MaxSize != nil {
+			fp.Attachments.MaxSize = *f.Attachments.MaxSize
+		}
+
+		if f.Attachments.Quota != nil {
+			fp.Attachments.Quota = *f.Attachments.Quota
+		}
+	}
+
 	return fp
 }
 
 // FeedParsed is the valid verion of [Feed] where no properties are unset. This struct has default values
 // where none were assigned in the base type
 type FeedParsed struct {
-	Name            string          `yaml:"name"`
-	Category        string          `yaml:"category"`
-	ID              string          `yaml:"id"`   // used as the unique identifier
-	Keys            []string        `yaml:"keys"` // used as the :key value in url path to resolve feed
-	Description     string          `yaml:"description"`
-	Middleware      []string        `yaml:"middleware"` // Filenames of middleware scripts
-	AdaptersEnabled bool            `yaml:"adapters_enabled"`
-	Adapters        []string        `yaml:"adapters"` // pointer to distinguish between null, empty array, and populated array
-	Retention       RetentionParsed `yaml:"retention"`
+	Name            string            `yaml:"name"`
+	Category        string            `yaml:"category"`
+	ID              string            `yaml:"id"`   // used as the unique identifier
+	Keys            []string          `yaml:"keys"` // used as the :key value in url path to resolve feed
+	Description     string            `yaml:"description"`
+	Middleware      []string          `yaml:"middleware"` // Filenames of middleware scripts
+	AdaptersEnabled bool              `yaml:"adapters_enabled"`
+	AdaptersDetect  bool              `yaml:"adapters_auto_detect"` // detect the adapter when none of the listed adapters match
+	Adapters        []string          `yaml:"adapters"`             // tried in order, an empty list is the same as none
+	AdapterSecrets  map[string]string `yaml:"adapter_secrets"`      // adapter reference => secret used to verify requests
+	Retention       RetentionParsed   `yaml:"retention"`
+	Attachments     AttachmentsParsed `yaml:"attachments"`
+	RateLimit       *RateLimit        `yaml:"rate_limit"`
+	Signature       *SignatureParsed  `yaml:"signature"`
+	Form            FormParsed        `yaml:"form"`
+	NtfySubscribe   bool              `yaml:"ntfy_subscribe"`
 }
 
 // Retention defines message retention policies
@@ -84,3 +147,22 @@ type RetentionParsed struct {
 	MaxCount   int `yaml:"max_count"`
 	MaxAgeDays int `yaml:"max_age_days"`
 }
+
+// Attachments defines the limits of the files stored with messages, sizes are
+// in bytes
+type Attachments struct {
+	MaxSize *int64 `yaml:"max_size"` // largest single file, 0 disables attachments
+	Quota   *int64 `yaml:"quota"`    // total size of the files of the feed
+}
+
+// AttachmentsParsed defines attachment limits with defaults applied
+type AttachmentsParsed struct {
+	MaxSize int64 `yaml:"max_size"`
+	Quota   int64 `yaml:"quota"`
+}
+
+// MaxBodySize returns the largest request body accepted for the feed, a file of
+// the attachment size limit and the rest of the message
+func (a AttachmentsParsed) MaxBodySize() int64 {
+	return max(a.MaxSize, 0) + BodyAllowance
+}
@@ -0,0 +1,29 @@
+package feeds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Feed_IntoParsed_AdaptersDetect(t *testing.T) {
+	off, on := false, true
+
+	tests := []struct {
+		name string
+		feed Feed
+		want bool
+	}{
+		{"no adapters", Feed{}, true},
+		{"empty list", Feed{Adapters: []string{}}, true},
+		{"opt out", Feed{AdaptersDetect: &off}, false},
+		{"listed adapters", Feed{Adapters: []string{"discord@v2"}}, false},
+		{"listed adapters with detection", Feed{Adapters: []string{"discord@v2"}, AdaptersDetect: &on}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.feed.IntoParsed().AdaptersDetect)
+		})
+	}
+}
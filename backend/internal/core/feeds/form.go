@@ -0,0 +1,95 @@
+package feeds
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// captchaProviders holds the response field and verification endpoint of the
+// supported captcha providers
+var captchaProviders = map[string]struct{ field, verifyURL string }{
+	"recaptcha": {"g-recaptcha-response", "https://www.google.com/recaptcha/api/siteverify"},
+	"hcaptcha":  {"h-captcha-response", "https://api.hcaptcha.com/siteverify"},
+	"turnstile": {"cf-turnstile-response", "https://challenges.cloudflare.com/turnstile/v0/siteverify"},
+}
+
+// Form configures how HTML form posts to a feed are handled
+type Form struct {
+	RedirectURL string   `yaml:"redirect_url"` // browsers are redirected here after a form post instead of receiving JSON
+	Honeypot    []string `yaml:"honeypot"`     // fields that must be left empty, submissions filling them are dropped
+	Captcha     *Captcha `yaml:"captcha"`      // captcha verification, disabled when nil
+}
+
+// Captcha configures verification of a captcha response sent with a form
+type Captcha struct {
+	Provider  string `yaml:"provider"`   // recaptcha, hcaptcha or turnstile
+	Secret    string `yaml:"secret"`     // secret key of the site
+	Field     string `yaml:"field"`      // form field holding the response, defaults to the field of the provider
+	VerifyURL string `yaml:"verify_url"` // siteverify endpoint, defaults to the endpoint of the provider
+}
+
+// FormParsed is [Form] with defaults applied
+type FormParsed struct {
+	RedirectURL string         `yaml:"redirect_url"`
+	Honeypot    []string       `yaml:"honeypot"`
+	Captcha     *CaptchaParsed `yaml:"captcha"`
+}
+
+// CaptchaParsed is [Captcha] with the defaults of the provider applied
+type CaptchaParsed struct {
+	Provider  string `yaml:"provider"`
+	Secret    string `yaml:"secret"`
+	Field     string `yaml:"field"`
+	VerifyURL string `yaml:"verify_url"`
+}
+
+func (f Form) IntoParsed() FormParsed {
+	fp := FormParsed{
+		RedirectURL: f.RedirectURL,
+		Honeypot:    f.Honeypot,
+	}
+
+	if f.Captcha != nil {
+		c := f.Captcha.IntoParsed()
+		fp.Captcha = &c
+	}
+
+	return fp
+}
+
+func (c Captcha) IntoParsed() CaptchaParsed {
+	cp := CaptchaParsed{
+		Provider:  strings.ToLower(c.Provider),
+		Secret:    c.Secret,
+		Field:     c.Field,
+		VerifyURL: c.VerifyURL,
+	}
+
+	provider := captchaProviders[cp.Provider]
+
+	if cp.Field == "" {
+		cp.Field = provider.field
+	}
+
+	if cp.VerifyURL == "" {
+		cp.VerifyURL = provider.verifyURL
+	}
+
+	return cp
+}
+
+// Validate reports configuration errors that would reject every submission
+func (c CaptchaParsed) Validate() error {
+	var errs []error
+
+	if c.Secret == "" {
+		errs = append(errs, errors.New("secret is required"))
+	}
+
+	if _, ok := captchaProviders[c.Provider]; !ok && (c.Field == "" || c.VerifyURL == "") {
+		errs = append(errs, fmt.Errorf("unsupported provider %q, set field and verify_url for other providers", c.Provider))
+	}
+
+	return errors.Join(errs...)
+}
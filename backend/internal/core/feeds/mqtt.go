@@ -0,0 +1,94 @@
+package feeds
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MQTT configures a client that subscribes to topics on an MQTT broker and
+// saves each published payload as a message. The client is not started when
+// no broker is set.
+type MQTT struct {
+	Broker        string             `yaml:"broker"`    // broker url, e.g. tcp://mosquitto:1883 or ssl://broker:8883
+	ClientID      string             `yaml:"client_id"` // defaults to hookfeed
+	Username      string             `yaml:"username"`
+	Password      string             `yaml:"password"`
+	Subscriptions []MQTTSubscription `yaml:"subscriptions"`
+}
+
+// MQTTSubscription routes the messages of a topic filter to a feed. Filters may
+// use the + (single level) and # (multi level) wildcards.
+type MQTTSubscription struct {
+	Topic string `yaml:"topic"` // topic filter, e.g. home/+/alerts or zigbee2mqtt/#
+	Feed  string `yaml:"feed"`  // feed id
+	QoS   byte   `yaml:"qos"`   // 0, 1 or 2
+}
+
+// Enabled reports whether a broker is configured
+func (m MQTT) Enabled() bool {
+	return m.Broker != ""
+}
+
+// Match returns the feed id for a message published to topic. Subscriptions
+// are matched in order and the first match wins.
+func (m MQTT) Match(topic string) (string, bool) {
+	for _, sub := range m.Subscriptions {
+		if TopicMatch(sub.Topic, topic) {
+			return sub.Feed, true
+		}
+	}
+
+	return "", false
+}
+
+// Validate reports topic filters that brokers would reject
+func (s MQTTSubscription) Validate() error {
+	if s.Topic == "" {
+		return errors.New("missing topic")
+	}
+
+	if s.QoS > 2 {
+		return fmt.Errorf("invalid qos %d", s.QoS)
+	}
+
+	levels := strings.Split(s.Topic, "/")
+	for i, level := range levels {
+		switch {
+		case level == "#" && i != len(levels)-1:
+			return fmt.Errorf("invalid topic %q: # must be the last level", s.Topic)
+		case level != "#" && level != "+" && strings.ContainsAny(level, "#+"):
+			return fmt.Errorf("invalid topic %q: wildcards must fill a whole level", s.Topic)
+		}
+	}
+
+	return nil
+}
+
+// TopicMatch reports whether a topic matches an MQTT topic filter. Topics
+// starting with $ are only matched by filters that do not start with a
+// wildcard.
+func TopicMatch(filter, topic string) bool {
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+
+		if i >= len(topicLevels) {
+			return false
+		}
+
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+
+	return len(filterLevels) == len(topicLevels)
+}
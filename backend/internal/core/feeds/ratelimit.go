@@ -0,0 +1,53 @@
+package feeds
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultRateLimitInterval is the interval of a rate limit without one
+const DefaultRateLimitInterval = time.Minute
+
+// RateLimit limits the requests accepted with a token bucket: Requests are
+// allowed per Interval and up to Burst requests at once
+type RateLimit struct {
+	Requests int           `yaml:"requests"` // requests allowed per interval
+	Interval time.Duration `yaml:"interval"` // defaults to one minute
+	Burst    int           `yaml:"burst"`    // requests allowed at once, defaults to requests
+}
+
+// IntoParsed returns the rate limit with defaults applied
+func (r RateLimit) IntoParsed() RateLimit {
+	if r.Interval <= 0 {
+		r.Interval = DefaultRateLimitInterval
+	}
+
+	if r.Burst <= 0 {
+		r.Burst = r.Requests
+	}
+
+	return r
+}
+
+// Validate reports whether the rate limit allows any request
+func (r RateLimit) Validate() error {
+	if r.Requests <= 0 {
+		return errors.New("rate limit requests must be greater than 0")
+	}
+
+	if r.Interval < 0 {
+		return errors.New("rate limit interval must not be negative")
+	}
+
+	if r.Burst < 0 {
+		return errors.New("rate limit burst must not be negative")
+	}
+
+	return nil
+}
+
+// PerSecond returns the rate at which requests are allowed
+func (r RateLimit) PerSecond() float64 {
+	r = r.IntoParsed()
+	return float64(r.Requests) / r.Interval.Seconds()
+}
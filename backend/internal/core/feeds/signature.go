@@ -0,0 +1,106 @@
+package feeds
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultSignatureAlgorithm = "sha256"
+	DefaultSignatureEncoding  = "hex"
+	DefaultSignaturePayload   = "{body}"
+	DefaultSignatureTolerance = 5 * time.Minute
+)
+
+// Signature configures HMAC verification of the requests to a feed. The HMAC
+// of the signed payload, keyed with the secret, must match one of the
+// signatures in the header.
+type Signature struct {
+	Header          string         `yaml:"header"`           // header holding the signature, e.g. X-Shopify-Hmac-Sha256
+	Algorithm       string         `yaml:"algorithm"`        // sha1, sha256 or sha512
+	Encoding        string         `yaml:"encoding"`         // hex or base64
+	Prefix          string         `yaml:"prefix"`           // stripped from the signature, e.g. "sha256="
+	TimestampHeader string         `yaml:"timestamp_header"` // optional header holding the unix timestamp of the request
+	TimestampPrefix string         `yaml:"timestamp_prefix"` // stripped from the timestamp, e.g. "t="
+	Tolerance       *time.Duration `yaml:"tolerance"`        // maximum age of the timestamp
+	Payload         string         `yaml:"payload"`          // signed content with {timestamp} and {body} placeholders
+	Secret          string         `yaml:"secret"`
+}
+
+// SignatureParsed is [Signature] with defaults applied
+type SignatureParsed struct {
+	Header          string        `yaml:"header"`
+	Algorithm       string        `yaml:"algorithm"`
+	Encoding        string        `yaml:"encoding"`
+	Prefix          string        `yaml:"prefix"`
+	TimestampHeader string        `yaml:"timestamp_header"`
+	TimestampPrefix string        `yaml:"timestamp_prefix"`
+	Tolerance       time.Duration `yaml:"tolerance"`
+	Payload         string        `yaml:"payload"`
+	Secret          string        `yaml:"secret"`
+}
+
+func (s Signature) IntoParsed() SignatureParsed {
+	sp := SignatureParsed{
+		Header:          s.Header,
+		Algorithm:       strings.ToLower(s.Algorithm),
+		Encoding:        strings.ToLower(s.Encoding),
+		Prefix:          s.Prefix,
+		TimestampHeader: s.TimestampHeader,
+		TimestampPrefix: s.TimestampPrefix,
+		Tolerance:       DefaultSignatureTolerance,
+		Payload:         s.Payload,
+		Secret:          s.Secret,
+	}
+
+	if sp.Algorithm == "" {
+		sp.Algorithm = DefaultSignatureAlgorithm
+	}
+
+	if sp.Encoding == "" {
+		sp.Encoding = DefaultSignatureEncoding
+	}
+
+	if sp.Payload == "" {
+		sp.Payload = DefaultSignaturePayload
+	}
+
+	if s.Tolerance != nil {
+		sp.Tolerance = *s.Tolerance
+	}
+
+	return sp
+}
+
+// Validate reports configuration errors that would reject every request
+func (s SignatureParsed) Validate() error {
+	var errs []error
+
+	if s.Header == "" {
+		errs = append(errs, errors.New("header is required"))
+	}
+
+	if s.Secret == "" {
+		errs = append(errs, errors.New("secret is required"))
+	}
+
+	switch s.Algorithm {
+	case "sha1", "sha256", "sha512":
+	default:
+		errs = append(errs, fmt.Errorf("unsupported algorithm %q", s.Algorithm))
+	}
+
+	switch s.Encoding {
+	case "hex", "base64":
+	default:
+		errs = append(errs, fmt.Errorf("unsupported encoding %q", s.Encoding))
+	}
+
+	if strings.Contains(s.Payload, "{timestamp}") && s.TimestampHeader == "" {
+		errs = append(errs, errors.New("payload uses {timestamp} without a timestamp_header"))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,66 @@
+package feeds
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// SyslogRouting routes syslog messages to feeds. Routes are matched in order
+// and the first match wins. Messages matching no route are sent to the default
+// feed, or dropped when there is none.
+type SyslogRouting struct {
+	Routes      []SyslogRoute `yaml:"routes"`
+	DefaultFeed string        `yaml:"default_feed"` // feed id for unmatched messages
+}
+
+// SyslogRoute matches syslog messages by facility, app name and hostname.
+// Empty fields match any message. App name and hostname are case-insensitive
+// glob patterns, e.g. "switch-*".
+type SyslogRoute struct {
+	Feed     string `yaml:"feed"`     // feed id
+	Facility string `yaml:"facility"` // facility name, e.g. local7
+	AppName  string `yaml:"app_name"`
+	Hostname string `yaml:"hostname"`
+}
+
+// Match returns the feed id for a message, and false when the message should
+// be dropped
+func (r SyslogRouting) Match(facility, appName, hostname string) (string, bool) {
+	for _, route := range r.Routes {
+		if route.matches(facility, appName, hostname) {
+			return route.Feed, true
+		}
+	}
+
+	return r.DefaultFeed, r.DefaultFeed != ""
+}
+
+func (r SyslogRoute) matches(facility, appName, hostname string) bool {
+	if r.Facility != "" && !strings.EqualFold(r.Facility, facility) {
+		return false
+	}
+
+	return globMatch(r.AppName, appName) && globMatch(r.Hostname, hostname)
+}
+
+// Validate reports patterns that are malformed and would never match
+func (r SyslogRoute) Validate() error {
+	for _, pattern := range []string{r.AppName, r.Hostname} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// globMatch matches a value against a case-insensitive glob pattern, an empty
+// pattern matches anything
+func globMatch(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return ok
+}
@@ -0,0 +1,55 @@
+-- name: AttachmentCreate :one
+INSERT INTO
+    attachments (feed_message_id, feed_slug, name, content_type, size, storage_key)
+VALUES
+    ($1, $2, $3, $4, $5, $6)
+RETURNING
+    *;
+
+-- name: AttachmentByID :one
+SELECT
+    *
+FROM
+    attachments
+WHERE
+    id = $1
+    AND feed_message_id IS NOT NULL;
+
+-- name: AttachmentsByFeedMessageID :many
+SELECT
+    *
+FROM
+    attachments
+WHERE
+    feed_message_id = $1
+ORDER BY
+    id;
+
+-- name: AttachmentFeedUsage :one
+-- AttachmentFeedUsage returns the total size of the stored attachments of a
+-- feed, including unlinked attachments whose blobs have not been removed yet.
+SELECT
+    COALESCE(SUM(size), 0) :: bigint AS usage
+FROM
+    attachments
+WHERE
+    feed_slug = $1;
+
+-- name: AttachmentsUnlinked :many
+-- AttachmentsUnlinked returns attachments whose message has been deleted.
+SELECT
+    *
+FROM
+    attachments
+WHERE
+    feed_message_id IS NULL
+ORDER BY
+    created_at
+LIMIT
+    $1;
+
+-- name: AttachmentDeleteByID :exec
+DELETE FROM
+    attachments
+WHERE
+    id = $1;
@@ -0,0 +1,68 @@
+-- name: FeedKVGet :one
+-- FeedKVGet returns the value of a key that has not expired.
+SELECT
+    value
+FROM
+    feed_kv
+WHERE
+    feed_slug = $1
+    AND key = $2
+    AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP);
+
+-- name: FeedKVSet :exec
+-- FeedKVSet creates or replaces a key. The key expires after ttl_seconds, or
+-- never when ttl_seconds is null.
+INSERT INTO
+    feed_kv (feed_slug, key, value, expires_at)
+VALUES
+    (
+        sqlc.arg('feed_slug'),
+        sqlc.arg('key'),
+        sqlc.arg('value'),
+        CURRENT_TIMESTAMP + make_interval(secs => sqlc.narg('ttl_seconds') :: double precision)
+    )
+ON CONFLICT (feed_slug, key) DO UPDATE
+SET
+    value = EXCLUDED.value,
+    expires_at = EXCLUDED.expires_at;
+
+-- name: FeedKVIncr :one
+-- FeedKVIncr atomically adds delta to a numeric key and returns the new value.
+-- Missing or expired keys start from zero and expire after ttl_seconds,
+-- existing keys keep their expiry.
+INSERT INTO
+    feed_kv (feed_slug, key, value, expires_at)
+VALUES
+    (
+        sqlc.arg('feed_slug'),
+        sqlc.arg('key'),
+        to_jsonb(sqlc.arg('delta') :: bigint),
+        CURRENT_TIMESTAMP + make_interval(secs => sqlc.narg('ttl_seconds') :: double precision)
+    )
+ON CONFLICT (feed_slug, key) DO UPDATE
+SET
+    value = CASE
+        WHEN feed_kv.expires_at <= CURRENT_TIMESTAMP THEN EXCLUDED.value
+        ELSE to_jsonb((feed_kv.value #>> '{}') :: bigint + sqlc.arg('delta') :: bigint)
+    END,
+    expires_at = CASE
+        WHEN feed_kv.expires_at <= CURRENT_TIMESTAMP THEN EXCLUDED.expires_at
+        ELSE feed_kv.expires_at
+    END
+RETURNING
+    (value #>> '{}') :: bigint AS value;
+
+-- name: FeedKVDelete :execrows
+DELETE FROM
+    feed_kv
+WHERE
+    feed_slug = $1
+    AND key = $2
+    AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP);
+
+-- name: FeedKVDeleteExpired :execrows
+-- FeedKVDeleteExpired deletes every key that has expired.
+DELETE FROM
+    feed_kv
+WHERE
+    expires_at <= CURRENT_TIMESTAMP;
@@ -22,7 +22,25 @@ INSERT INTO feed_messages (
     processed_at
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
-) RETURNING id, feed_slug, raw_request, raw_headers, raw_query_params, title, message, priority, logs, metadata, state, state_changed_at, received_at, processed_at, created_at, updated_at;
+) RETURNING id, feed_slug, raw_request, raw_headers, raw_query_params, title, message, priority, logs, metadata, state, state_changed_at, received_at, processed_at, created_at, updated_at, seq;
+
+-- name: FeedMessageCreateBatch :copyfrom
+INSERT INTO feed_messages (
+    feed_slug,
+    raw_request,
+    raw_headers,
+    raw_query_params,
+    title,
+    message,
+    priority,
+    logs,
+    metadata,
+    state,
+    received_at,
+    processed_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+);
 
 -- name: FeedMessageGetAll :many
 SELECT
@@ -56,6 +74,19 @@ SELECT
 FROM
     feed_messages_view;
 
+-- name: FeedMessageOpenIDsByGroupKey :many
+-- Returns the new and acknowledged messages of a feed sharing an adapter group key
+SELECT
+    id
+FROM
+    feed_messages
+WHERE
+    feed_slug = $1
+    AND metadata ->> 'groupKey' = sqlc.arg('group_key')::text
+    AND state IN ('new', 'acknowledged')
+ORDER BY
+    received_at ASC;
+
 -- name: FeedMessagesByFeedSlug :many
 SELECT
     sqlc.embed(feed_messages_view)
@@ -85,6 +116,20 @@ WHERE
     AND (sqlc.narg('since')::timestamp IS NULL OR received_at >= sqlc.narg('since'))
     AND (sqlc.narg('until')::timestamp IS NULL OR received_at <= sqlc.narg('until'));
 
+-- name: FeedMessagesBeforeSeq :many
+-- Returns the messages numbered below before, newest first
+SELECT
+    sqlc.embed(feed_messages_view)
+FROM
+    feed_messages_view
+WHERE
+    (sqlc.narg('feed_slug')::text IS NULL OR feed_slug = sqlc.narg('feed_slug'))
+    AND seq < sqlc.arg('before')
+ORDER BY
+    seq DESC
+LIMIT
+    sqlc.arg('limit');
+
 -- name: FeedMessageUpdateState :one
 UPDATE feed_messages
 SET
@@ -92,7 +137,7 @@ SET
     state_changed_at = CURRENT_TIMESTAMP
 WHERE
     id = $1
-RETURNING id, feed_slug, raw_request, raw_headers, raw_query_params, title, message, priority, logs, metadata, state, state_changed_at, received_at, processed_at, created_at, updated_at;
+RETURNING id, feed_slug, raw_request, raw_headers, raw_query_params, title, message, priority, logs, metadata, state, state_changed_at, received_at, processed_at, created_at, updated_at, seq;
 
 -- name: FeedMessageDeleteByID :exec
 DELETE FROM
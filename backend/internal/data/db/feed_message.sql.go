@@ -72,7 +72,7 @@ func (q *Queries) FeedMessageBulkUpdateState(ctx context.Context, arg FeedMessag
 
 const feedMessageByID = `-- name: FeedMessageByID :one
 SELECT
-    feed_messages_view.id, feed_messages_view.feed_slug, feed_messages_view.raw_request, feed_messages_view.raw_headers, feed_messages_view.raw_query_params, feed_messages_view.title, feed_messages_view.message, feed_messages_view.priority, feed_messages_view.logs, feed_messages_view.metadata, feed_messages_view.state, feed_messages_view.state_changed_at, feed_messages_view.received_at, feed_messages_view.processed_at, feed_messages_view.created_at, feed_messages_view.updated_at
+    feed_messages_view.id, feed_messages_view.feed_slug, feed_messages_view.raw_request, feed_messages_view.raw_headers, feed_messages_view.raw_query_params, feed_messages_view.title, feed_messages_view.message, feed_messages_view.priority, feed_messages_view.logs, feed_messages_view.metadata, feed_messages_view.state, feed_messages_view.state_changed_at, feed_messages_view.received_at, feed_messages_view.processed_at, feed_messages_view.created_at, feed_messages_view.updated_at, feed_messages_view.seq
 FROM
     feed_messages_view
 WHERE
@@ -103,6 +103,7 @@ func (q *Queries) FeedMessageByID(ctx context.Context, id uuid.UUID) (FeedMessag
 		&i.FeedMessagesView.ProcessedAt,
 		&i.FeedMessagesView.CreatedAt,
 		&i.FeedMessagesView.UpdatedAt,
+		&i.FeedMessagesView.Seq,
 	)
 	return i, err
 }
@@ -123,7 +124,7 @@ INSERT INTO feed_messages (
     processed_at
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
-) RETURNING id, feed_slug, raw_request, raw_headers, raw_query_params, title, message, priority, logs, metadata, state, state_changed_at, received_at, processed_at, created_at, updated_at
+) RETURNING id, feed_slug, raw_request, raw_headers, raw_query_params, title, message, priority, logs, metadata, state, state_changed_at, received_at, processed_at, created_at, updated_at, seq
 `
 
 type FeedMessageCreateParams struct {
@@ -158,6 +159,7 @@ type FeedMessageCreateRow struct {
 	ProcessedAt    pgtype.Timestamp
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
+	Seq            int64
 }
 
 func (q *Queries) FeedMessageCreate(ctx context.Context, arg FeedMessageCreateParams) (FeedMessageCreateRow, error) {
@@ -193,10 +195,26 @@ func (q *Queries) FeedMessageCreate(ctx context.Context, arg FeedMessageCreatePa
 		&i.ProcessedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Seq,
 	)
 	return i, err
 }
 
+type FeedMessageCreateBatchParams struct {
+	FeedSlug       string
+	RawRequest     []byte
+	RawHeaders     []byte
+	RawQueryParams []byte
+	Title          *string
+	Message        *string
+	Priority       *int32
+	Logs           []string
+	Metadata       []byte
+	State          *string
+	ReceivedAt     time.Time
+	ProcessedAt    pgtype.Timestamp
+}
+
 const feedMessageDeleteByID = `-- name: FeedMessageDeleteByID :exec
 DELETE FROM
     feed_messages
@@ -250,7 +268,7 @@ func (q *Queries) FeedMessageDeleteOldByCount(ctx context.Context, arg FeedMessa
 
 const feedMessageGetAll = `-- name: FeedMessageGetAll :many
 SELECT
-    feed_messages_view.id, feed_messages_view.feed_slug, feed_messages_view.raw_request, feed_messages_view.raw_headers, feed_messages_view.raw_query_params, feed_messages_view.title, feed_messages_view.message, feed_messages_view.priority, feed_messages_view.logs, feed_messages_view.metadata, feed_messages_view.state, feed_messages_view.state_changed_at, feed_messages_view.received_at, feed_messages_view.processed_at, feed_messages_view.created_at, feed_messages_view.updated_at
+    feed_messages_view.id, feed_messages_view.feed_slug, feed_messages_view.raw_request, feed_messages_view.raw_headers, feed_messages_view.raw_query_params, feed_messages_view.title, feed_messages_view.message, feed_messages_view.priority, feed_messages_view.logs, feed_messages_view.metadata, feed_messages_view.state, feed_messages_view.state_changed_at, feed_messages_view.received_at, feed_messages_view.processed_at, feed_messages_view.created_at, feed_messages_view.updated_at, feed_messages_view.seq
 FROM
     feed_messages_view
 ORDER BY
@@ -311,6 +329,7 @@ func (q *Queries) FeedMessageGetAll(ctx context.Context, arg FeedMessageGetAllPa
 			&i.FeedMessagesView.ProcessedAt,
 			&i.FeedMessagesView.CreatedAt,
 			&i.FeedMessagesView.UpdatedAt,
+			&i.FeedMessagesView.Seq,
 		); err != nil {
 			return nil, err
 		}
@@ -336,9 +355,48 @@ func (q *Queries) FeedMessageGetAllCount(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const feedMessageOpenIDsByGroupKey = `-- name: FeedMessageOpenIDsByGroupKey :many
+SELECT
+    id
+FROM
+    feed_messages
+WHERE
+    feed_slug = $1
+    AND metadata ->> 'groupKey' = $2::text
+    AND state IN ('new', 'acknowledged')
+ORDER BY
+    received_at ASC
+`
+
+type FeedMessageOpenIDsByGroupKeyParams struct {
+	FeedSlug string
+	GroupKey string
+}
+
+// Returns the new and acknowledged messages of a feed sharing an adapter group key
+func (q *Queries) FeedMessageOpenIDsByGroupKey(ctx context.Context, arg FeedMessageOpenIDsByGroupKeyParams) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, feedMessageOpenIDsByGroupKey, arg.FeedSlug, arg.GroupKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const feedMessageSearch = `-- name: FeedMessageSearch :many
 SELECT
-    v.id, v.feed_slug, v.raw_request, v.raw_headers, v.raw_query_params, v.title, v.message, v.priority, v.logs, v.metadata, v.state, v.state_changed_at, v.received_at, v.processed_at, v.created_at, v.updated_at
+    v.id, v.feed_slug, v.raw_request, v.raw_headers, v.raw_query_params, v.title, v.message, v.priority, v.logs, v.metadata, v.state, v.state_changed_at, v.received_at, v.processed_at, v.created_at, v.updated_at, v.seq
 FROM
     feed_messages_view v
     INNER JOIN feed_messages fm ON v.id = fm.id
@@ -406,6 +464,7 @@ func (q *Queries) FeedMessageSearch(ctx context.Context, arg FeedMessageSearchPa
 			&i.FeedMessagesView.ProcessedAt,
 			&i.FeedMessagesView.CreatedAt,
 			&i.FeedMessagesView.UpdatedAt,
+			&i.FeedMessagesView.Seq,
 		); err != nil {
 			return nil, err
 		}
@@ -462,7 +521,7 @@ SET
     state_changed_at = CURRENT_TIMESTAMP
 WHERE
     id = $1
-RETURNING id, feed_slug, raw_request, raw_headers, raw_query_params, title, message, priority, logs, metadata, state, state_changed_at, received_at, processed_at, created_at, updated_at
+RETURNING id, feed_slug, raw_request, raw_headers, raw_query_params, title, message, priority, logs, metadata, state, state_changed_at, received_at, processed_at, created_at, updated_at, seq
 `
 
 type FeedMessageUpdateStateParams struct {
@@ -487,6 +546,7 @@ type FeedMessageUpdateStateRow struct {
 	ProcessedAt    pgtype.Timestamp
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
+	Seq            int64
 }
 
 func (q *Queries) FeedMessageUpdateState(ctx context.Context, arg FeedMessageUpdateStateParams) (FeedMessageUpdateStateRow, error) {
@@ -509,13 +569,77 @@ func (q *Queries) FeedMessageUpdateState(ctx context.Context, arg FeedMessageUpd
 		&i.ProcessedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Seq,
 	)
 	return i, err
 }
 
+const feedMessagesBeforeSeq = `-- name: FeedMessagesBeforeSeq :many
+SELECT
+    feed_messages_view.id, feed_messages_view.feed_slug, feed_messages_view.raw_request, feed_messages_view.raw_headers, feed_messages_view.raw_query_params, feed_messages_view.title, feed_messages_view.message, feed_messages_view.priority, feed_messages_view.logs, feed_messages_view.metadata, feed_messages_view.state, feed_messages_view.state_changed_at, feed_messages_view.received_at, feed_messages_view.processed_at, feed_messages_view.created_at, feed_messages_view.updated_at, feed_messages_view.seq
+FROM
+    feed_messages_view
+WHERE
+    ($1::text IS NULL OR feed_slug = $1)
+    AND seq < $2
+ORDER BY
+    seq DESC
+LIMIT
+    $3
+`
+
+type FeedMessagesBeforeSeqParams struct {
+	FeedSlug *string
+	Before   int64
+	Limit    int32
+}
+
+type FeedMessagesBeforeSeqRow struct {
+	FeedMessagesView FeedMessagesView
+}
+
+// Returns the messages numbered below before, newest first
+func (q *Queries) FeedMessagesBeforeSeq(ctx context.Context, arg FeedMessagesBeforeSeqParams) ([]FeedMessagesBeforeSeqRow, error) {
+	rows, err := q.db.Query(ctx, feedMessagesBeforeSeq, arg.FeedSlug, arg.Before, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeedMessagesBeforeSeqRow
+	for rows.Next() {
+		var i FeedMessagesBeforeSeqRow
+		if err := rows.Scan(
+			&i.FeedMessagesView.ID,
+			&i.FeedMessagesView.FeedSlug,
+			&i.FeedMessagesView.RawRequest,
+			&i.FeedMessagesView.RawHeaders,
+			&i.FeedMessagesView.RawQueryParams,
+			&i.FeedMessagesView.Title,
+			&i.FeedMessagesView.Message,
+			&i.FeedMessagesView.Priority,
+			&i.FeedMessagesView.Logs,
+			&i.FeedMessagesView.Metadata,
+			&i.FeedMessagesView.State,
+			&i.FeedMessagesView.StateChangedAt,
+			&i.FeedMessagesView.ReceivedAt,
+			&i.FeedMessagesView.ProcessedAt,
+			&i.FeedMessagesView.CreatedAt,
+			&i.FeedMessagesView.UpdatedAt,
+			&i.FeedMessagesView.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const feedMessagesByFeedSlug = `-- name: FeedMessagesByFeedSlug :many
 SELECT
-    feed_messages_view.id, feed_messages_view.feed_slug, feed_messages_view.raw_request, feed_messages_view.raw_headers, feed_messages_view.raw_query_params, feed_messages_view.title, feed_messages_view.message, feed_messages_view.priority, feed_messages_view.logs, feed_messages_view.metadata, feed_messages_view.state, feed_messages_view.state_changed_at, feed_messages_view.received_at, feed_messages_view.processed_at, feed_messages_view.created_at, feed_messages_view.updated_at
+    feed_messages_view.id, feed_messages_view.feed_slug, feed_messages_view.raw_request, feed_messages_view.raw_headers, feed_messages_view.raw_query_params, feed_messages_view.title, feed_messages_view.message, feed_messages_view.priority, feed_messages_view.logs, feed_messages_view.metadata, feed_messages_view.state, feed_messages_view.state_changed_at, feed_messages_view.received_at, feed_messages_view.processed_at, feed_messages_view.created_at, feed_messages_view.updated_at, feed_messages_view.seq
 FROM
     feed_messages_view
 WHERE
@@ -579,6 +703,7 @@ func (q *Queries) FeedMessagesByFeedSlug(ctx context.Context, arg FeedMessagesBy
 			&i.FeedMessagesView.ProcessedAt,
 			&i.FeedMessagesView.CreatedAt,
 			&i.FeedMessagesView.UpdatedAt,
+			&i.FeedMessagesView.Seq,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,33 @@
+-- name: GotifyClientCreate :one
+INSERT INTO
+    gotify_clients (user_id, name, token)
+VALUES
+    ($1, $2, $3)
+RETURNING
+    *;
+
+-- name: GotifyClientsByUser :many
+SELECT
+    *
+FROM
+    gotify_clients
+WHERE
+    user_id = $1
+ORDER BY
+    id;
+
+-- name: UserByGotifyClient :one
+SELECT
+    users.*
+FROM
+    gotify_clients
+    JOIN users ON gotify_clients.user_id = users.id
+WHERE
+    gotify_clients.token = $1;
+
+-- name: GotifyClientDelete :execrows
+DELETE FROM
+    gotify_clients
+WHERE
+    id = $1
+    AND user_id = $2;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Per-feed key/value state for middleware scripts
+CREATE TABLE IF NOT EXISTS feed_kv (
+    feed_slug VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value JSONB NOT NULL,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (feed_slug, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_feed_kv_expires_at ON feed_kv(expires_at) WHERE expires_at IS NOT NULL;
+
+CREATE TRIGGER update_feed_kv_updated_at BEFORE UPDATE ON feed_kv
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS update_feed_kv_updated_at ON feed_kv;
+DROP TABLE IF EXISTS feed_kv;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Files of feed messages, their content is kept in the blob store under
+-- storage_key. Deleting a message unlinks its attachments, the blobs of
+-- unlinked attachments are removed by a periodic cleanup.
+CREATE TABLE IF NOT EXISTS attachments (
+    id UUID DEFAULT uuid_generate_v7() PRIMARY KEY,
+    feed_message_id UUID REFERENCES feed_messages(id) ON DELETE SET NULL,
+    feed_slug VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_feed_message_id ON attachments(feed_message_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_feed_slug ON attachments(feed_slug);
+CREATE INDEX IF NOT EXISTS idx_attachments_unlinked ON attachments(created_at) WHERE feed_message_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS attachments;
+-- +goose StatementEnd
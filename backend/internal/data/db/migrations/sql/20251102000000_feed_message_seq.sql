@@ -0,0 +1,78 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Drop the view first
+DROP VIEW IF EXISTS feed_messages_view;
+
+-- Sequential message numbers, used where clients expect integer ids such as
+-- the Gotify API. Existing messages are numbered in id order.
+ALTER TABLE feed_messages ADD COLUMN seq BIGINT;
+
+UPDATE feed_messages fm
+SET seq = numbered.n
+FROM (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY id) AS n
+    FROM feed_messages
+) numbered
+WHERE fm.id = numbered.id;
+
+CREATE SEQUENCE feed_messages_seq_seq OWNED BY feed_messages.seq;
+SELECT setval('feed_messages_seq_seq', COALESCE(MAX(seq), 0) + 1, false) FROM feed_messages;
+
+ALTER TABLE feed_messages
+    ALTER COLUMN seq SET DEFAULT nextval('feed_messages_seq_seq'),
+    ALTER COLUMN seq SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_feed_messages_seq ON feed_messages(seq);
+
+-- Recreate the view with the new column
+CREATE VIEW feed_messages_view AS
+SELECT
+    id,
+    feed_slug,
+    raw_request,
+    raw_headers,
+    raw_query_params,
+    title,
+    message,
+    priority,
+    logs,
+    metadata,
+    state,
+    state_changed_at,
+    received_at,
+    processed_at,
+    created_at,
+    updated_at,
+    seq
+FROM feed_messages;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Drop the view
+DROP VIEW IF EXISTS feed_messages_view;
+
+-- Remove the column, the sequence is owned by it
+ALTER TABLE feed_messages DROP COLUMN IF EXISTS seq;
+
+-- Recreate the view without the column
+CREATE VIEW feed_messages_view AS
+SELECT
+    id,
+    feed_slug,
+    raw_request,
+    raw_headers,
+    raw_query_params,
+    title,
+    message,
+    priority,
+    logs,
+    metadata,
+    state,
+    state_changed_at,
+    received_at,
+    processed_at,
+    created_at,
+    updated_at
+FROM feed_messages;
+-- +goose StatementEnd
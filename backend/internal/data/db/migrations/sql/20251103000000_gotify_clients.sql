@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tokens of Gotify clients, such as the Android app. Like session tokens only
+-- the hash of the token is stored.
+CREATE TABLE IF NOT EXISTS gotify_clients (
+    id UUID DEFAULT uuid_generate_v7 () PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token BYTEA NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS gotify_clients_token_idx ON gotify_clients (token);
+
+CREATE INDEX IF NOT EXISTS gotify_clients_user_id_idx ON gotify_clients (user_id);
+
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS gotify_clients;
+
+-- +goose StatementEnd
@@ -11,6 +11,26 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Attachment struct {
+	ID            uuid.UUID
+	FeedMessageID pgtype.UUID
+	FeedSlug      string
+	Name          string
+	ContentType   string
+	Size          int64
+	StorageKey    string
+	CreatedAt     time.Time
+}
+
+type FeedKv struct {
+	FeedSlug  string
+	Key       string
+	Value     []byte
+	ExpiresAt pgtype.Timestamp
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 type FeedMessage struct {
 	ID             uuid.UUID
 	FeedSlug       string
@@ -29,6 +49,7 @@ type FeedMessage struct {
 	UpdatedAt      time.Time
 	SearchVector   interface{}
 	RawQueryParams []byte
+	Seq            int64
 }
 
 type FeedMessagesView struct {
@@ -48,6 +69,15 @@ type FeedMessagesView struct {
 	ProcessedAt    pgtype.Timestamp
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
+	Seq            int64
+}
+
+type GotifyClient struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+	Token     []byte
 }
 
 type User struct {
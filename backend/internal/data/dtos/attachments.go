@@ -0,0 +1,40 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hay-kot/hookfeed/backend/internal/data/db"
+)
+
+// Attachment is a file stored with a feed message
+type Attachment struct {
+	ID            uuid.UUID `json:"id"`
+	FeedMessageID uuid.UUID `json:"feedMessageId"`
+	FeedSlug      string    `json:"feedSlug"`
+	Name          string    `json:"name"`
+	ContentType   string    `json:"contentType"`
+	Size          int64     `json:"size"`
+	CreatedAt     time.Time `json:"createdAt"`
+	StorageKey    string    `json:"-"`
+}
+
+// AttachmentUpload is a file received with a message, before it is stored
+type AttachmentUpload struct {
+	Name        string
+	ContentType string // detected from the content when empty
+	Data        []byte
+}
+
+func MapAttachment(a db.Attachment) Attachment {
+	return Attachment{
+		ID:            a.ID,
+		FeedMessageID: uuid.UUID(a.FeedMessageID.Bytes),
+		FeedSlug:      a.FeedSlug,
+		Name:          a.Name,
+		ContentType:   a.ContentType,
+		Size:          a.Size,
+		CreatedAt:     a.CreatedAt,
+		StorageKey:    a.StorageKey,
+	}
+}
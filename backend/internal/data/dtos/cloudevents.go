@@ -0,0 +1,50 @@
+package dtos
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CloudEvent is an event received in one of the CloudEvents 1.0 HTTP modes.
+// Data holds the event data as JSON, data that is not JSON is kept as a string.
+type CloudEvent struct {
+	ID              string
+	Source          string
+	SpecVersion     string
+	Type            string
+	Subject         string
+	Time            time.Time // zero when the event has no time
+	DataContentType string
+	DataSchema      string
+	Extensions      map[string]any
+	Data            json.RawMessage // nil when the event has no data
+}
+
+// Attributes returns the context attributes and extensions of the event keyed
+// by their CloudEvents names. Unset attributes are omitted.
+func (e CloudEvent) Attributes() map[string]any {
+	attrs := make(map[string]any, len(e.Extensions)+8)
+	for name, value := range e.Extensions {
+		attrs[name] = value
+	}
+
+	for name, value := range map[string]string{
+		"id":              e.ID,
+		"source":          e.Source,
+		"specversion":     e.SpecVersion,
+		"type":            e.Type,
+		"subject":         e.Subject,
+		"datacontenttype": e.DataContentType,
+		"dataschema":      e.DataSchema,
+	} {
+		if value != "" {
+			attrs[name] = value
+		}
+	}
+
+	if !e.Time.IsZero() {
+		attrs["time"] = e.Time.Format(time.RFC3339Nano)
+	}
+
+	return attrs
+}
@@ -39,6 +39,7 @@ type FeedMessage struct {
 	CreatedAt      time.Time       `json:"createdAt"`
 	UpdatedAt      time.Time       `json:"updatedAt"`
 	SearchVector   *string         `json:"-"`
+	Seq            int64           `json:"-"` // sequential number, used as the Gotify message id
 }
 
 type FeedMessageCreate struct {
@@ -185,6 +186,7 @@ func MapFeedMessage(d db.FeedMessage) FeedMessage {
 		CreatedAt:      d.CreatedAt,
 		UpdatedAt:      d.UpdatedAt,
 		SearchVector:   nil,
+		Seq:            d.Seq,
 	}
 }
 
@@ -218,6 +220,7 @@ func MapFeedMessageView(d db.FeedMessagesView) FeedMessage {
 		CreatedAt:      d.CreatedAt,
 		UpdatedAt:      d.UpdatedAt,
 		SearchVector:   nil,
+		Seq:            d.Seq,
 	}
 }
 
@@ -1,17 +1,24 @@
 package dtos
 
 type Feed struct {
-	Name        string    `json:"name"`
-	Category    string    `json:"category"`
-	ID          string    `json:"id"`
-	Keys        []string  `json:"-"`
-	Description string    `json:"description"`
-	Middleware  []string  `json:"middleware"`
-	Adapters    []string  `json:"adapters"`
-	Retention   Retention `json:"retention"`
+	Name        string           `json:"name"`
+	Category    string           `json:"category"`
+	ID          string           `json:"id"`
+	Keys        []string         `json:"-"`
+	Description string           `json:"description"`
+	Middleware  []string         `json:"middleware"`
+	Adapters    []string         `json:"adapters"`
+	Retention   Retention        `json:"retention"`
+	Attachments AttachmentLimits `json:"attachments"`
 }
 
 type Retention struct {
 	MaxCount   int `json:"maxCount"`
 	MaxAgeDays int `json:"maxAgeDays"`
 }
+
+// AttachmentLimits are the attachment limits of a feed in bytes
+type AttachmentLimits struct {
+	MaxSize int64 `json:"maxSize"`
+	Quota   int64 `json:"quota"`
+}
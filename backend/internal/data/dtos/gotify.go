@@ -0,0 +1,141 @@
+package dtos
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"time"
+
+	"github.com/hay-kot/hookfeed/backend/internal/data/db"
+)
+
+// GotifyMessage is a message in the format returned by the Gotify API
+type GotifyMessage struct {
+	ID       int64          `json:"id"`
+	AppID    int64          `json:"appid"`
+	Title    string         `json:"title"`
+	Message  string         `json:"message"`
+	Priority int            `json:"priority"`
+	Extras   map[string]any `json:"extras,omitempty"`
+	Date     time.Time      `json:"date"`
+}
+
+// gotifyPriorities maps hookfeed priorities (1-5) to Gotify priorities (0-10)
+var gotifyPriorities = map[int32]int{1: 0, 2: 2, 3: 5, 4: 8, 5: 10}
+
+// MapGotifyMessage converts a FeedMessage into a GotifyMessage. The original
+// Gotify priority and extras are read from the message metadata when present.
+func MapGotifyMessage(m FeedMessage) GotifyMessage {
+	var metadata struct {
+		GotifyPriority *int           `json:"gotifyPriority"`
+		Extras         map[string]any `json:"extras"`
+	}
+	_ = json.Unmarshal(m.Metadata, &metadata)
+
+	msg := GotifyMessage{
+		ID:       m.Seq,
+		AppID:    GotifyAppID(m.FeedSlug),
+		Priority: gotifyPriorities[m.Priority],
+		Extras:   metadata.Extras,
+		Date:     m.ReceivedAt,
+	}
+
+	if metadata.GotifyPriority != nil {
+		msg.Priority = *metadata.GotifyPriority
+	}
+	if m.Title != nil {
+		msg.Title = *m.Title
+	}
+	if m.Message != nil {
+		msg.Message = *m.Message
+	}
+
+	return msg
+}
+
+// GotifyApplication is a feed in the format of a Gotify application
+type GotifyApplication struct {
+	ID              int64  `json:"id"`
+	Token           string `json:"token"` // feed keys are not exposed
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	Internal        bool   `json:"internal"`
+	Image           string `json:"image"`
+	DefaultPriority int    `json:"defaultPriority"`
+}
+
+// MapGotifyApplication converts a Feed into a GotifyApplication
+func MapGotifyApplication(f Feed) GotifyApplication {
+	return GotifyApplication{
+		ID:              GotifyAppID(f.ID),
+		Name:            f.Name,
+		Description:     f.Description,
+		Image:           "static/defaultapp.png",
+		DefaultPriority: gotifyPriorities[3],
+	}
+}
+
+// GotifyPaging describes a page of messages, Since is the id to pass as since=
+// to request the next page
+type GotifyPaging struct {
+	Next  string `json:"next,omitempty"`
+	Since int64  `json:"since"`
+	Size  int    `json:"size"`
+	Limit int    `json:"limit"`
+}
+
+// GotifyPagedMessages is a page of messages returned by the Gotify API
+type GotifyPagedMessages struct {
+	Paging   GotifyPaging    `json:"paging"`
+	Messages []GotifyMessage `json:"messages"`
+}
+
+// GotifyClient is a Gotify client. The token is only set when the client is
+// created, afterwards only its hash is stored.
+type GotifyClient struct {
+	ID    int64  `json:"id"`
+	Token string `json:"token"`
+	Name  string `json:"name"`
+}
+
+// MapGotifyClient converts a stored client into a GotifyClient without a token
+func MapGotifyClient(c db.GotifyClient) GotifyClient {
+	return GotifyClient{
+		ID:   GotifyAppID(c.ID.String()),
+		Name: c.Name,
+	}
+}
+
+// GotifyClientCreate is the body of a Gotify client creation request
+type GotifyClientCreate struct {
+	Name string `json:"name"`
+}
+
+// GotifyUser is a user in the format returned by the Gotify API
+type GotifyUser struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+// MapGotifyUser converts a User into a GotifyUser
+func MapGotifyUser(u User) GotifyUser {
+	return GotifyUser{
+		ID:    GotifyAppID(u.ID.String()),
+		Name:  u.Username,
+		Admin: u.IsAdmin,
+	}
+}
+
+// GotifyVersion is the response of the Gotify version endpoint
+type GotifyVersion struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+}
+
+// GotifyAppID returns the numeric Gotify id of a feed, or any other string id
+func GotifyAppID(id string) int64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return int64(h.Sum32())
+}
@@ -0,0 +1,9 @@
+package dtos
+
+// PushoverResponse is a status response in the format returned by the Pushover
+// API. Status is 1 on success and 0 when the request was rejected.
+type PushoverResponse struct {
+	Status  int      `json:"status"`
+	Request string   `json:"request"`
+	Errors  []string `json:"errors,omitempty"`
+}
@@ -0,0 +1,13 @@
+package dtos
+
+import "time"
+
+// RateLimitStats are the webhook requests of a feed rejected by rate limits
+// since the server started
+type RateLimitStats struct {
+	FeedID         string     `json:"feedId"`
+	Limited        bool       `json:"limited"`        // the feed has a rate limit
+	Rejected       int64      `json:"rejected"`       // rejected by the limit of the feed
+	RejectedByIP   int64      `json:"rejectedByIp"`   // rejected by the limit per source IP
+	LastRejectedAt *time.Time `json:"lastRejectedAt"` // nil when no request was rejected
+}
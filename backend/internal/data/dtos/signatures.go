@@ -0,0 +1,13 @@
+package dtos
+
+import "time"
+
+// SignatureStats are the webhook requests of a feed rejected by signature or
+// adapter secret verification since the server started
+type SignatureStats struct {
+	FeedID         string     `json:"feedId"`
+	Verified       bool       `json:"verified"`       // the feed has a signature or adapter secrets
+	Rejected       int64      `json:"rejected"`       // requests that failed verification
+	LastRejectedAt *time.Time `json:"lastRejectedAt"` // nil when no request was rejected
+	LastError      string     `json:"lastError"`      // reason of the last rejection
+}
@@ -9,11 +9,21 @@ type WebhookRequest struct {
 	Headers     map[string][]string // All request headers
 	QueryParams map[string][]string // URL query parameters
 	Body        map[string]any      // Raw JSON body
+	RawBody     []byte              // Unparsed request body, used for signature checks
+	CloudEvents []CloudEvent        // Events of a CloudEvents request, nil for other requests
+	Form        bool                // true for HTML form posts, whose fields are in Body
+	RemoteIP    string              // Address of the client, sent along with captcha checks
+	Title       string              // Title of the message before middleware and adapters, optional
+	Metadata    map[string]any      // Metadata of the message before middleware and adapters, optional
+	Attachments []AttachmentUpload  // Files uploaded with the request, stored with the message
 }
 
 // WebhookResponse represents the response sent back to the webhook sender
 type WebhookResponse struct {
-	Success   bool      `json:"success"`
-	MessageID uuid.UUID `json:"messageId"`
-	FeedID    string    `json:"feedId"`
+	Success     bool        `json:"success"`
+	MessageID   uuid.UUID   `json:"messageId"`
+	MessageIDs  []uuid.UUID `json:"messageIds,omitempty"` // messages of a CloudEvents request
+	FeedID      string      `json:"feedId"`
+	Aborted     bool        `json:"aborted,omitempty"` // true when middleware dropped the message
+	RedirectURL string      `json:"-"`                 // form posts are redirected here instead of receiving the response
 }
@@ -0,0 +1,147 @@
+package adapters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hay-kot/hookfeed/backend/hookfeed"
+)
+
+// alertSeverityPriorities maps common severity label values to priorities
+var alertSeverityPriorities = map[string]int32{
+	"critical":  5,
+	"emergency": 5,
+	"page":      5,
+	"error":     5,
+	"high":      4,
+	"warning":   4,
+	"warn":      4,
+	"info":      3,
+	"low":       2,
+	"none":      2,
+	"debug":     1,
+}
+
+// AlertmanagerAdapter adapts Prometheus Alertmanager webhook notifications. A
+// notification describes one alert group; notifications for the same group
+// share metadata.groupKey, and a resolved notification resolves the earlier
+// messages of its group.
+//
+//   - commonAnnotations.title, summary or the alertname label → title
+//   - summary and description annotations of each alert → message
+//   - severity labels → priority (highest wins)
+//   - groupKey → metadata.groupKey
+//   - status, receiver, commonLabels, externalURL → metadata.alertmanager*
+type AlertmanagerAdapter struct{}
+
+var (
+	_ Adapter  = AlertmanagerAdapter{}
+	_ Resolver = AlertmanagerAdapter{}
+)
+
+func (AlertmanagerAdapter) Name() string    { return "alertmanager" }
+func (AlertmanagerAdapter) Version() string { return "v1" }
+
+// Detect matches payloads with a groupKey, a status and an alerts array
+func (AlertmanagerAdapter) Detect(payload map[string]any, _ map[string]string) bool {
+	_, hasAlerts := payload["alerts"].([]any)
+	return hasAlerts && stringAt(payload, "groupKey") != "" && stringAt(payload, "status") != ""
+}
+
+// Resolved reports whether every alert of the group has resolved
+func (AlertmanagerAdapter) Resolved(payload map[string]any, _ map[string]string) bool {
+	return stringAt(payload, "status") == "resolved"
+}
+
+func (AlertmanagerAdapter) Transform(payload map[string]any, _ map[string]string) (*hookfeed.Payload, error) {
+	out := &hookfeed.Payload{Metadata: map[string]any{}}
+
+	status := stringAt(payload, "status")
+	commonLabels, _ := payload["commonLabels"].(map[string]any)
+	commonAnnotations, _ := payload["commonAnnotations"].(map[string]any)
+
+	alerts := make([]map[string]any, 0)
+	for _, item := range asSlice(payload["alerts"]) {
+		if alert, ok := item.(map[string]any); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	title := firstString(commonAnnotations, "title", "summary")
+	if title == "" {
+		title = stringAt(commonLabels, "alertname")
+	}
+	if title == "" {
+		title = stringAt(payload, "groupLabels", "alertname")
+	}
+
+	firing := 0
+	lines := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		if stringAt(alert, "status") == "firing" {
+			firing++
+		}
+
+		labels, _ := alert["labels"].(map[string]any)
+		if p := alertSeverityPriorities[strings.ToLower(stringAt(labels, "severity"))]; p > out.Priority {
+			out.Priority = p
+		}
+
+		annotations, _ := alert["annotations"].(map[string]any)
+		summary := stringAt(annotations, "summary")
+		description := stringAt(annotations, "description")
+
+		if len(alerts) == 1 {
+			if summary == title {
+				summary = ""
+			}
+			lines = append(lines, compact([]string{summary, description})...)
+			continue
+		}
+
+		name := summary
+		if name == "" {
+			name = stringAt(labels, "alertname")
+		}
+		line := fmt.Sprintf("- [%s] %s", stringAt(alert, "status"), name)
+		if description != "" {
+			line += ": " + description
+		}
+		lines = append(lines, line)
+	}
+
+	switch status {
+	case "firing":
+		out.Title = fmt.Sprintf("[FIRING:%d] %s", firing, title)
+	case "resolved":
+		out.Title = "[RESOLVED] " + title
+	default:
+		out.Title = title
+	}
+	out.Message = strings.Join(lines, "\n")
+
+	out.Metadata[GroupKeyMetadataKey] = stringAt(payload, "groupKey")
+	out.Metadata["alertmanagerStatus"] = status
+	out.Metadata["alertmanagerAlerts"] = len(alerts)
+	if receiver := stringAt(payload, "receiver"); receiver != "" {
+		out.Metadata["alertmanagerReceiver"] = receiver
+	}
+	if len(commonLabels) > 0 {
+		out.Metadata["alertmanagerLabels"] = commonLabels
+	}
+	if url := stringAt(payload, "externalURL"); url != "" {
+		out.Metadata["alertmanagerUrl"] = url
+	}
+
+	return out, nil
+}
+
+// firstString returns the first non-empty string value of keys in m
+func firstString(m map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s, _ := m[key].(string); s != "" {
+			return s
+		}
+	}
+	return ""
+}
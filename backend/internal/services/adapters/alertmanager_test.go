@@ -0,0 +1,105 @@
+package adapters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func alertmanagerPayload(t *testing.T, body string) map[string]any {
+	t.Helper()
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &payload))
+	require.True(t, AlertmanagerAdapter{}.Detect(payload, nil))
+
+	return payload
+}
+
+func Test_AlertmanagerAdapter_Detect(t *testing.T) {
+	a := AlertmanagerAdapter{}
+
+	assert.False(t, a.Detect(map[string]any{"alerts": []any{}, "status": "firing"}, nil))
+	assert.False(t, a.Detect(map[string]any{"groupKey": "g", "status": "firing"}, nil))
+	assert.False(t, a.Detect(map[string]any{"text": "hello"}, nil))
+}
+
+func Test_AlertmanagerAdapter_Firing(t *testing.T) {
+	payload := alertmanagerPayload(t, `{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"HighCPU\"}",
+  "status": "firing",
+  "receiver": "hookfeed",
+  "groupLabels": { "alertname": "HighCPU" },
+  "commonLabels": { "alertname": "HighCPU", "job": "node" },
+  "commonAnnotations": {},
+  "externalURL": "http://alertmanager:9093",
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": { "alertname": "HighCPU", "instance": "web-1", "severity": "warning" },
+      "annotations": { "summary": "CPU above 90% on web-1", "description": "5m load is 7.2" }
+    },
+    {
+      "status": "firing",
+      "labels": { "alertname": "HighCPU", "instance": "db-1", "severity": "critical" },
+      "annotations": { "summary": "CPU above 90% on db-1" }
+    },
+    {
+      "status": "resolved",
+      "labels": { "alertname": "HighCPU", "instance": "web-2", "severity": "warning" },
+      "annotations": {}
+    }
+  ]
+}`)
+
+	a := AlertmanagerAdapter{}
+	assert.False(t, a.Resolved(payload, nil))
+
+	out, err := a.Transform(payload, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "[FIRING:2] HighCPU", out.Title)
+	assert.Equal(t, "- [firing] CPU above 90% on web-1: 5m load is 7.2\n"+
+		"- [firing] CPU above 90% on db-1\n"+
+		"- [resolved] HighCPU", out.Message)
+	assert.Equal(t, int32(5), out.Priority)
+	assert.Equal(t, map[string]any{
+		"groupKey":             "{}:{alertname=\"HighCPU\"}",
+		"alertmanagerStatus":   "firing",
+		"alertmanagerAlerts":   3,
+		"alertmanagerReceiver": "hookfeed",
+		"alertmanagerLabels":   map[string]any{"alertname": "HighCPU", "job": "node"},
+		"alertmanagerUrl":      "http://alertmanager:9093",
+	}, out.Metadata)
+}
+
+func Test_AlertmanagerAdapter_Resolved(t *testing.T) {
+	payload := alertmanagerPayload(t, `{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"DiskFull\"}",
+  "status": "resolved",
+  "commonLabels": { "alertname": "DiskFull", "severity": "warning" },
+  "commonAnnotations": { "summary": "Disk almost full", "description": "/var at 95%" },
+  "alerts": [
+    {
+      "status": "resolved",
+      "labels": { "alertname": "DiskFull", "severity": "warning" },
+      "annotations": { "summary": "Disk almost full", "description": "/var at 95%" }
+    }
+  ]
+}`)
+
+	a := AlertmanagerAdapter{}
+	assert.True(t, a.Resolved(payload, nil))
+
+	out, err := a.Transform(payload, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "[RESOLVED] Disk almost full", out.Title)
+	assert.Equal(t, "/var at 95%", out.Message)
+	assert.Equal(t, int32(4), out.Priority)
+	assert.Equal(t, "{}:{alertname=\"DiskFull\"}", out.Metadata[GroupKeyMetadataKey])
+}
@@ -0,0 +1,237 @@
+package adapters
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+)
+
+const (
+	// CloudEventsContentType is the content type of a structured mode event
+	CloudEventsContentType = "application/cloudevents+json"
+	// CloudEventsBatchContentType is the content type of a batch of events
+	CloudEventsBatchContentType = "application/cloudevents-batch+json"
+	// CloudEventMetadataKey is the metadata key holding the attributes of an event
+	CloudEventMetadataKey = "cloudEvent"
+)
+
+// ErrInvalidCloudEvent is returned for CloudEvents requests that are malformed
+// or miss a required attribute
+var ErrInvalidCloudEvent = errors.New("invalid CloudEvent")
+
+// IsCloudEvent reports whether a request uses one of the CloudEvents HTTP
+// modes: binary mode with ce-* headers, or structured and batch mode by content
+// type
+func IsCloudEvent(headers http.Header) bool {
+	switch contentMediaType(headers) {
+	case CloudEventsContentType, CloudEventsBatchContentType:
+		return true
+	}
+	return headers.Get("Ce-Specversion") != ""
+}
+
+// ParseCloudEvents parses the events of a CloudEvents request. Structured and
+// binary mode requests hold a single event, batch requests any number of them.
+// The result is nil for requests that are not CloudEvents.
+func ParseCloudEvents(headers http.Header, body []byte) ([]dtos.CloudEvent, error) {
+	switch contentMediaType(headers) {
+	case CloudEventsContentType:
+		event, err := parseStructuredCloudEvent(body)
+		if err != nil {
+			return nil, err
+		}
+		return []dtos.CloudEvent{event}, nil
+	case CloudEventsBatchContentType:
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return nil, fmt.Errorf("%w: batch must be a JSON array: %v", ErrInvalidCloudEvent, err)
+		}
+
+		events := make([]dtos.CloudEvent, 0, len(batch))
+		for i, raw := range batch {
+			event, err := parseStructuredCloudEvent(raw)
+			if err != nil {
+				return nil, fmt.Errorf("event %d: %w", i, err)
+			}
+			events = append(events, event)
+		}
+		return events, nil
+	}
+
+	if headers.Get("Ce-Specversion") == "" {
+		return nil, nil
+	}
+
+	event, err := parseBinaryCloudEvent(headers, body)
+	if err != nil {
+		return nil, err
+	}
+	return []dtos.CloudEvent{event}, nil
+}
+
+// parseStructuredCloudEvent parses an event encoded as a JSON object
+func parseStructuredCloudEvent(raw []byte) (dtos.CloudEvent, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return dtos.CloudEvent{}, fmt.Errorf("%w: %v", ErrInvalidCloudEvent, err)
+	}
+
+	event := dtos.CloudEvent{Extensions: map[string]any{}}
+
+	for name, value := range fields {
+		switch name {
+		case "data":
+			if string(value) != "null" {
+				event.Data = value
+			}
+		case "data_base64":
+			// decoded below once the content type is known
+		case "id", "source", "specversion", "type", "subject", "time", "datacontenttype", "dataschema":
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				return dtos.CloudEvent{}, fmt.Errorf("%w: %s must be a string", ErrInvalidCloudEvent, name)
+			}
+			if err := setCloudEventAttribute(&event, name, s); err != nil {
+				return dtos.CloudEvent{}, err
+			}
+		default:
+			var v any
+			_ = json.Unmarshal(value, &v)
+			event.Extensions[name] = v
+		}
+	}
+
+	if value, ok := fields["data_base64"]; ok {
+		var encoded string
+		if err := json.Unmarshal(value, &encoded); err != nil {
+			return dtos.CloudEvent{}, fmt.Errorf("%w: data_base64 must be a string", ErrInvalidCloudEvent)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return dtos.CloudEvent{}, fmt.Errorf("%w: data_base64: %v", ErrInvalidCloudEvent, err)
+		}
+		event.Data = cloudEventData(event.DataContentType, decoded)
+	}
+
+	return event, validateCloudEvent(event)
+}
+
+// parseBinaryCloudEvent parses an event with its attributes in ce-* headers and
+// its data in the body
+func parseBinaryCloudEvent(headers http.Header, body []byte) (dtos.CloudEvent, error) {
+	event := dtos.CloudEvent{Extensions: map[string]any{}}
+
+	for key, values := range headers {
+		name, ok := strings.CutPrefix(strings.ToLower(key), "ce-")
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		// Header values are percent-encoded
+		value := values[0]
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+
+		if err := setCloudEventAttribute(&event, name, value); err != nil {
+			return dtos.CloudEvent{}, err
+		}
+	}
+
+	event.DataContentType = headers.Get("Content-Type")
+	event.Data = cloudEventData(event.DataContentType, body)
+
+	return event, validateCloudEvent(event)
+}
+
+// setCloudEventAttribute sets a context attribute, names that are not
+// attributes of the specification are stored as extensions
+func setCloudEventAttribute(event *dtos.CloudEvent, name, value string) error {
+	switch name {
+	case "id":
+		event.ID = value
+	case "source":
+		event.Source = value
+	case "specversion":
+		event.SpecVersion = value
+	case "type":
+		event.Type = value
+	case "subject":
+		event.Subject = value
+	case "datacontenttype":
+		event.DataContentType = value
+	case "dataschema":
+		event.DataSchema = value
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("%w: time must be an RFC 3339 timestamp", ErrInvalidCloudEvent)
+		}
+		event.Time = t
+	default:
+		event.Extensions[name] = value
+	}
+	return nil
+}
+
+// validateCloudEvent checks the spec version and the required attributes
+func validateCloudEvent(event dtos.CloudEvent) error {
+	if !strings.HasPrefix(event.SpecVersion, "1.") {
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalidCloudEvent, event.SpecVersion)
+	}
+
+	var missing []string
+	for name, value := range map[string]string{"id": event.ID, "source": event.Source, "type": event.Type} {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return fmt.Errorf("%w: missing %s", ErrInvalidCloudEvent, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// cloudEventData returns event data as JSON. JSON data is kept as is, other
+// text is stored as a JSON string and binary data as a base64 encoded string.
+func cloudEventData(contentType string, data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+
+	mt, _, _ := mime.ParseMediaType(contentType)
+	isJSON := contentType == "" || mt == "application/json" || mt == "text/json" || strings.HasSuffix(mt, "+json")
+
+	if isJSON && json.Valid(data) {
+		return data
+	}
+
+	s := string(data)
+	if !utf8.Valid(data) {
+		s = base64.StdEncoding.EncodeToString(data)
+	}
+
+	encoded, _ := json.Marshal(s)
+	return encoded
+}
+
+// contentMediaType returns the media type of the Content-Type header without its
+// parameters
+func contentMediaType(headers http.Header) string {
+	mt, _, _ := mime.ParseMediaType(headers.Get("Content-Type"))
+	return mt
+}
@@ -0,0 +1,170 @@
+package adapters
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IsCloudEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers http.Header
+		want    bool
+	}{
+		{"binary", http.Header{"Ce-Specversion": {"1.0"}, "Content-Type": {"application/json"}}, true},
+		{"structured", http.Header{"Content-Type": {"application/cloudevents+json; charset=utf-8"}}, true},
+		{"batch", http.Header{"Content-Type": {"application/cloudevents-batch+json"}}, true},
+		{"plain json", http.Header{"Content-Type": {"application/json"}}, false},
+		{"no headers", http.Header{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsCloudEvent(tt.headers))
+		})
+	}
+}
+
+func Test_ParseCloudEvents_Binary(t *testing.T) {
+	headers := http.Header{
+		"Ce-Specversion":          {"1.0"},
+		"Ce-Id":                   {"A234-1234-1234"},
+		"Ce-Source":               {"/mycontext/subcontext"},
+		"Ce-Type":                 {"com.example.someevent"},
+		"Ce-Subject":              {"orders%2F42"},
+		"Ce-Time":                 {"2018-04-05T17:31:00Z"},
+		"Ce-Comexampleextension1": {"value"},
+		"Content-Type":            {"application/json"},
+	}
+
+	events, err := ParseCloudEvents(headers, []byte(`{"order":42}`))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	event := events[0]
+	assert.Equal(t, "A234-1234-1234", event.ID)
+	assert.Equal(t, "/mycontext/subcontext", event.Source)
+	assert.Equal(t, "com.example.someevent", event.Type)
+	assert.Equal(t, "orders/42", event.Subject)
+	assert.Equal(t, time.Date(2018, 4, 5, 17, 31, 0, 0, time.UTC), event.Time)
+	assert.Equal(t, "application/json", event.DataContentType)
+	assert.JSONEq(t, `{"order":42}`, string(event.Data))
+	assert.Equal(t, map[string]any{"comexampleextension1": "value"}, event.Extensions)
+}
+
+func Test_ParseCloudEvents_BinaryText(t *testing.T) {
+	headers := http.Header{
+		"Ce-Specversion": {"1.0"},
+		"Ce-Id":          {"1"},
+		"Ce-Source":      {"urn:test"},
+		"Ce-Type":        {"log.line"},
+		"Content-Type":   {"text/plain"},
+	}
+
+	events, err := ParseCloudEvents(headers, []byte("disk almost full"))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.JSONEq(t, `"disk almost full"`, string(events[0].Data))
+}
+
+func Test_ParseCloudEvents_Structured(t *testing.T) {
+	headers := http.Header{"Content-Type": {CloudEventsContentType}}
+	body := `{
+		"specversion": "1.0",
+		"type": "com.github.pull_request.opened",
+		"source": "https://github.com/cloudevents/spec/pull",
+		"subject": "123",
+		"id": "A234-1234-1234",
+		"time": "2018-04-05T17:31:00.5Z",
+		"comexampleextension1": 5,
+		"datacontenttype": "application/json",
+		"data": {"action": "opened"}
+	}`
+
+	events, err := ParseCloudEvents(headers, []byte(body))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	event := events[0]
+	assert.Equal(t, "com.github.pull_request.opened", event.Type)
+	assert.Equal(t, "123", event.Subject)
+	assert.Equal(t, time.Date(2018, 4, 5, 17, 31, 0, 500_000_000, time.UTC), event.Time)
+	assert.JSONEq(t, `{"action":"opened"}`, string(event.Data))
+	assert.Equal(t, map[string]any{"comexampleextension1": float64(5)}, event.Extensions)
+
+	assert.Equal(t, map[string]any{
+		"id":                   "A234-1234-1234",
+		"source":               "https://github.com/cloudevents/spec/pull",
+		"specversion":          "1.0",
+		"type":                 "com.github.pull_request.opened",
+		"subject":              "123",
+		"time":                 "2018-04-05T17:31:00.5Z",
+		"datacontenttype":      "application/json",
+		"comexampleextension1": float64(5),
+	}, event.Attributes())
+}
+
+func Test_ParseCloudEvents_DataBase64(t *testing.T) {
+	headers := http.Header{"Content-Type": {CloudEventsContentType}}
+	body := `{"specversion":"1.0","id":"1","source":"urn:test","type":"t","datacontenttype":"application/json","data_base64":"eyJvayI6dHJ1ZX0="}`
+
+	events, err := ParseCloudEvents(headers, []byte(body))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.JSONEq(t, `{"ok":true}`, string(events[0].Data))
+}
+
+func Test_ParseCloudEvents_Batch(t *testing.T) {
+	headers := http.Header{"Content-Type": {CloudEventsBatchContentType}}
+	body := `[
+		{"specversion":"1.0","id":"1","source":"urn:test","type":"first","data":{"n":1}},
+		{"specversion":"1.0","id":"2","source":"urn:test","type":"second"}
+	]`
+
+	events, err := ParseCloudEvents(headers, []byte(body))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "first", events[0].Type)
+	assert.Equal(t, "second", events[1].Type)
+	assert.Nil(t, events[1].Data)
+
+	events, err = ParseCloudEvents(headers, []byte(`[]`))
+	require.NoError(t, err)
+	assert.NotNil(t, events)
+	assert.Empty(t, events)
+}
+
+func Test_ParseCloudEvents_Invalid(t *testing.T) {
+	structured := http.Header{"Content-Type": {CloudEventsContentType}}
+
+	tests := []struct {
+		name    string
+		headers http.Header
+		body    string
+	}{
+		{"missing type", structured, `{"specversion":"1.0","id":"1","source":"urn:test"}`},
+		{"unsupported specversion", structured, `{"specversion":"0.3","id":"1","source":"urn:test","type":"t"}`},
+		{"bad time", structured, `{"specversion":"1.0","id":"1","source":"urn:test","type":"t","time":"yesterday"}`},
+		{"non-string attribute", structured, `{"specversion":"1.0","id":1,"source":"urn:test","type":"t"}`},
+		{"not an object", structured, `[]`},
+		{"batch not an array", http.Header{"Content-Type": {CloudEventsBatchContentType}}, `{}`},
+		{"binary missing id", http.Header{"Ce-Specversion": {"1.0"}, "Ce-Source": {"urn:test"}, "Ce-Type": {"t"}}, `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCloudEvents(tt.headers, []byte(tt.body))
+			require.ErrorIs(t, err, ErrInvalidCloudEvent)
+		})
+	}
+}
+
+func Test_ParseCloudEvents_NotCloudEvent(t *testing.T) {
+	events, err := ParseCloudEvents(http.Header{"Content-Type": {"application/json"}}, []byte(`{}`))
+	require.NoError(t, err)
+	assert.Nil(t, events)
+}
@@ -0,0 +1,172 @@
+package adapters
+
+import (
+	"strings"
+
+	"github.com/hay-kot/hookfeed/backend/hookfeed"
+)
+
+// discordColorPriorities maps well known embed colors to priorities. Colors not
+// listed are mapped by hue, see discordColorPriority.
+var discordColorPriorities = map[int]int32{
+	15158332: 5, // red
+	15548997: 5, // discord red
+	10038562: 5, // dark red
+	16776960: 4, // yellow
+	16705372: 4, // discord yellow
+	15105570: 4, // orange
+	3066993:  3, // green
+	5763719:  3, // discord green
+	3447003:  3, // blue
+	5793266:  3, // blurple
+}
+
+// DiscordAdapter adapts Discord execute-webhook payloads so tools that post to a
+// Discord webhook URL can target a feed unchanged.
+//
+//   - embeds[0].title → title
+//   - embeds[0].description and content → message
+//   - embeds[0].color → priority
+//   - embeds[0].fields → metadata.discordFields
+//   - username, avatar_url → metadata.discordUsername, metadata.discordAvatarUrl
+type DiscordAdapter struct{}
+
+var _ Adapter = DiscordAdapter{}
+
+func (DiscordAdapter) Name() string    { return "discord" }
+func (DiscordAdapter) Version() string { return "v2" }
+
+// Detect matches payloads with an embeds array or a content string
+func (DiscordAdapter) Detect(payload map[string]any, headers map[string]string) bool {
+	if _, ok := payload["embeds"].([]any); ok {
+		return true
+	}
+
+	if content, ok := payload["content"].(string); ok && content != "" {
+		return true
+	}
+
+	return strings.Contains(headerValue(headers, "User-Agent"), "Discord") &&
+		(payload["username"] != nil || payload["avatar_url"] != nil)
+}
+
+func (DiscordAdapter) Transform(payload map[string]any, _ map[string]string) (*hookfeed.Payload, error) {
+	out := &hookfeed.Payload{Metadata: map[string]any{}}
+
+	content, _ := payload["content"].(string)
+
+	var embed map[string]any
+	if embeds, ok := payload["embeds"].([]any); ok && len(embeds) > 0 {
+		embed, _ = embeds[0].(map[string]any)
+	}
+
+	var description string
+	if embed != nil {
+		out.Title, _ = embed["title"].(string)
+		if out.Title == "" {
+			if author, ok := embed["author"].(map[string]any); ok {
+				out.Title, _ = author["name"].(string)
+			}
+		}
+
+		description, _ = embed["description"].(string)
+
+		if color, ok := embed["color"].(float64); ok {
+			out.Priority = discordColorPriority(int(color))
+		}
+
+		if fields := discordFields(embed["fields"]); len(fields) > 0 {
+			out.Metadata["discordFields"] = fields
+		}
+
+		if url, _ := embed["url"].(string); url != "" {
+			out.Metadata["discordUrl"] = url
+		}
+	}
+
+	parts := make([]string, 0, 2)
+	for _, s := range []string{content, description} {
+		if s = strings.TrimSpace(s); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	out.Message = strings.Join(parts, "\n\n")
+
+	if username, _ := payload["username"].(string); username != "" {
+		out.Metadata["discordUsername"] = username
+	}
+	if avatar, _ := payload["avatar_url"].(string); avatar != "" {
+		out.Metadata["discordAvatarUrl"] = avatar
+	}
+
+	return out, nil
+}
+
+// discordFields converts embed fields into a name => value map
+func discordFields(v any) map[string]any {
+	list, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+
+	fields := make(map[string]any, len(list))
+	for _, item := range list {
+		field, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		name, _ := field["name"].(string)
+		if name == "" {
+			continue
+		}
+		fields[name] = field["value"]
+	}
+
+	return fields
+}
+
+// discordColorPriority maps an embed color to a priority. Known colors use
+// discordColorPriorities, others are mapped by hue: reds are urgent, oranges and
+// yellows high, everything else default. Zero means the color was not set.
+func discordColorPriority(color int) int32 {
+	if color <= 0 {
+		return 0
+	}
+
+	if p, ok := discordColorPriorities[color]; ok {
+		return p
+	}
+
+	r := float64((color >> 16) & 0xff)
+	g := float64((color >> 8) & 0xff)
+	b := float64(color & 0xff)
+
+	hi := max(r, g, b)
+	lo := min(r, g, b)
+	if hi == lo || hi-lo < 40 {
+		return 3 // greys carry no severity
+	}
+
+	var hue float64
+	switch hi {
+	case r:
+		hue = 60 * (g - b) / (hi - lo)
+	case g:
+		hue = 60 * ((b-r)/(hi-lo) + 2)
+	default:
+		hue = 60 * ((r-g)/(hi-lo) + 4)
+	}
+	if hue < 0 {
+		hue += 360
+	}
+
+	switch {
+	case hue < 20 || hue >= 340:
+		return 5
+	case hue < 65:
+		return 4
+	default:
+		return 3
+	}
+}
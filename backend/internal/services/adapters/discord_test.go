@@ -0,0 +1,105 @@
+package adapters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DiscordAdapter_Detect(t *testing.T) {
+	a := DiscordAdapter{}
+
+	tests := []struct {
+		name    string
+		payload map[string]any
+		headers map[string]string
+		want    bool
+	}{
+		{"content", map[string]any{"content": "hello"}, nil, true},
+		{"embeds", map[string]any{"embeds": []any{}}, nil, true},
+		{"discord user agent", map[string]any{"username": "bot"}, map[string]string{"User-Agent": "DiscordBot (x, 1)"}, true},
+		{"empty content", map[string]any{"content": ""}, nil, false},
+		{"ntfy", map[string]any{"topic": "t", "message": "m"}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, a.Detect(tt.payload, tt.headers))
+		})
+	}
+}
+
+func Test_DiscordAdapter_Transform(t *testing.T) {
+	body := `{
+  "content": "Deploy finished",
+  "username": "CI Bot",
+  "avatar_url": "https://example.com/bot.png",
+  "embeds": [
+    {
+      "title": "api@1.4.2",
+      "description": "Rolled out to production",
+      "url": "https://ci.example.com/runs/7",
+      "color": 15158332,
+      "fields": [
+        { "name": "Environment", "value": "production", "inline": true },
+        { "name": "Duration", "value": "3m12s" }
+      ]
+    }
+  ]
+}`
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &payload))
+
+	out, err := DiscordAdapter{}.Transform(payload, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "api@1.4.2", out.Title)
+	assert.Equal(t, "Deploy finished\n\nRolled out to production", out.Message)
+	assert.Equal(t, int32(5), out.Priority)
+	assert.Equal(t, map[string]any{
+		"discordUsername":  "CI Bot",
+		"discordAvatarUrl": "https://example.com/bot.png",
+		"discordUrl":       "https://ci.example.com/runs/7",
+		"discordFields": map[string]any{
+			"Environment": "production",
+			"Duration":    "3m12s",
+		},
+	}, out.Metadata)
+}
+
+func Test_DiscordAdapter_ContentOnly(t *testing.T) {
+	out, err := DiscordAdapter{}.Transform(map[string]any{"content": "ping"}, nil)
+	require.NoError(t, err)
+
+	assert.Empty(t, out.Title)
+	assert.Equal(t, "ping", out.Message)
+	assert.Equal(t, int32(0), out.Priority)
+	assert.Empty(t, out.Metadata)
+}
+
+func Test_discordColorPriority(t *testing.T) {
+	tests := []struct {
+		name  string
+		color int
+		want  int32
+	}{
+		{"unset", 0, 0},
+		{"red", 15158332, 5},
+		{"yellow", 16776960, 4},
+		{"green", 3066993, 3},
+		{"blue", 3447003, 3},
+		{"crimson by hue", 0xDC143C, 5},
+		{"gold by hue", 0xFFD700, 4},
+		{"purple by hue", 0x9B59B6, 3},
+		{"grey", 0x95A5A6, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, discordColorPriority(tt.color))
+		})
+	}
+}
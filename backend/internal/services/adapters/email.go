@@ -0,0 +1,286 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+)
+
+// emailPriorities maps the X-Priority header (1 highest - 5 lowest) to
+// priorities
+var emailPriorities = map[string]int32{
+	"1": 5,
+	"2": 4,
+	"3": 3,
+	"4": 2,
+	"5": 1,
+}
+
+// EmailAttachment describes a part of an email that is not its body
+type EmailAttachment struct {
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"contentType"`
+	Size        int    `json:"size"`
+}
+
+// email holds the parts of a parsed email used for a message
+type email struct {
+	text        string
+	html        string
+	attachments []EmailAttachment
+}
+
+// ParseEmail parses a MIME email into a message for the feed:
+//
+//   - Subject → title
+//   - text/plain body, or the text of the text/html body → message
+//   - X-Priority, Importance → priority
+//   - headers → raw headers
+//   - From, To, Cc, Date, Message-Id → metadata.emailFrom, metadata.emailTo, ...
+//   - attachments → metadata.emailAttachments (file name, content type and size)
+//
+// The raw request holds the addresses, subject, bodies and attachment list.
+func ParseEmail(r io.Reader, feedID string) (dtos.FeedMessageCreate, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return dtos.FeedMessageCreate{}, fmt.Errorf("invalid email: %w", err)
+	}
+
+	var e email
+	err = e.readPart(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Header.Get("Content-Disposition"), msg.Body)
+	if err != nil {
+		return dtos.FeedMessageCreate{}, fmt.Errorf("invalid email body: %w", err)
+	}
+
+	dec := new(mime.WordDecoder)
+	decode := func(key string) string {
+		value := msg.Header.Get(key)
+		if decoded, err := dec.DecodeHeader(value); err == nil {
+			return decoded
+		}
+		return value
+	}
+
+	data := dtos.FeedMessageCreateNew()
+	data.FeedID = feedID
+
+	subject := decode("Subject")
+	if subject != "" {
+		data.Title = subject
+	}
+
+	text := strings.TrimSpace(e.text)
+	if text == "" {
+		text = htmlToText(e.html)
+	}
+	if text != "" {
+		data.Message = text
+	}
+
+	data.Priority = emailPriority(msg.Header)
+
+	from, to, cc := decode("From"), decode("To"), decode("Cc")
+
+	metadata := map[string]any{}
+	for key, value := range map[string]string{
+		"emailFrom":      from,
+		"emailTo":        to,
+		"emailCc":        cc,
+		"emailDate":      msg.Header.Get("Date"),
+		"emailMessageId": msg.Header.Get("Message-Id"),
+	} {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	if len(e.attachments) > 0 {
+		metadata["emailAttachments"] = e.attachments
+	}
+
+	raw := map[string]any{
+		"from":    from,
+		"to":      to,
+		"subject": subject,
+		"text":    e.text,
+	}
+	if cc != "" {
+		raw["cc"] = cc
+	}
+	if e.html != "" {
+		raw["html"] = e.html
+	}
+	if len(e.attachments) > 0 {
+		raw["attachments"] = e.attachments
+	}
+
+	if data.RawRequest, err = json.Marshal(raw); err != nil {
+		return dtos.FeedMessageCreate{}, err
+	}
+	if data.RawHeaders, err = json.Marshal(msg.Header); err != nil {
+		return dtos.FeedMessageCreate{}, err
+	}
+	if data.Metadata, err = json.Marshal(metadata); err != nil {
+		return dtos.FeedMessageCreate{}, err
+	}
+
+	return data, nil
+}
+
+// emailPriority returns the priority of the X-Priority header, e.g. "1
+// (Highest)", or of the Importance header. Emails without either are normal
+// priority.
+func emailPriority(header mail.Header) int32 {
+	if fields := strings.Fields(header.Get("X-Priority")); len(fields) > 0 {
+		if p, ok := emailPriorities[fields[0]]; ok {
+			return p
+		}
+	}
+
+	switch strings.ToLower(header.Get("Importance")) {
+	case "high":
+		return 4
+	case "low":
+		return 2
+	}
+
+	return 3
+}
+
+// readPart reads a MIME part, descending into multipart parts. The first
+// text/plain and text/html parts that are not attachments are the bodies,
+// other parts are recorded as attachments.
+func (e *email) readPart(contentType, encoding, disposition string, body io.Reader) error {
+	mt, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mt, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			err = e.readPart(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part.Header.Get("Content-Disposition"), part)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := io.ReadAll(transferDecoder(encoding, body))
+	if err != nil {
+		return err
+	}
+
+	dispType, dispParams, _ := mime.ParseMediaType(disposition)
+	filename := dispParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if filename != "" {
+		if decoded, err := new(mime.WordDecoder).DecodeHeader(filename); err == nil {
+			filename = decoded
+		}
+	}
+
+	isAttachment := dispType == "attachment" || filename != ""
+
+	switch {
+	case mt == "text/plain" && !isAttachment && e.text == "":
+		e.text = decodeCharset(params["charset"], content)
+	case mt == "text/html" && !isAttachment && e.html == "":
+		e.html = decodeCharset(params["charset"], content)
+	default:
+		e.attachments = append(e.attachments, EmailAttachment{
+			Filename:    filename,
+			ContentType: mt,
+			Size:        len(content),
+		})
+	}
+
+	return nil
+}
+
+// transferDecoder decodes a part with its Content-Transfer-Encoding
+func transferDecoder(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &newlineSkipper{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// newlineSkipper drops the line breaks of base64 encoded parts
+type newlineSkipper struct{ r io.Reader }
+
+func (n *newlineSkipper) Read(p []byte) (int, error) {
+	c, err := n.r.Read(p)
+	out := p[:0]
+	for _, b := range p[:c] {
+		if b != '\r' && b != '\n' {
+			out = append(out, b)
+		}
+	}
+	return len(out), err
+}
+
+// decodeCharset converts text in ISO-8859-1 to UTF-8, other charsets are
+// expected to be UTF-8 compatible
+func decodeCharset(charset string, content []byte) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1":
+		if !utf8.Valid(content) {
+			runes := make([]rune, len(content))
+			for i, b := range content {
+				runes[i] = rune(b)
+			}
+			return string(runes)
+		}
+	}
+	return string(bytes.ToValidUTF8(content, []byte("�")))
+}
+
+var (
+	htmlHiddenRe  = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	htmlBreakRe   = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6]|table|blockquote)>`)
+	htmlTagRe     = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLinesRe  = regexp.MustCompile(`\n{3,}`)
+	lineSpacingRe = regexp.MustCompile(`[ \t]+`)
+)
+
+// htmlToText strips the markup of an HTML body, keeping line breaks between
+// block elements
+func htmlToText(s string) string {
+	s = htmlHiddenRe.ReplaceAllString(s, "")
+	s = htmlBreakRe.ReplaceAllString(s, "\n")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(lineSpacingRe.ReplaceAllString(line, " "))
+	}
+
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
@@ -0,0 +1,108 @@
+package adapters
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseEmail_Plain(t *testing.T) {
+	raw := strings.Join([]string{
+		"From: UPS <ups@nas.lan>",
+		"To: power@hookfeed.local",
+		"Subject: =?UTF-8?Q?On_battery_=E2=9A=A1?=",
+		"Date: Mon, 02 Jan 2006 15:04:05 -0700",
+		"Message-Id: <1@nas.lan>",
+		"X-Priority: 1 (Highest)",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"Utility power failed, running on battery =E2=80=93 12 minutes remaining.",
+		"",
+	}, "\r\n")
+
+	got, err := ParseEmail(strings.NewReader(raw), "power")
+	require.NoError(t, err)
+
+	assert.Equal(t, "power", got.FeedID)
+	assert.Equal(t, "On battery ⚡", got.Title)
+	assert.Equal(t, "Utility power failed, running on battery – 12 minutes remaining.", got.Message)
+	assert.Equal(t, int32(5), got.Priority)
+
+	var metadata map[string]any
+	require.NoError(t, json.Unmarshal(got.Metadata, &metadata))
+	assert.Equal(t, "UPS <ups@nas.lan>", metadata["emailFrom"])
+	assert.Equal(t, "power@hookfeed.local", metadata["emailTo"])
+	assert.Equal(t, "<1@nas.lan>", metadata["emailMessageId"])
+	assert.NotContains(t, metadata, "emailAttachments")
+
+	var headers map[string][]string
+	require.NoError(t, json.Unmarshal(got.RawHeaders, &headers))
+	assert.Equal(t, []string{"1 (Highest)"}, headers["X-Priority"])
+}
+
+func Test_ParseEmail_MultipartWithAttachment(t *testing.T) {
+	raw := strings.Join([]string{
+		"From: printer@office.lan",
+		"To: printers@hookfeed.local",
+		"Subject: Toner low",
+		"Importance: high",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="outer"`,
+		"",
+		"--outer",
+		`Content-Type: multipart/alternative; boundary="inner"`,
+		"",
+		"--inner",
+		"Content-Type: text/html; charset=utf-8",
+		"",
+		"<html><head><style>p{color:red}</style></head><body><h1>Status</h1><p>Toner &amp; drum low</p><p>Tray 2<br>empty</p></body></html>",
+		"--inner--",
+		"--outer",
+		"Content-Type: application/pdf; name=report.pdf",
+		"Content-Disposition: attachment; filename=report.pdf",
+		"Content-Transfer-Encoding: base64",
+		"",
+		"JVBERi0x",
+		"LjQK",
+		"--outer--",
+		"",
+	}, "\r\n")
+
+	got, err := ParseEmail(strings.NewReader(raw), "printers")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Toner low", got.Title)
+	assert.Equal(t, "Status\nToner & drum low\nTray 2\nempty", got.Message)
+	assert.Equal(t, int32(4), got.Priority)
+
+	var metadata map[string]any
+	require.NoError(t, json.Unmarshal(got.Metadata, &metadata))
+	assert.Equal(t, []any{
+		map[string]any{"filename": "report.pdf", "contentType": "application/pdf", "size": float64(9)},
+	}, metadata["emailAttachments"])
+
+	var request map[string]any
+	require.NoError(t, json.Unmarshal(got.RawRequest, &request))
+	assert.Equal(t, "printer@office.lan", request["from"])
+	assert.Contains(t, request["html"], "<h1>Status</h1>")
+}
+
+func Test_ParseEmail_Defaults(t *testing.T) {
+	raw := "From: nas@lan\r\n\r\n"
+
+	got, err := ParseEmail(strings.NewReader(raw), "nas")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Untitled Message", got.Title)
+	assert.Equal(t, "no message provided", got.Message)
+	assert.Equal(t, int32(3), got.Priority)
+}
+
+func Test_ParseEmail_Invalid(t *testing.T) {
+	_, err := ParseEmail(strings.NewReader("not an email"), "nas")
+	require.Error(t, err)
+}
@@ -0,0 +1,138 @@
+package adapters
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+)
+
+const (
+	formContentType      = "application/x-www-form-urlencoded"
+	multipartContentType = "multipart/form-data"
+)
+
+// ErrInvalidForm is returned for form posts with a body that cannot be parsed
+var ErrInvalidForm = errors.New("invalid form body")
+
+// IsForm reports whether a request is an HTML form post, url encoded or
+// multipart
+func IsForm(headers http.Header) bool {
+	switch contentMediaType(headers) {
+	case formContentType, multipartContentType:
+		return true
+	}
+	return false
+}
+
+// ParseForm converts the body of an HTML form post into a JSON object. Fields
+// sent once are strings, repeated fields and fields named with a "[]" suffix
+// are arrays. Uploaded files are described by their file name, content type and
+// size, their content is read by [ParseFormFiles].
+func ParseForm(headers http.Header, body []byte) (map[string]any, error) {
+	mt, params, _ := mime.ParseMediaType(headers.Get("Content-Type"))
+
+	values := map[string][]any{}
+
+	switch mt {
+	case formContentType:
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidForm, err)
+		}
+
+		for name, vs := range form {
+			for _, v := range vs {
+				values[name] = append(values[name], v)
+			}
+		}
+	case multipartContentType:
+		form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(1 << 20)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidForm, err)
+		}
+		defer func() { _ = form.RemoveAll() }()
+
+		for name, vs := range form.Value {
+			for _, v := range vs {
+				values[name] = append(values[name], v)
+			}
+		}
+
+		for name, files := range form.File {
+			for _, f := range files {
+				values[name] = append(values[name], map[string]any{
+					"filename":    f.Filename,
+					"contentType": f.Header.Get("Content-Type"),
+					"size":        f.Size,
+				})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported content type %q", ErrInvalidForm, mt)
+	}
+
+	out := make(map[string]any, len(values))
+	for name, vs := range values {
+		key, isArray := strings.CutSuffix(name, "[]")
+		if !isArray && len(vs) == 1 {
+			out[key] = vs[0]
+			continue
+		}
+		out[key] = vs
+	}
+
+	return out, nil
+}
+
+// ParseFormFiles returns the files uploaded with a multipart form post, nil for
+// other requests. A generic content type is left empty to be detected from the
+// content.
+func ParseFormFiles(headers http.Header, body []byte) ([]dtos.AttachmentUpload, error) {
+	mt, params, _ := mime.ParseMediaType(headers.Get("Content-Type"))
+	if mt != multipartContentType {
+		return nil, nil
+	}
+
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidForm, err)
+	}
+	defer func() { _ = form.RemoveAll() }()
+
+	var uploads []dtos.AttachmentUpload
+	for _, files := range form.File {
+		for _, fh := range files {
+			f, err := fh.Open()
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidForm, err)
+			}
+
+			data, err := io.ReadAll(f)
+			_ = f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidForm, err)
+			}
+
+			contentType := fh.Header.Get("Content-Type")
+			if contentType == "application/octet-stream" {
+				contentType = ""
+			}
+
+			uploads = append(uploads, dtos.AttachmentUpload{
+				Name:        fh.Filename,
+				ContentType: contentType,
+				Data:        data,
+			})
+		}
+	}
+
+	return uploads, nil
+}
@@ -0,0 +1,86 @@
+package adapters
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IsForm(t *testing.T) {
+	assert.True(t, IsForm(http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}))
+	assert.True(t, IsForm(http.Header{"Content-Type": {"multipart/form-data; boundary=abc"}}))
+	assert.False(t, IsForm(http.Header{"Content-Type": {"application/json"}}))
+	assert.False(t, IsForm(http.Header{}))
+}
+
+func Test_ParseForm_URLEncoded(t *testing.T) {
+	headers := http.Header{"Content-Type": {"application/x-www-form-urlencoded; charset=utf-8"}}
+	body := "name=Ada+Lovelace&email=ada%40example.com&topic=a&topic=b&tags%5B%5D=one"
+
+	got, err := ParseForm(headers, []byte(body))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"name":  "Ada Lovelace",
+		"email": "ada@example.com",
+		"topic": []any{"a", "b"},
+		"tags":  []any{"one"},
+	}, got)
+}
+
+func Test_ParseForm_Multipart(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	require.NoError(t, mw.WriteField("message", "Hello"))
+	fw, err := mw.CreateFormFile("resume", "cv.pdf")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("%PDF-1.4"))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	headers := http.Header{"Content-Type": {mw.FormDataContentType()}}
+
+	got, err := ParseForm(headers, buf.Bytes())
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello", got["message"])
+	assert.Equal(t, map[string]any{
+		"filename":    "cv.pdf",
+		"contentType": "application/octet-stream",
+		"size":        int64(8),
+	}, got["resume"])
+}
+
+func Test_ParseForm_Invalid(t *testing.T) {
+	_, err := ParseForm(http.Header{"Content-Type": {"multipart/form-data; boundary=abc"}}, []byte("not multipart"))
+	require.ErrorIs(t, err, ErrInvalidForm)
+
+	_, err = ParseForm(http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}, []byte("a=%zz"))
+	require.ErrorIs(t, err, ErrInvalidForm)
+}
+
+func Test_ParseFormFiles(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	require.NoError(t, mw.WriteField("message", "Hello"))
+	fw, err := mw.CreateFormFile("resume", "cv.pdf")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("%PDF-1.4"))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	got, err := ParseFormFiles(http.Header{"Content-Type": {mw.FormDataContentType()}}, buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "cv.pdf", got[0].Name)
+	assert.Empty(t, got[0].ContentType, "generic content types are detected later")
+	assert.Equal(t, []byte("%PDF-1.4"), got[0].Data)
+
+	got, err = ParseFormFiles(http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}, []byte("a=b"))
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
@@ -0,0 +1,186 @@
+package adapters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hay-kot/hookfeed/backend/hookfeed"
+)
+
+// githubConclusionPriorities maps workflow run conclusions to priorities.
+// Conclusions not listed leave the priority unset.
+var githubConclusionPriorities = map[string]int32{
+	"failure":         5,
+	"startup_failure": 5,
+	"timed_out":       5,
+	"cancelled":       4,
+	"action_required": 4,
+}
+
+// GitHubAdapter formats GitHub webhook deliveries based on the X-GitHub-Event
+// header. The push, pull_request, workflow_run, release, issues and ping
+// events get readable titles and messages, other events a generic title.
+//
+//   - X-GitHub-Event, X-GitHub-Delivery → metadata.githubEvent, metadata.githubDelivery
+//   - action → metadata.githubAction
+//   - repository.full_name → metadata.githubRepository
+//   - sender.login → metadata.githubSender
+//   - workflow_run.conclusion → priority
+type GitHubAdapter struct{}
+
+var (
+	_ Adapter  = GitHubAdapter{}
+	_ Verifier = GitHubAdapter{}
+)
+
+func (GitHubAdapter) Name() string    { return "github" }
+func (GitHubAdapter) Version() string { return "v1" }
+
+// Detect matches requests with an X-GitHub-Event header
+func (GitHubAdapter) Detect(_ map[string]any, headers map[string]string) bool {
+	return headerValue(headers, "X-GitHub-Event") != ""
+}
+
+// Verify checks the X-Hub-Signature-256 header, a hex encoded HMAC-SHA256 of
+// the body prefixed with "sha256=".
+func (GitHubAdapter) Verify(secret string, body []byte, headers map[string]string) error {
+	signature, ok := strings.CutPrefix(headerValue(headers, "X-Hub-Signature-256"), "sha256=")
+	if !ok {
+		return fmt.Errorf("%w: missing X-Hub-Signature-256 header", ErrInvalidSignature)
+	}
+
+	if !validHexHMAC(secret, body, signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (GitHubAdapter) Transform(payload map[string]any, headers map[string]string) (*hookfeed.Payload, error) {
+	out := &hookfeed.Payload{Metadata: map[string]any{}}
+
+	event := headerValue(headers, "X-GitHub-Event")
+	action := stringAt(payload, "action")
+	repo := stringAt(payload, "repository", "full_name")
+
+	out.Metadata["githubEvent"] = event
+	for key, value := range map[string]string{
+		"githubDelivery":   headerValue(headers, "X-GitHub-Delivery"),
+		"githubAction":     action,
+		"githubRepository": repo,
+		"githubSender":     stringAt(payload, "sender", "login"),
+	} {
+		if value != "" {
+			out.Metadata[key] = value
+		}
+	}
+
+	var title, url string
+	switch event {
+	case "ping":
+		title = "Webhook ping"
+		out.Message = stringAt(payload, "zen")
+	case "push":
+		title, out.Message = githubPush(payload)
+		url = stringAt(payload, "compare")
+	case "pull_request":
+		pr, _ := payload["pull_request"].(map[string]any)
+		if action == "closed" && pr["merged"] == true {
+			action = "merged"
+		}
+		title = fmt.Sprintf("Pull request #%s %s: %s", idAt(pr, "number"), action, stringAt(pr, "title"))
+		out.Message = strings.TrimSpace(stringAt(pr, "body"))
+		url = stringAt(pr, "html_url")
+	case "issues":
+		issue, _ := payload["issue"].(map[string]any)
+		title = fmt.Sprintf("Issue #%s %s: %s", idAt(issue, "number"), action, stringAt(issue, "title"))
+		out.Message = strings.TrimSpace(stringAt(issue, "body"))
+		url = stringAt(issue, "html_url")
+	case "release":
+		release, _ := payload["release"].(map[string]any)
+		name := stringAt(release, "name")
+		if name == "" {
+			name = stringAt(release, "tag_name")
+		}
+		if release["prerelease"] == true {
+			name += " (pre-release)"
+		}
+		title = fmt.Sprintf("Release %s %s", name, action)
+		out.Message = strings.TrimSpace(stringAt(release, "body"))
+		url = stringAt(release, "html_url")
+	case "workflow_run":
+		run, _ := payload["workflow_run"].(map[string]any)
+		status := stringAt(run, "conclusion")
+		if status == "" {
+			status = stringAt(run, "status")
+		}
+		title = fmt.Sprintf("Workflow %s %s on %s", stringAt(run, "name"), status, stringAt(run, "head_branch"))
+		out.Message = stringAt(run, "display_title")
+		out.Priority = githubConclusionPriorities[stringAt(run, "conclusion")]
+		url = stringAt(run, "html_url")
+	default:
+		title = strings.TrimSpace(event + " " + action)
+	}
+
+	if repo != "" {
+		title = "[" + repo + "] " + title
+	}
+	out.Title = title
+
+	if url != "" {
+		out.Metadata["githubUrl"] = url
+	}
+
+	return out, nil
+}
+
+// githubPush formats a push event, listing the pushed commits
+func githubPush(payload map[string]any) (string, string) {
+	ref := stringAt(payload, "ref")
+	pusher := stringAt(payload, "pusher", "name")
+
+	if tag, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+		if payload["deleted"] == true {
+			return fmt.Sprintf("Tag %s deleted by %s", tag, pusher), ""
+		}
+		return fmt.Sprintf("Tag %s pushed by %s", tag, pusher), ""
+	}
+
+	branch := strings.TrimPrefix(ref, "refs/heads/")
+	if payload["deleted"] == true {
+		return fmt.Sprintf("Branch %s deleted by %s", branch, pusher), ""
+	}
+
+	commits := asSlice(payload["commits"])
+
+	noun := "commits"
+	if len(commits) == 1 {
+		noun = "commit"
+	}
+	title := fmt.Sprintf("%d new %s pushed to %s by %s", len(commits), noun, branch, pusher)
+
+	lines := make([]string, 0, len(commits))
+	for _, item := range commits {
+		commit, _ := item.(map[string]any)
+		id := stringAt(commit, "id")
+		if len(id) > 7 {
+			id = id[:7]
+		}
+		summary, _, _ := strings.Cut(stringAt(commit, "message"), "\n")
+		lines = append(lines, fmt.Sprintf("- %s %s (%s)", id, summary, stringAt(commit, "author", "name")))
+	}
+
+	return title, strings.Join(lines, "\n")
+}
+
+// stringAt returns the string at the path of nested objects, or an empty string
+func stringAt(m map[string]any, path ...string) string {
+	for i, key := range path {
+		if i == len(path)-1 {
+			s, _ := m[key].(string)
+			return s
+		}
+		m, _ = m[key].(map[string]any)
+	}
+	return ""
+}
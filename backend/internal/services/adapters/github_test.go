@@ -0,0 +1,188 @@
+package adapters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func githubTransform(t *testing.T, event, body string) map[string]any {
+	t.Helper()
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &payload))
+
+	headers := map[string]string{"X-GitHub-Event": event, "X-GitHub-Delivery": "72d3162e"}
+	require.True(t, GitHubAdapter{}.Detect(payload, headers))
+
+	out, err := GitHubAdapter{}.Transform(payload, headers)
+	require.NoError(t, err)
+
+	return map[string]any{
+		"title":    out.Title,
+		"message":  out.Message,
+		"priority": out.Priority,
+		"metadata": out.Metadata,
+	}
+}
+
+func Test_GitHubAdapter_Events(t *testing.T) {
+	repo := `"repository": {"full_name": "acme/api"}, "sender": {"login": "octocat"}`
+
+	tests := []struct {
+		name     string
+		event    string
+		body     string
+		title    string
+		message  string
+		priority int32
+		url      string
+	}{
+		{
+			name:  "push",
+			event: "push",
+			body: `{"ref": "refs/heads/main", "compare": "https://github.com/acme/api/compare/a...b", "pusher": {"name": "octocat"},
+				"commits": [
+					{"id": "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c", "message": "Fix login\n\nLonger description", "author": {"name": "Mona"}},
+					{"id": "6113728f27ae82c7b1a177c8d03f9e96e0adf246", "message": "Bump deps", "author": {"name": "Hubot"}}
+				], ` + repo + `}`,
+			title:   "[acme/api] 2 new commits pushed to main by octocat",
+			message: "- 0d1a26e Fix login (Mona)\n- 6113728 Bump deps (Hubot)",
+			url:     "https://github.com/acme/api/compare/a...b",
+		},
+		{
+			name:  "tag push",
+			event: "push",
+			body:  `{"ref": "refs/tags/v1.2.0", "pusher": {"name": "octocat"}, "commits": [], ` + repo + `}`,
+			title: "[acme/api] Tag v1.2.0 pushed by octocat",
+		},
+		{
+			name:  "merged pull request",
+			event: "pull_request",
+			body: `{"action": "closed", "pull_request": {"number": 42, "title": "Add retries", "body": "Closes #7\n",
+				"merged": true, "html_url": "https://github.com/acme/api/pull/42"}, ` + repo + `}`,
+			title:   "[acme/api] Pull request #42 merged: Add retries",
+			message: "Closes #7",
+			url:     "https://github.com/acme/api/pull/42",
+		},
+		{
+			name:  "failed workflow run",
+			event: "workflow_run",
+			body: `{"action": "completed", "workflow_run": {"name": "CI", "status": "completed", "conclusion": "failure",
+				"head_branch": "main", "display_title": "Fix login", "html_url": "https://github.com/acme/api/actions/runs/1"}, ` + repo + `}`,
+			title:    "[acme/api] Workflow CI failure on main",
+			message:  "Fix login",
+			priority: 5,
+			url:      "https://github.com/acme/api/actions/runs/1",
+		},
+		{
+			name:  "successful workflow run",
+			event: "workflow_run",
+			body: `{"action": "completed", "workflow_run": {"name": "CI", "status": "completed", "conclusion": "success",
+				"head_branch": "main"}, ` + repo + `}`,
+			title: "[acme/api] Workflow CI success on main",
+		},
+		{
+			name:    "release",
+			event:   "release",
+			body:    `{"action": "published", "release": {"tag_name": "v1.2.0", "name": "", "prerelease": true, "body": "Changelog"}, ` + repo + `}`,
+			title:   "[acme/api] Release v1.2.0 (pre-release) published",
+			message: "Changelog",
+		},
+		{
+			name:    "issue",
+			event:   "issues",
+			body:    `{"action": "opened", "issue": {"number": 7, "title": "Login broken", "body": "Steps..."}, ` + repo + `}`,
+			title:   "[acme/api] Issue #7 opened: Login broken",
+			message: "Steps...",
+		},
+		{
+			name:    "ping",
+			event:   "ping",
+			body:    `{"zen": "Keep it logically awesome.", "hook_id": 1}`,
+			title:   "Webhook ping",
+			message: "Keep it logically awesome.",
+		},
+		{
+			name:  "other event",
+			event: "star",
+			body:  `{"action": "created", ` + repo + `}`,
+			title: "[acme/api] star created",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := githubTransform(t, tt.event, tt.body)
+
+			assert.Equal(t, tt.title, out["title"])
+			assert.Equal(t, tt.message, out["message"])
+			assert.Equal(t, tt.priority, out["priority"])
+
+			metadata := out["metadata"].(map[string]any)
+			assert.Equal(t, tt.event, metadata["githubEvent"])
+			assert.Equal(t, "72d3162e", metadata["githubDelivery"])
+			if tt.url != "" {
+				assert.Equal(t, tt.url, metadata["githubUrl"])
+			}
+		})
+	}
+}
+
+func Test_GitHubAdapter_Verify(t *testing.T) {
+	// Example from the GitHub webhook documentation
+	secret := "It's a Secret to Everybody"
+	body := []byte("Hello, World!")
+	valid := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	tests := []struct {
+		name      string
+		signature string
+		wantErr   bool
+	}{
+		{"valid", valid, false},
+		{"wrong signature", "sha256=" + "00" + valid[9:], true},
+		{"missing prefix", valid[7:], true},
+		{"not hex", "sha256=zz", true},
+		{"missing", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.signature != "" {
+				headers["X-Hub-Signature-256"] = tt.signature
+			}
+
+			err := GitHubAdapter{}.Verify(secret, body, headers)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_Registry_Verify(t *testing.T) {
+	r := NewRegistry(GitHubAdapter{}, DiscordAdapter{})
+
+	body := []byte("Hello, World!")
+	headers := map[string]string{
+		"X-Hub-Signature-256": "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17",
+	}
+
+	require.NoError(t, r.Verify(nil, body, headers))
+	require.NoError(t, r.Verify(map[string]string{"github": "It's a Secret to Everybody"}, body, headers))
+
+	err := r.Verify(map[string]string{"github@v1": "wrong"}, body, headers)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	err = r.Verify(map[string]string{"discord": "secret"}, body, headers)
+	require.Error(t, err)
+
+	err = r.Verify(map[string]string{"gitlab": "secret"}, body, headers)
+	require.ErrorIs(t, err, ErrUnknownAdapter)
+}
@@ -0,0 +1,137 @@
+package adapters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+)
+
+// ErrGotifyMessageRequired is returned when a Gotify request has no message,
+// matching the validation of the Gotify server
+var ErrGotifyMessageRequired = errors.New("message is required")
+
+// gotifyMessage represents a parsed Gotify-compatible message
+type gotifyMessage struct {
+	Title    string         `json:"title"`
+	Message  string         `json:"message"`
+	Priority *int           `json:"priority"`
+	Extras   map[string]any `json:"extras"`
+}
+
+// GotifyToken returns the application token of a Gotify request. The token is
+// read from the X-Gotify-Key header, the token query parameter, or a bearer
+// Authorization header, in that order.
+func GotifyToken(r *http.Request) string {
+	if token := r.Header.Get("X-Gotify-Key"); token != "" {
+		return token
+	}
+
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
+
+// ParseGotifyMessage parses a Gotify compatible http request and transforms it
+// into a validated creation object or returns an error. JSON, urlencoded and
+// multipart form bodies are supported.
+func ParseGotifyMessage(r *http.Request, feedID string) (dtos.FeedMessageCreate, error) {
+	// Copy HTTP request data (raw body, headers, query params)
+	data, err := feedMessageFromRequest(r)
+	if err != nil {
+		return dtos.FeedMessageCreate{}, fmt.Errorf("failed to copy request: %w", err)
+	}
+
+	data.FeedID = feedID
+
+	var msg gotifyMessage
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		if err := r.ParseMultipartForm(1 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return dtos.FeedMessageCreate{}, fmt.Errorf("invalid form body: %w", err)
+		}
+
+		msg.Title = r.PostForm.Get("title")
+		msg.Message = r.PostForm.Get("message")
+		if p := r.PostForm.Get("priority"); p != "" {
+			priority, err := strconv.Atoi(p)
+			if err != nil {
+				return dtos.FeedMessageCreate{}, fmt.Errorf("invalid priority %q", p)
+			}
+			msg.Priority = &priority
+		}
+		if extras := r.PostForm.Get("extras"); extras != "" {
+			if err := json.Unmarshal([]byte(extras), &msg.Extras); err != nil {
+				return dtos.FeedMessageCreate{}, fmt.Errorf("invalid extras: %w", err)
+			}
+		}
+
+		// Store the decoded form instead of the wrapped raw body
+		data.RawRequest, err = copyAndTransformValues(r.PostForm)
+		if err != nil {
+			return dtos.FeedMessageCreate{}, err
+		}
+	default:
+		if err := json.Unmarshal(data.RawRequest, &msg); err != nil {
+			return dtos.FeedMessageCreate{}, fmt.Errorf("invalid JSON body: %w", err)
+		}
+	}
+
+	if msg.Message == "" {
+		return dtos.FeedMessageCreate{}, ErrGotifyMessageRequired
+	}
+
+	metadata := map[string]any{}
+	if msg.Priority != nil {
+		metadata["gotifyPriority"] = *msg.Priority
+	}
+	if len(msg.Extras) > 0 {
+		metadata["extras"] = msg.Extras
+	}
+
+	data.Metadata, err = json.Marshal(metadata)
+	if err != nil {
+		return dtos.FeedMessageCreate{}, err
+	}
+
+	data.Title = msg.Title
+	data.Message = msg.Message
+	data.Priority = 3
+	if msg.Priority != nil {
+		data.Priority = GotifyPriority(*msg.Priority)
+	}
+
+	return data, nil
+}
+
+// GotifyPriority maps a Gotify priority (0-10) to a hookfeed priority (1-5)
+// following the Gotify client conventions: 0 is silent, 1-3 low, 4-7 normal and
+// 8-10 high, with 10 treated as urgent.
+func GotifyPriority(p int) int32 {
+	switch {
+	case p <= 0:
+		return 1
+	case p <= 3:
+		return 2
+	case p <= 7:
+		return 3
+	case p <= 9:
+		return 4
+	default:
+		return 5
+	}
+}
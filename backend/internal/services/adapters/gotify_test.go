@@ -0,0 +1,167 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GotifyToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(r *http.Request)
+		url   string
+		want  string
+	}{
+		{
+			name:  "header",
+			setup: func(r *http.Request) { r.Header.Set("X-Gotify-Key", "from-header") },
+			url:   "/message?token=from-query",
+			want:  "from-header",
+		},
+		{
+			name: "query",
+			url:  "/message?token=from-query",
+			want: "from-query",
+		},
+		{
+			name:  "bearer",
+			setup: func(r *http.Request) { r.Header.Set("Authorization", "Bearer from-bearer") },
+			url:   "/message",
+			want:  "from-bearer",
+		},
+		{
+			name:  "basic auth is ignored",
+			setup: func(r *http.Request) { r.Header.Set("Authorization", "Basic dXNlcjpwYXNz") },
+			url:   "/message",
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.url, nil)
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+
+			assert.Equal(t, tt.want, GotifyToken(req))
+		})
+	}
+}
+
+func Test_ParseGotifyMessage(t *testing.T) {
+	t.Run("JSON body", func(t *testing.T) {
+		body := `{"title":"Backup","message":"Backup finished","priority":8,"extras":{"client::display":{"contentType":"text/markdown"}}}`
+		req := httptest.NewRequest(http.MethodPost, "/message?token=secret", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		dto, err := ParseGotifyMessage(req, "feed-id")
+		require.NoError(t, err)
+
+		assert.Equal(t, "feed-id", dto.FeedID)
+		assert.Equal(t, "Backup", dto.Title)
+		assert.Equal(t, "Backup finished", dto.Message)
+		assert.Equal(t, int32(4), dto.Priority)
+		assert.JSONEq(t, body, string(dto.RawRequest))
+		assert.JSONEq(t, `{"gotifyPriority":8,"extras":{"client::display":{"contentType":"text/markdown"}}}`, string(dto.Metadata))
+		assert.JSONEq(t, `{"token":"<redacted>"}`, string(dto.RawQueryParams))
+	})
+
+	t.Run("urlencoded form", func(t *testing.T) {
+		form := url.Values{"title": {"Watchtower"}, "message": {"Updated 2 containers"}, "priority": {"2"}}
+		req := httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		dto, err := ParseGotifyMessage(req, "feed-id")
+		require.NoError(t, err)
+
+		assert.Equal(t, "Watchtower", dto.Title)
+		assert.Equal(t, "Updated 2 containers", dto.Message)
+		assert.Equal(t, int32(2), dto.Priority)
+		assert.JSONEq(t, `{"title":"Watchtower","message":"Updated 2 containers","priority":"2"}`, string(dto.RawRequest))
+	})
+
+	t.Run("multipart form", func(t *testing.T) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		require.NoError(t, mw.WriteField("message", "Proxmox backup failed"))
+		require.NoError(t, mw.WriteField("priority", "10"))
+		require.NoError(t, mw.WriteField("extras", `{"client::notification":{"click":{"url":"https://pve.local"}}}`))
+		require.NoError(t, mw.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/message", &buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+
+		dto, err := ParseGotifyMessage(req, "feed-id")
+		require.NoError(t, err)
+
+		assert.Equal(t, "Proxmox backup failed", dto.Message)
+		assert.Equal(t, int32(5), dto.Priority)
+
+		var metadata map[string]any
+		require.NoError(t, json.Unmarshal(dto.Metadata, &metadata))
+		assert.Contains(t, metadata, "extras")
+	})
+
+	t.Run("default priority", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(`{"message":"hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		dto, err := ParseGotifyMessage(req, "feed-id")
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), dto.Priority)
+		assert.JSONEq(t, `{}`, string(dto.Metadata))
+	})
+
+	t.Run("message is required", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(`{"title":"only"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		_, err := ParseGotifyMessage(req, "feed-id")
+		require.ErrorIs(t, err, ErrGotifyMessageRequired)
+	})
+
+	t.Run("invalid priority", func(t *testing.T) {
+		form := url.Values{"message": {"hi"}, "priority": {"high"}}
+		req := httptest.NewRequest(http.MethodPost, "/message", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		_, err := ParseGotifyMessage(req, "feed-id")
+		require.Error(t, err)
+	})
+}
+
+func Test_GotifyPriority(t *testing.T) {
+	want := map[int]int32{0: 1, 1: 2, 3: 2, 4: 3, 7: 3, 8: 4, 9: 4, 10: 5, 15: 5, -1: 1}
+	for in, out := range want {
+		assert.Equal(t, out, GotifyPriority(in), "priority %d", in)
+	}
+}
+
+func Test_MapGotifyMessage(t *testing.T) {
+	title := "Backup"
+	msg := dtos.MapGotifyMessage(dtos.FeedMessage{
+		ID:       uuid.New(),
+		Seq:      42,
+		FeedSlug: "backups",
+		Title:    &title,
+		Priority: 4,
+		Metadata: []byte(`{"gotifyPriority":7}`),
+	})
+
+	assert.Equal(t, int64(42), msg.ID, "the id is the sequential number of the message")
+	assert.Equal(t, dtos.GotifyAppID("backups"), msg.AppID)
+	assert.Equal(t, title, msg.Title)
+	assert.Equal(t, 7, msg.Priority)
+}
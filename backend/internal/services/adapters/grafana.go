@@ -0,0 +1,201 @@
+package adapters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hay-kot/hookfeed/backend/hookfeed"
+)
+
+// grafanaStatePriorities maps Grafana alert states to priorities
+var grafanaStatePriorities = map[string]int32{
+	"alerting": 5,
+	"no_data":  4,
+	"pending":  3,
+	"ok":       2,
+	"paused":   2,
+}
+
+// grafanaNoDataAlert is the alertname of unified alerting no-data alerts
+const grafanaNoDataAlert = "DatasourceNoData"
+
+// GrafanaAdapter adapts Grafana webhook contact point notifications, both the
+// unified alerting format (alerts[]) and the legacy dashboard alert format
+// (ruleUrl, evalMatches). An "ok" notification resolves the earlier messages of
+// its alert group.
+//
+//   - title, or the alertname/ruleName → title
+//   - summary and description annotations, or message → message
+//   - state (alerting, no_data, pending, ok) → priority
+//   - groupKey, or the legacy ruleId → metadata.groupKey
+//   - dashboard, panel, rule, silence and image links → metadata.grafana*Url
+//   - evaluated values → metadata.grafanaValues
+type GrafanaAdapter struct{}
+
+var (
+	_ Adapter  = GrafanaAdapter{}
+	_ Resolver = GrafanaAdapter{}
+)
+
+func (GrafanaAdapter) Name() string    { return "grafana" }
+func (GrafanaAdapter) Version() string { return "v1" }
+
+// Detect matches payloads with an orgId and either an alerts array or a legacy
+// ruleUrl/ruleId
+func (GrafanaAdapter) Detect(payload map[string]any, _ map[string]string) bool {
+	if _, ok := payload["orgId"]; !ok {
+		return false
+	}
+
+	_, hasAlerts := payload["alerts"].([]any)
+	return hasAlerts || payload["ruleUrl"] != nil || payload["ruleId"] != nil
+}
+
+// Resolved reports whether the notification returned the alert to ok
+func (GrafanaAdapter) Resolved(payload map[string]any, _ map[string]string) bool {
+	return grafanaState(payload) == "ok"
+}
+
+func (GrafanaAdapter) Transform(payload map[string]any, _ map[string]string) (*hookfeed.Payload, error) {
+	out := &hookfeed.Payload{Metadata: map[string]any{}}
+
+	state := grafanaState(payload)
+	out.Priority = grafanaStatePriorities[state]
+	out.Metadata["grafanaState"] = state
+
+	alerts := make([]map[string]any, 0)
+	for _, item := range asSlice(payload["alerts"]) {
+		if alert, ok := item.(map[string]any); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	out.Title = stringAt(payload, "title")
+	if out.Title == "" {
+		out.Title = stringAt(payload, "ruleName")
+	}
+	if out.Title == "" {
+		out.Title = stringAt(payload, "commonLabels", "alertname")
+	}
+
+	links := map[string]string{
+		"grafanaRuleUrl":  stringAt(payload, "ruleUrl"),
+		"grafanaImageUrl": firstString(payload, "imageUrl", "imageURL"),
+	}
+
+	var values []any
+	if len(alerts) > 0 {
+		out.Message = grafanaAlertsText(alerts)
+
+		for _, alert := range alerts {
+			for key, field := range map[string]string{
+				"grafanaRuleUrl":      "generatorURL",
+				"grafanaDashboardUrl": "dashboardURL",
+				"grafanaPanelUrl":     "panelURL",
+				"grafanaSilenceUrl":   "silenceURL",
+				"grafanaImageUrl":     "imageURL",
+			} {
+				if links[key] == "" {
+					links[key] = stringAt(alert, field)
+				}
+			}
+
+			if v, ok := alert["values"].(map[string]any); ok && len(v) > 0 {
+				values = append(values, v)
+			}
+		}
+	} else {
+		out.Message = strings.TrimSpace(stringAt(payload, "message"))
+
+		// Legacy alerts report the evaluated series as evalMatches
+		matches := map[string]any{}
+		for _, item := range asSlice(payload["evalMatches"]) {
+			match, _ := item.(map[string]any)
+			if metric := stringAt(match, "metric"); metric != "" {
+				matches[metric] = match["value"]
+			}
+		}
+		if len(matches) > 0 {
+			values = append(values, matches)
+		}
+	}
+
+	for key, link := range links {
+		if link != "" {
+			out.Metadata[key] = link
+		}
+	}
+
+	if len(values) > 0 {
+		out.Metadata["grafanaValues"] = values
+	}
+
+	if groupKey := stringAt(payload, "groupKey"); groupKey != "" {
+		out.Metadata[GroupKeyMetadataKey] = groupKey
+	} else if ruleID, ok := payload["ruleId"].(float64); ok {
+		orgID, _ := payload["orgId"].(float64)
+		out.Metadata[GroupKeyMetadataKey] = fmt.Sprintf("grafana:%d:%d", int64(orgID), int64(ruleID))
+	}
+
+	return out, nil
+}
+
+// grafanaState returns the legacy state of a notification, deriving it from
+// the status and alerts of unified alerting payloads
+func grafanaState(payload map[string]any) string {
+	switch stringAt(payload, "status") {
+	case "resolved":
+		return "ok"
+	case "firing":
+		// Firing groups made only of no-data alerts are reported as no_data
+		noData := false
+		for _, item := range asSlice(payload["alerts"]) {
+			alert, _ := item.(map[string]any)
+			if stringAt(alert, "status") != "firing" {
+				continue
+			}
+			if stringAt(alert, "labels", "alertname") != grafanaNoDataAlert {
+				return "alerting"
+			}
+			noData = true
+		}
+		if noData {
+			return "no_data"
+		}
+	}
+
+	if state := stringAt(payload, "state"); state != "" {
+		return state
+	}
+	return "alerting"
+}
+
+// grafanaAlertsText renders unified alerts like the Alertmanager adapter: the
+// summary and description of a single alert, or one line per alert
+func grafanaAlertsText(alerts []map[string]any) string {
+	lines := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		summary := stringAt(alert, "annotations", "summary")
+		description := stringAt(alert, "annotations", "description")
+
+		if len(alerts) == 1 {
+			lines = append(lines, compact([]string{summary, description})...)
+			if valueString := stringAt(alert, "valueString"); len(lines) == 0 && valueString != "" {
+				lines = append(lines, valueString)
+			}
+			continue
+		}
+
+		name := summary
+		if name == "" {
+			name = stringAt(alert, "labels", "alertname")
+		}
+		line := fmt.Sprintf("- [%s] %s", stringAt(alert, "status"), name)
+		if description != "" {
+			line += ": " + description
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
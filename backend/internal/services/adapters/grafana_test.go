@@ -0,0 +1,158 @@
+package adapters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func grafanaPayload(t *testing.T, body string) map[string]any {
+	t.Helper()
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &payload))
+	require.True(t, GrafanaAdapter{}.Detect(payload, nil))
+
+	return payload
+}
+
+func Test_GrafanaAdapter_Detect(t *testing.T) {
+	a := GrafanaAdapter{}
+
+	// Alertmanager payloads share the alerts array but have no orgId
+	assert.False(t, a.Detect(map[string]any{"groupKey": "g", "status": "firing", "alerts": []any{}}, nil))
+	assert.False(t, a.Detect(map[string]any{"orgId": float64(1)}, nil))
+}
+
+func Test_GrafanaAdapter_Unified(t *testing.T) {
+	payload := grafanaPayload(t, `{
+  "receiver": "hookfeed",
+  "status": "firing",
+  "orgId": 1,
+  "groupKey": "{}/{}:{alertname=\"HighLatency\"}",
+  "title": "[FIRING:1] HighLatency (api)",
+  "state": "alerting",
+  "message": "**Firing**\n\nValue: A=812",
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": { "alertname": "HighLatency", "service": "api" },
+      "annotations": { "summary": "p99 latency above 500ms", "description": "api p99 is 812ms" },
+      "generatorURL": "https://grafana.local/alerting/grafana/abc/view",
+      "silenceURL": "https://grafana.local/alerting/silence/new",
+      "dashboardURL": "https://grafana.local/d/xyz",
+      "panelURL": "https://grafana.local/d/xyz?viewPanel=2",
+      "imageURL": "https://grafana.local/render/2.png",
+      "values": { "A": 812.4, "B": 1 },
+      "valueString": "[ var='A' value=812.4 ]"
+    }
+  ]
+}`)
+
+	a := GrafanaAdapter{}
+	assert.False(t, a.Resolved(payload, nil))
+
+	out, err := a.Transform(payload, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "[FIRING:1] HighLatency (api)", out.Title)
+	assert.Equal(t, "p99 latency above 500ms\napi p99 is 812ms", out.Message)
+	assert.Equal(t, int32(5), out.Priority)
+	assert.Equal(t, map[string]any{
+		"grafanaState":        "alerting",
+		"grafanaRuleUrl":      "https://grafana.local/alerting/grafana/abc/view",
+		"grafanaSilenceUrl":   "https://grafana.local/alerting/silence/new",
+		"grafanaDashboardUrl": "https://grafana.local/d/xyz",
+		"grafanaPanelUrl":     "https://grafana.local/d/xyz?viewPanel=2",
+		"grafanaImageUrl":     "https://grafana.local/render/2.png",
+		"grafanaValues":       []any{map[string]any{"A": 812.4, "B": float64(1)}},
+		"groupKey":            "{}/{}:{alertname=\"HighLatency\"}",
+	}, out.Metadata)
+}
+
+func Test_GrafanaAdapter_States(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		state    string
+		priority int32
+		resolved bool
+	}{
+		{
+			name:     "resolved",
+			body:     `{"orgId": 1, "status": "resolved", "state": "ok", "alerts": [{"status": "resolved", "labels": {"alertname": "HighLatency"}}]}`,
+			state:    "ok",
+			priority: 2,
+			resolved: true,
+		},
+		{
+			name:     "no data",
+			body:     `{"orgId": 1, "status": "firing", "state": "alerting", "alerts": [{"status": "firing", "labels": {"alertname": "DatasourceNoData"}}]}`,
+			state:    "no_data",
+			priority: 4,
+		},
+		{
+			name:     "legacy alerting",
+			body:     `{"orgId": 1, "ruleId": 7, "ruleName": "CPU", "ruleUrl": "https://grafana.local/d/abc", "state": "alerting", "evalMatches": [{"metric": "cpu", "value": 97}]}`,
+			state:    "alerting",
+			priority: 5,
+		},
+		{
+			name:     "legacy no data",
+			body:     `{"orgId": 1, "ruleId": 7, "ruleName": "CPU", "state": "no_data"}`,
+			state:    "no_data",
+			priority: 4,
+		},
+		{
+			name:     "legacy ok",
+			body:     `{"orgId": 1, "ruleId": 7, "ruleName": "CPU", "state": "ok"}`,
+			state:    "ok",
+			priority: 2,
+			resolved: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := grafanaPayload(t, tt.body)
+
+			out, err := GrafanaAdapter{}.Transform(payload, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.state, out.Metadata["grafanaState"])
+			assert.Equal(t, tt.priority, out.Priority)
+			assert.Equal(t, tt.resolved, GrafanaAdapter{}.Resolved(payload, nil))
+		})
+	}
+}
+
+func Test_GrafanaAdapter_Legacy(t *testing.T) {
+	payload := grafanaPayload(t, `{
+  "orgId": 1,
+  "dashboardId": 4,
+  "panelId": 2,
+  "ruleId": 7,
+  "ruleName": "CPU usage",
+  "ruleUrl": "https://grafana.local/d/abc?viewPanel=2",
+  "state": "alerting",
+  "title": "[Alerting] CPU usage",
+  "message": "CPU usage above threshold\n",
+  "imageUrl": "https://grafana.local/render/2.png",
+  "evalMatches": [
+    { "metric": "web-1", "value": 97.5, "tags": {} },
+    { "metric": "web-2", "value": 91, "tags": {} }
+  ]
+}`)
+
+	out, err := GrafanaAdapter{}.Transform(payload, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "[Alerting] CPU usage", out.Title)
+	assert.Equal(t, "CPU usage above threshold", out.Message)
+	assert.Equal(t, "https://grafana.local/d/abc?viewPanel=2", out.Metadata["grafanaRuleUrl"])
+	assert.Equal(t, "https://grafana.local/render/2.png", out.Metadata["grafanaImageUrl"])
+	assert.Equal(t, []any{map[string]any{"web-1": 97.5, "web-2": float64(91)}}, out.Metadata["grafanaValues"])
+	assert.Equal(t, "grafana:1:7", out.Metadata[GroupKeyMetadataKey])
+}
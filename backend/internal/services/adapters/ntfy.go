@@ -1,49 +1,91 @@
 package adapters
 
 import (
+	"bytes"
 	"cmp"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/hay-kot/hookfeed/backend/hookfeed"
 	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/kyokomi/emoji/v2"
 )
 
 // ntfyMessage represents a parsed ntfy-compatible message
 type ntfyMessage struct {
-	Topic    string            `json:"topic"`
-	Message  string            `json:"message"`
-	Title    string            `json:"title"`
-	Priority int32             `json:"priority"`
-	Tags     []string          `json:"tags"`
-	Click    string            `json:"click"`
-	Icon     string            `json:"icon"`
-	Actions  []json.RawMessage `json:"actions"`
-	Markdown bool              `json:"markdown"`
+	Topic    string           `json:"topic"`
+	Message  string           `json:"message"`
+	Title    string           `json:"title"`
+	Priority int32            `json:"priority"`
+	Tags     []string         `json:"tags"`
+	Click    string           `json:"click"`
+	Icon     string           `json:"icon"`
+	Actions  []map[string]any `json:"actions"`
+	Markdown bool             `json:"markdown"`
+	Attach   string           `json:"attach"`
+	Filename string           `json:"filename"`
+	Delay    string           `json:"delay"`
+	Email    string           `json:"email"`
+}
+
+// NtfyMetadata is the metadata stored with messages published through the
+// ntfy-compatible endpoint
+type NtfyMetadata struct {
+	Tags       []string         `json:"tags,omitempty"`
+	Emojis     []string         `json:"emojis,omitempty"` // tags that are emoji shortcodes, expanded
+	Click      string           `json:"click,omitempty"`
+	Icon       string           `json:"icon,omitempty"`
+	Actions    []map[string]any `json:"actions,omitempty"`
+	Markdown   bool             `json:"markdown,omitempty"`
+	Attachment *NtfyAttachment  `json:"attachment,omitempty"`
+	Email      string           `json:"email,omitempty"`
+	Delay      string           `json:"delay,omitempty"`
+	DeliverAt  *time.Time       `json:"deliverAt,omitempty"`
+}
+
+// NtfyAttachment describes a file attached to a ntfy message, either uploaded
+// as the body of a PUT request or linked by URL with X-Attach
+type NtfyAttachment struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type,omitempty"`
+	Size int64  `json:"size,omitempty"`
+	URL  string `json:"url,omitempty"`
 }
 
 // ParseNtfyMessage parses a ntfy compatible http request and transforms it into
 // a validated creation object or returns an error.
 // Priority order: JSON body < Query Params < Headers
 func ParseNtfyMessage(r *http.Request, feedID string) (dtos.FeedMessageCreate, error) {
+	// A PUT of a binary body, or of any body with a filename, is a file upload
+	upload, _, err := ReadNtfyUpload(r)
+	if err != nil {
+		return dtos.FeedMessageCreate{}, err
+	}
+
 	// Copy HTTP request data (raw body, headers, query params)
 	data, err := feedMessageFromRequest(r)
 	if err != nil {
 		return dtos.FeedMessageCreate{}, fmt.Errorf("failed to copy request: %w", err)
 	}
 
+	// Binary content is not stored in the raw request
+	if upload != nil {
+		data.RawRequest = json.RawMessage("{}")
+	}
+
 	// Set the feed ID (passed from controller after resolving key -> ID)
 	data.FeedID = feedID
 
 	// Parse JSON body if Content-Type is application/json
 	var jsonMsg ntfyMessage
-	if r.Header.Get("Content-Type") == "application/json" {
-		var bodyData map[string]interface{}
-		if err := json.Unmarshal(data.RawRequest, &bodyData); err == nil {
-			// Try to unmarshal as ntfy message structure
-			bodyBytes, _ := json.Marshal(bodyData)
-			_ = json.Unmarshal(bodyBytes, &jsonMsg)
-		}
+	if upload == nil && r.Header.Get("Content-Type") == "application/json" {
+		_ = json.Unmarshal(data.RawRequest, &jsonMsg)
 	}
 
 	// Extract values from query parameters
@@ -73,7 +115,7 @@ func ParseNtfyMessage(r *http.Request, feedID string) (dtos.FeedMessageCreate, e
 	priority := cmp.Or(headerPriority, queryPriority, jsonMsg.Priority, int32(3))
 
 	// If message is still empty, try to use the raw body as plain text
-	if message == "" {
+	if message == "" && upload == nil {
 		var bodyData map[string]interface{}
 		if err := json.Unmarshal(data.RawRequest, &bodyData); err == nil {
 			// Check for $body key (plain text wrapped by copyBody)
@@ -83,6 +125,20 @@ func ParseNtfyMessage(r *http.Request, feedID string) (dtos.FeedMessageCreate, e
 		}
 	}
 
+	metadata, err := parseNtfyMetadata(r, jsonMsg, upload)
+	if err != nil {
+		return dtos.FeedMessageCreate{}, err
+	}
+
+	if message == "" && metadata.Attachment != nil {
+		message = "You received a file: " + cmp.Or(metadata.Attachment.Name, "attachment")
+	}
+
+	data.Metadata, err = json.Marshal(metadata)
+	if err != nil {
+		return dtos.FeedMessageCreate{}, err
+	}
+
 	// Set the parsed ntfy fields
 	data.Title = title
 	data.Message = message
@@ -90,3 +146,255 @@ func ParseNtfyMessage(r *http.Request, feedID string) (dtos.FeedMessageCreate, e
 
 	return data, nil
 }
+
+// parseNtfyMetadata resolves the optional ntfy publish parameters with the same
+// precedence as the title and message: headers > query > json
+func parseNtfyMetadata(r *http.Request, jsonMsg ntfyMessage, upload *NtfyAttachment) (NtfyMetadata, error) {
+	query := r.URL.Query()
+
+	param := func(headers []string, params []string, fallback string) string {
+		return cmp.Or(GetHeader(r, headers...), GetQueryParam(query, params...), fallback)
+	}
+
+	meta := NtfyMetadata{
+		Click: param([]string{"X-Click", "Click"}, []string{"click"}, jsonMsg.Click),
+		Icon:  param([]string{"X-Icon", "Icon"}, []string{"icon"}, jsonMsg.Icon),
+		Email: param([]string{"X-Email", "X-E-Mail", "Email", "E-Mail", "Mail", "E"}, []string{"email", "mail", "e"}, jsonMsg.Email),
+	}
+
+	meta.Tags = jsonMsg.Tags
+	if tags := param([]string{"X-Tags", "X-Tag", "Tags", "Tag", "Ta"}, []string{"tags", "tag", "ta"}, ""); tags != "" {
+		meta.Tags = SplitAndTrim(tags)
+	}
+	meta.Emojis = NtfyTagEmojis(meta.Tags)
+
+	meta.Markdown = jsonMsg.Markdown
+	if md := param([]string{"X-Markdown", "Markdown", "Md"}, []string{"markdown", "md"}, ""); md != "" {
+		meta.Markdown = NtfyBool(md)
+	}
+	if r.Header.Get("Content-Type") == "text/markdown" {
+		meta.Markdown = true
+	}
+
+	meta.Actions = jsonMsg.Actions
+	if actions := param([]string{"X-Actions", "Actions", "Action"}, []string{"actions", "action"}, ""); actions != "" {
+		parsed, err := parseNtfyActions(actions)
+		if err != nil {
+			return NtfyMetadata{}, err
+		}
+		meta.Actions = parsed
+	}
+
+	filename := param([]string{"X-Filename", "Filename", "File", "F"}, []string{"filename", "file", "f"}, jsonMsg.Filename)
+	switch {
+	case upload != nil:
+		meta.Attachment = upload
+		meta.Attachment.Name = cmp.Or(filename, meta.Attachment.Name)
+	default:
+		if attach := param([]string{"X-Attach", "Attach", "A"}, []string{"attach", "a"}, jsonMsg.Attach); attach != "" {
+			meta.Attachment = &NtfyAttachment{URL: attach, Name: filename}
+		}
+	}
+
+	if delay := param([]string{"X-Delay", "Delay", "X-At", "At", "X-In", "In"}, []string{"delay", "at", "in"}, jsonMsg.Delay); delay != "" {
+		at, err := parseNtfyDelay(delay, time.Now())
+		if err != nil {
+			return NtfyMetadata{}, err
+		}
+		meta.Delay = delay
+		meta.DeliverAt = &at
+	}
+
+	return meta, nil
+}
+
+// ReadNtfyUpload returns the attachment described by the body of a PUT
+// request and its content, or nil when the body is a regular message. The body
+// is buffered so it can be read again by the caller.
+func ReadNtfyUpload(r *http.Request) (*NtfyAttachment, []byte, error) {
+	if r.Method != http.MethodPut {
+		return nil, nil, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	_ = r.Body.Close()
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	filename := cmp.Or(GetHeader(r, "X-Filename", "Filename", "File", "F"), GetQueryParam(r.URL.Query(), "filename", "file", "f"))
+	if len(body) == 0 || (filename == "" && utf8.Valid(body)) {
+		return nil, nil, nil
+	}
+
+	return &NtfyAttachment{
+		Name: cmp.Or(filename, "attachment"),
+		Type: http.DetectContentType(body),
+		Size: int64(len(body)),
+	}, body, nil
+}
+
+// NtfyTagEmojis returns the emoji for each tag that is an emoji shortcode,
+// for example "warning" expands to ⚠️
+func NtfyTagEmojis(tags []string) []string {
+	codes := emoji.CodeMap()
+
+	var emojis []string
+	for _, tag := range tags {
+		if e, ok := codes[":"+strings.ToLower(tag)+":"]; ok {
+			emojis = append(emojis, e)
+		}
+	}
+	return emojis
+}
+
+// NtfyBool parses a boolean parameter the way ntfy does
+func NtfyBool(s string) bool {
+	switch strings.ToLower(s) {
+	case "1", "yes", "true":
+		return true
+	}
+	return false
+}
+
+// parseNtfyDelay parses a ntfy delay, which is a unix timestamp, a duration
+// such as 30m, 2h or 1d, or an RFC3339 time, relative to now
+func parseNtfyDelay(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.Add(time.Duration(n) * 24 * time.Hour).UTC(), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(d).UTC(), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid delay %q", s)
+}
+
+// parseNtfyActions parses the X-Actions header, which is either a JSON array
+// or ntfy's short format: "<action>, <label>, <url>, key=value; ..."
+func parseNtfyActions(s string) ([]map[string]any, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") {
+		var actions []map[string]any
+		if err := json.Unmarshal([]byte(s), &actions); err != nil {
+			return nil, fmt.Errorf("invalid actions: %w", err)
+		}
+		return actions, nil
+	}
+
+	var actions []map[string]any
+	for _, def := range strings.Split(s, ";") {
+		fields := SplitAndTrim(def)
+		if len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+
+		action := map[string]any{"action": strings.ToLower(fields[0]), "label": fields[1]}
+		for i, field := range fields[2:] {
+			key, value, ok := strings.Cut(field, "=")
+			switch {
+			case !ok && i == 0:
+				action["url"] = field
+			case !ok:
+				return nil, fmt.Errorf("invalid action field %q", field)
+			case key == "clear":
+				action[key] = NtfyBool(value)
+			default:
+				action[key] = value
+			}
+		}
+
+		actions = append(actions, action)
+	}
+
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("invalid actions %q", s)
+	}
+
+	return actions, nil
+}
+
+// NtfyAdapter adapts ntfy JSON publish requests sent to a webhook endpoint.
+// Headers take precedence over the JSON body.
+type NtfyAdapter struct{}
+
+var _ Adapter = NtfyAdapter{}
+
+func (NtfyAdapter) Name() string    { return "ntfy" }
+func (NtfyAdapter) Version() string { return "v1" }
+
+// ntfyDetectHeaders are headers only ntfy publishers send. Generic headers
+// such as X-Priority and X-Title are also set by mail bridges and other senders.
+var ntfyDetectHeaders = []string{"X-Ntfy-ID", "X-Actions", "X-Click", "X-Attach", "X-Markdown", "X-Delay", "X-Icon"}
+
+// Detect matches requests with ntfy specific headers, or a JSON body with a
+// topic and a message or title
+func (NtfyAdapter) Detect(payload map[string]any, headers map[string]string) bool {
+	if headerValue(headers, ntfyDetectHeaders...) != "" {
+		return true
+	}
+
+	topic, _ := payload["topic"].(string)
+	message, _ := payload["message"].(string)
+	title, _ := payload["title"].(string)
+
+	return topic != "" && (message != "" || title != "")
+}
+
+func (NtfyAdapter) Transform(payload map[string]any, headers map[string]string) (*hookfeed.Payload, error) {
+	out := &hookfeed.Payload{Metadata: map[string]any{}}
+
+	bodyTitle, _ := payload["title"].(string)
+	bodyMessage, _ := payload["message"].(string)
+
+	out.Title = cmp.Or(headerValue(headers, "X-Title", "Title"), bodyTitle)
+	out.Message = cmp.Or(headerValue(headers, "X-Message", "Message"), bodyMessage)
+
+	if p := headerValue(headers, "X-Priority", "Priority"); p != "" {
+		out.Priority, _ = ParsePriority(p)
+	} else {
+		switch p := payload["priority"].(type) {
+		case float64:
+			out.Priority, _ = ParsePriority(strconv.Itoa(int(p)))
+		case string:
+			out.Priority, _ = ParsePriority(p)
+		}
+	}
+
+	var tags []string
+	if tags = SplitAndTrim(headerValue(headers, "X-Tags", "Tags")); len(tags) > 0 {
+		out.Metadata["tags"] = tags
+	} else if bodyTags, ok := payload["tags"].([]any); ok && len(bodyTags) > 0 {
+		out.Metadata["tags"] = bodyTags
+		for _, tag := range bodyTags {
+			if s, ok := tag.(string); ok {
+				tags = append(tags, s)
+			}
+		}
+	}
+
+	if emojis := NtfyTagEmojis(tags); len(emojis) > 0 {
+		out.Metadata["emojis"] = emojis
+	}
+
+	if topic, _ := payload["topic"].(string); topic != "" {
+		out.Metadata["topic"] = topic
+	}
+
+	return out, nil
+}
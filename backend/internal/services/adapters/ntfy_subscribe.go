@@ -0,0 +1,207 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+)
+
+// ntfy subscribe event types
+const (
+	NtfyEventOpen      = "open"
+	NtfyEventKeepalive = "keepalive"
+	NtfyEventMessage   = "message"
+)
+
+// NtfyEvent is a message in the ntfy subscribe wire format, see
+// https://docs.ntfy.sh/subscribe/api/#json-message-format
+type NtfyEvent struct {
+	ID          string           `json:"id"`
+	Time        int64            `json:"time"`
+	Event       string           `json:"event"`
+	Topic       string           `json:"topic"`
+	Title       string           `json:"title,omitempty"`
+	Message     string           `json:"message,omitempty"`
+	Priority    int32            `json:"priority,omitempty"`
+	Tags        []string         `json:"tags,omitempty"`
+	Click       string           `json:"click,omitempty"`
+	Icon        string           `json:"icon,omitempty"`
+	Actions     []map[string]any `json:"actions,omitempty"`
+	Attachment  *NtfyAttachment  `json:"attachment,omitempty"`
+	ContentType string           `json:"content_type,omitempty"`
+}
+
+// NewNtfyEvent returns a control event, such as open or keepalive, for a topic
+func NewNtfyEvent(event, topic string) NtfyEvent {
+	return NtfyEvent{
+		ID:    uuid.NewString(),
+		Time:  time.Now().Unix(),
+		Event: event,
+		Topic: topic,
+	}
+}
+
+// NtfyMessageEvent converts a feed message into a ntfy message event. The ntfy
+// fields are read from the message metadata, so messages published through
+// any endpoint keep their tags, click URL and actions.
+func NtfyMessageEvent(msg dtos.FeedMessage, topic string) NtfyEvent {
+	var meta NtfyMetadata
+	if len(msg.Metadata) > 0 {
+		// Metadata of other sources may not match, keep the fields that do
+		_ = json.Unmarshal(msg.Metadata, &meta)
+	}
+
+	event := NtfyEvent{
+		ID:         msg.ID.String(),
+		Time:       msg.ReceivedAt.Unix(),
+		Event:      NtfyEventMessage,
+		Topic:      topic,
+		Priority:   msg.Priority,
+		Tags:       slices.DeleteFunc(meta.Tags, func(tag string) bool { return tag == "" }),
+		Click:      meta.Click,
+		Icon:       meta.Icon,
+		Actions:    meta.Actions,
+		Attachment: meta.Attachment,
+	}
+
+	if msg.Title != nil {
+		event.Title = *msg.Title
+	}
+	if msg.Message != nil {
+		event.Message = *msg.Message
+	}
+	if meta.Markdown {
+		event.ContentType = "text/markdown"
+	}
+
+	return event
+}
+
+// NtfySince is the since= parameter of a subscribe request. Either Time or
+// MessageID is set, unless no cached messages are requested.
+type NtfySince struct {
+	All       bool
+	Latest    bool
+	Time      time.Time
+	MessageID uuid.UUID
+}
+
+// IsNone reports whether no cached messages are requested
+func (s NtfySince) IsNone() bool {
+	return !s.All && !s.Latest && s.Time.IsZero() && s.MessageID == uuid.Nil
+}
+
+// ParseNtfySince parses a since= parameter: all, latest, none, a duration such
+// as 10m or 1d, a unix timestamp or a message ID
+func ParseNtfySince(s string, now time.Time) (NtfySince, error) {
+	switch s {
+	case "", "none":
+		return NtfySince{}, nil
+	case "all":
+		return NtfySince{All: true}, nil
+	case "latest":
+		return NtfySince{Latest: true}, nil
+	}
+
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return NtfySince{Time: time.Unix(secs, 0)}, nil
+	}
+
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return NtfySince{Time: now.Add(-time.Duration(n) * 24 * time.Hour)}, nil
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return NtfySince{Time: now.Add(-d)}, nil
+	}
+
+	if id, err := uuid.Parse(s); err == nil {
+		return NtfySince{MessageID: id}, nil
+	}
+
+	return NtfySince{}, fmt.Errorf("invalid since %q", s)
+}
+
+// NtfyFilter is the set of subscribe filters, see
+// https://docs.ntfy.sh/subscribe/api/#filter-messages
+type NtfyFilter struct {
+	ID         string
+	Title      string
+	Message    string
+	Priorities []int32  // any of the priorities
+	Tags       []string // all of the tags
+}
+
+// ParseNtfyFilter reads the filter parameters from the query string or the
+// X- headers of a subscribe request
+func ParseNtfyFilter(r *http.Request) (NtfyFilter, error) {
+	query := r.URL.Query()
+
+	param := func(headers []string, params ...string) string {
+		if v := GetQueryParam(query, params...); v != "" {
+			return v
+		}
+		return GetHeader(r, headers...)
+	}
+
+	filter := NtfyFilter{
+		ID:      param([]string{"X-ID"}, "id"),
+		Title:   param([]string{"X-Title", "Title"}, "title", "t"),
+		Message: param([]string{"X-Message", "Message"}, "message", "m"),
+		Tags:    SplitAndTrim(param([]string{"X-Tags", "Tags", "Ta"}, "tags", "tag", "ta")),
+	}
+
+	for _, p := range SplitAndTrim(param([]string{"X-Priority", "Priority"}, "priority", "prio", "p")) {
+		priority, err := ParsePriority(p)
+		if err != nil {
+			return NtfyFilter{}, fmt.Errorf("invalid priority %q", p)
+		}
+		filter.Priorities = append(filter.Priorities, priority)
+	}
+
+	return filter, nil
+}
+
+// Match reports whether a message event passes the filter
+func (f NtfyFilter) Match(e NtfyEvent) bool {
+	if e.Event != NtfyEventMessage {
+		return true
+	}
+
+	if f.ID != "" && e.ID != f.ID {
+		return false
+	}
+
+	if f.Title != "" && e.Title != f.Title {
+		return false
+	}
+
+	if f.Message != "" && e.Message != f.Message {
+		return false
+	}
+
+	priority := e.Priority
+	if priority == 0 {
+		priority = 3
+	}
+	if len(f.Priorities) > 0 && !slices.Contains(f.Priorities, priority) {
+		return false
+	}
+
+	for _, tag := range f.Tags {
+		if !slices.Contains(e.Tags, tag) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,145 @@
+package adapters
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NtfyMessageEvent(t *testing.T) {
+	title := "Backup"
+	message := "Backup **done**"
+	received := time.Unix(1700000000, 0)
+
+	msg := dtos.FeedMessage{
+		ID:         uuid.New(),
+		FeedSlug:   "backups",
+		Title:      &title,
+		Message:    &message,
+		Priority:   4,
+		Metadata:   json.RawMessage(`{"tags": ["tada"], "emojis": ["🎉"], "click": "https://example.com", "markdown": true}`),
+		ReceivedAt: received,
+	}
+
+	event := NtfyMessageEvent(msg, "backups-key")
+
+	assert.Equal(t, NtfyEvent{
+		ID:          msg.ID.String(),
+		Time:        1700000000,
+		Event:       NtfyEventMessage,
+		Topic:       "backups-key",
+		Title:       "Backup",
+		Message:     "Backup **done**",
+		Priority:    4,
+		Tags:        []string{"tada"},
+		Click:       "https://example.com",
+		ContentType: "text/markdown",
+	}, event)
+}
+
+func Test_NtfyMessageEvent_ForeignMetadata(t *testing.T) {
+	message := "deploy finished"
+	msg := dtos.FeedMessage{
+		ID:       uuid.New(),
+		Message:  &message,
+		Priority: 3,
+		Metadata: json.RawMessage(`{"tags": [1, 2], "click": "https://example.com"}`),
+	}
+
+	event := NtfyMessageEvent(msg, "deploys")
+
+	assert.Equal(t, "deploy finished", event.Message)
+	assert.Empty(t, event.Tags)
+	assert.Equal(t, "https://example.com", event.Click)
+}
+
+func Test_ParseNtfySince(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	id := uuid.New()
+
+	tests := []struct {
+		in      string
+		want    NtfySince
+		wantErr bool
+	}{
+		{in: "", want: NtfySince{}},
+		{in: "none", want: NtfySince{}},
+		{in: "all", want: NtfySince{All: true}},
+		{in: "latest", want: NtfySince{Latest: true}},
+		{in: "10m", want: NtfySince{Time: now.Add(-10 * time.Minute)}},
+		{in: "2d", want: NtfySince{Time: now.Add(-48 * time.Hour)}},
+		{in: "1704067200", want: NtfySince{Time: time.Unix(1704067200, 0)}},
+		{in: id.String(), want: NtfySince{MessageID: id}},
+		{in: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseNtfySince(tt.in, now)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_NtfyFilter(t *testing.T) {
+	event := NtfyEvent{
+		ID:       "abc",
+		Event:    NtfyEventMessage,
+		Title:    "Disk",
+		Message:  "disk full",
+		Priority: 5,
+		Tags:     []string{"warning", "disk"},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{name: "no filters", query: "", want: true},
+		{name: "id", query: "id=abc", want: true},
+		{name: "other id", query: "id=def", want: false},
+		{name: "title", query: "title=Disk", want: true},
+		{name: "message", query: "message=disk+full", want: true},
+		{name: "other message", query: "message=disk", want: false},
+		{name: "any priority", query: "priority=high,urgent", want: true},
+		{name: "other priority", query: "priority=1,2", want: false},
+		{name: "all tags", query: "tags=warning,disk", want: true},
+		{name: "missing tag", query: "tags=warning,cpu", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/topic/json?"+tt.query, nil)
+
+			filter, err := ParseNtfyFilter(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, filter.Match(event))
+		})
+	}
+
+	t.Run("control events always match", func(t *testing.T) {
+		filter := NtfyFilter{ID: "def"}
+		assert.True(t, filter.Match(NewNtfyEvent(NtfyEventKeepalive, "topic")))
+	})
+
+	t.Run("invalid priority", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/topic/json?priority=loud", nil)
+
+		_, err := ParseNtfyFilter(req)
+		require.Error(t, err)
+	})
+}
@@ -1,6 +1,7 @@
 package adapters
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
@@ -8,6 +9,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -235,3 +237,149 @@ func Test_ParseNtfyMessage(t *testing.T) {
 		assert.JSONEq(t, expectedHeaders, string(dto.RawHeaders))
 	})
 }
+
+func Test_ParseNtfyMessage_Metadata(t *testing.T) {
+	parse := func(t *testing.T, req *http.Request) (string, NtfyMetadata) {
+		t.Helper()
+
+		dto, err := ParseNtfyMessage(req, "test-feed-id")
+		require.NoError(t, err)
+
+		var meta NtfyMetadata
+		require.NoError(t, json.Unmarshal(dto.Metadata, &meta))
+		return dto.Message, meta
+	}
+
+	t.Run("headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/test-topic", strings.NewReader("disk full"))
+		req.Header.Set("X-Tags", "warning, disk")
+		req.Header.Set("X-Click", "https://example.com")
+		req.Header.Set("X-Icon", "https://example.com/icon.png")
+		req.Header.Set("X-Markdown", "yes")
+		req.Header.Set("X-Email", "ops@example.com")
+		req.Header.Set("X-Attach", "https://example.com/log.txt")
+		req.Header.Set("X-Filename", "log.txt")
+
+		msg, meta := parse(t, req)
+
+		assert.Equal(t, "disk full", msg)
+		assert.Equal(t, []string{"warning", "disk"}, meta.Tags)
+		assert.Equal(t, []string{"⚠️"}, meta.Emojis)
+		assert.Equal(t, "https://example.com", meta.Click)
+		assert.Equal(t, "https://example.com/icon.png", meta.Icon)
+		assert.True(t, meta.Markdown)
+		assert.Equal(t, "ops@example.com", meta.Email)
+		assert.Equal(t, &NtfyAttachment{URL: "https://example.com/log.txt", Name: "log.txt"}, meta.Attachment)
+	})
+
+	t.Run("JSON body", func(t *testing.T) {
+		body := `{
+			"topic": "test-topic",
+			"message": "backup done",
+			"tags": ["tada"],
+			"click": "https://example.com",
+			"markdown": true,
+			"actions": [{"action": "view", "label": "Open", "url": "https://example.com"}]
+		}`
+
+		req := httptest.NewRequest(http.MethodPost, "/test-topic?tags=rocket", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		_, meta := parse(t, req)
+
+		assert.Equal(t, []string{"rocket"}, meta.Tags, "query params should override JSON")
+		assert.Equal(t, "https://example.com", meta.Click)
+		assert.True(t, meta.Markdown)
+		require.Len(t, meta.Actions, 1)
+		assert.Equal(t, "Open", meta.Actions[0]["label"])
+	})
+
+	t.Run("short format actions", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/test-topic", strings.NewReader("door opened"))
+		req.Header.Set("X-Actions", "view, Open portal, https://home.example.com, clear=true; http, Close door, https://api.example.com/door, method=PUT")
+
+		_, meta := parse(t, req)
+
+		require.Len(t, meta.Actions, 2)
+		assert.Equal(t, map[string]any{"action": "view", "label": "Open portal", "url": "https://home.example.com", "clear": true}, meta.Actions[0])
+		assert.Equal(t, map[string]any{"action": "http", "label": "Close door", "url": "https://api.example.com/door", "method": "PUT"}, meta.Actions[1])
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/test-topic?delay=1700000000", strings.NewReader("later"))
+
+		_, meta := parse(t, req)
+
+		assert.Equal(t, "1700000000", meta.Delay)
+		require.NotNil(t, meta.DeliverAt)
+		assert.Equal(t, time.Unix(1700000000, 0).UTC(), *meta.DeliverAt)
+	})
+
+	t.Run("invalid delay", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/test-topic", strings.NewReader("later"))
+		req.Header.Set("X-Delay", "someday")
+
+		_, err := ParseNtfyMessage(req, "test-feed-id")
+		require.Error(t, err)
+	})
+
+	t.Run("binary PUT is an attachment", func(t *testing.T) {
+		png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\xff\xfe")
+		req := httptest.NewRequest(http.MethodPut, "/test-topic", bytes.NewReader(png))
+		req.Header.Set("X-Filename", "screen.png")
+
+		dto, err := ParseNtfyMessage(req, "test-feed-id")
+		require.NoError(t, err)
+
+		assert.Equal(t, "You received a file: screen.png", dto.Message)
+		assert.JSONEq(t, `{}`, string(dto.RawRequest))
+
+		var meta NtfyMetadata
+		require.NoError(t, json.Unmarshal(dto.Metadata, &meta))
+		assert.Equal(t, &NtfyAttachment{Name: "screen.png", Type: "image/png", Size: int64(len(png))}, meta.Attachment)
+
+		// The body is still readable by the caller
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, png, body)
+	})
+
+	t.Run("text PUT is a message", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/test-topic", strings.NewReader("plain text"))
+
+		msg, meta := parse(t, req)
+
+		assert.Equal(t, "plain text", msg)
+		assert.Nil(t, meta.Attachment)
+	})
+}
+
+func Test_parseNtfyDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "30m", want: now.Add(30 * time.Minute)},
+		{in: "2h", want: now.Add(2 * time.Hour)},
+		{in: "1d", want: now.Add(24 * time.Hour)},
+		{in: "1704153600", want: time.Unix(1704153600, 0).UTC()},
+		{in: "2024-01-02T10:00:00Z", want: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)},
+		{in: "tomorrow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseNtfyDelay(tt.in, now)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
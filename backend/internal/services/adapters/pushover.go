@@ -0,0 +1,164 @@
+package adapters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+)
+
+// PushoverParamError is a request parameter that failed validation. The
+// message is phrased like the errors returned by the Pushover API.
+type PushoverParamError struct {
+	Param string
+	Msg   string
+}
+
+func (e *PushoverParamError) Error() string {
+	return e.Param + " " + e.Msg
+}
+
+// PushoverRequest is a parsed Pushover message request
+type PushoverRequest struct {
+	Token string                 // application token, resolved to a feed key
+	User  string                 // user or group key, not validated
+	Data  dtos.FeedMessageCreate // message with FeedID unset
+}
+
+// pushoverPriorities maps Pushover priorities (-2 to 2) to hookfeed priorities
+var pushoverPriorities = map[int]int32{-2: 1, -1: 2, 0: 3, 1: 4, 2: 5}
+
+// ParsePushoverMessage parses a Pushover compatible http request. Parameters
+// are read from a JSON body, or from the form and query string otherwise.
+// Validation failures are returned as *PushoverParamError.
+func ParsePushoverMessage(r *http.Request) (PushoverRequest, error) {
+	data, err := feedMessageFromRequest(r)
+	if err != nil {
+		return PushoverRequest{}, fmt.Errorf("failed to copy request: %w", err)
+	}
+
+	var params map[string]string
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var body map[string]any
+		if err := json.Unmarshal(data.RawRequest, &body); err != nil {
+			return PushoverRequest{}, fmt.Errorf("invalid JSON body: %w", err)
+		}
+
+		params = make(map[string]string, len(body))
+		for k, v := range body {
+			switch v := v.(type) {
+			case string:
+				params[k] = v
+			case float64:
+				params[k] = strconv.FormatFloat(v, 'f', -1, 64)
+			case bool:
+				params[k] = "0"
+				if v {
+					params[k] = "1"
+				}
+			}
+		}
+	} else {
+		if err := r.ParseMultipartForm(1 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return PushoverRequest{}, fmt.Errorf("invalid form body: %w", err)
+		}
+
+		params = firstFormValues(r)
+	}
+
+	// Store the parameters with the token redacted instead of the raw body
+	data.RawRequest, err = json.Marshal(redactParams(params))
+	if err != nil {
+		return PushoverRequest{}, err
+	}
+
+	req := PushoverRequest{
+		Token: params["token"],
+		User:  params["user"],
+	}
+
+	if req.Token == "" {
+		return req, &PushoverParamError{Param: "token", Msg: "is invalid"}
+	}
+
+	message := strings.TrimSpace(params["message"])
+	if message == "" {
+		return req, &PushoverParamError{Param: "message", Msg: "cannot be blank"}
+	}
+
+	priority := 0
+	if p := params["priority"]; p != "" {
+		priority, err = strconv.Atoi(p)
+		if _, ok := pushoverPriorities[priority]; err != nil || !ok {
+			return req, &PushoverParamError{Param: "priority", Msg: "is invalid"}
+		}
+	}
+
+	metadata := map[string]any{"pushoverPriority": priority}
+
+	html := params["html"] == "1"
+	monospace := params["monospace"] == "1"
+	switch {
+	case html && monospace:
+		return req, &PushoverParamError{Param: "html", Msg: "cannot be used with monospace"}
+	case html:
+		metadata["format"] = "html"
+	case monospace:
+		metadata["format"] = "monospace"
+	}
+
+	if ts := params["timestamp"]; ts != "" {
+		secs, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return req, &PushoverParamError{Param: "timestamp", Msg: "is invalid"}
+		}
+		metadata["timestamp"] = time.Unix(secs, 0).UTC()
+	}
+
+	for param, key := range map[string]string{"url": "url", "url_title": "urlTitle", "sound": "sound", "device": "device"} {
+		if v := params[param]; v != "" {
+			metadata[key] = v
+		}
+	}
+
+	data.Metadata, err = json.Marshal(metadata)
+	if err != nil {
+		return req, err
+	}
+
+	data.Title = params["title"]
+	data.Message = message
+	data.Priority = pushoverPriorities[priority]
+	req.Data = data
+
+	return req, nil
+}
+
+// firstFormValues returns the first value of every form and query parameter,
+// form values taking precedence
+func firstFormValues(r *http.Request) map[string]string {
+	params := make(map[string]string, len(r.Form))
+	for k, v := range r.Form {
+		if len(v) > 0 {
+			params[k] = v[0]
+		}
+	}
+	return params
+}
+
+// redactParams returns a copy of params with secret values redacted
+func redactParams(params map[string]string) map[string]string {
+	result := make(map[string]string, len(params))
+	for k, v := range params {
+		result[k] = sanitizeSecrets(k, v)
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+package adapters
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParsePushoverMessage(t *testing.T) {
+	t.Run("urlencoded form", func(t *testing.T) {
+		form := url.Values{
+			"token":     {"app-token"},
+			"user":      {"user-key"},
+			"title":     {"Backup"},
+			"message":   {"Backup finished"},
+			"priority":  {"1"},
+			"url":       {"https://nas.local"},
+			"url_title": {"Open NAS"},
+			"html":      {"1"},
+			"timestamp": {"1700000000"},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/1/messages.json", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		got, err := ParsePushoverMessage(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, "app-token", got.Token)
+		assert.Equal(t, "user-key", got.User)
+		assert.Equal(t, "Backup", got.Data.Title)
+		assert.Equal(t, "Backup finished", got.Data.Message)
+		assert.Equal(t, int32(4), got.Data.Priority)
+		assert.JSONEq(t, `{
+			"pushoverPriority": 1,
+			"format": "html",
+			"timestamp": "2023-11-14T22:13:20Z",
+			"url": "https://nas.local",
+			"urlTitle": "Open NAS"
+		}`, string(got.Data.Metadata))
+	})
+
+	t.Run("JSON body", func(t *testing.T) {
+		body := `{"token":"app-token","user":"user-key","message":"Disk full","priority":2,"monospace":1}`
+		req := httptest.NewRequest(http.MethodPost, "/1/messages.json", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		got, err := ParsePushoverMessage(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, "app-token", got.Token)
+		assert.Equal(t, "Disk full", got.Data.Message)
+		assert.Equal(t, int32(5), got.Data.Priority)
+
+		var metadata map[string]any
+		require.NoError(t, json.Unmarshal(got.Data.Metadata, &metadata))
+		assert.Equal(t, "monospace", metadata["format"])
+	})
+
+	t.Run("query parameters", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/1/messages.json?token=app-token&message=hello", nil)
+
+		got, err := ParsePushoverMessage(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, "app-token", got.Token)
+		assert.Equal(t, "hello", got.Data.Message)
+		assert.Equal(t, int32(3), got.Data.Priority)
+	})
+
+	t.Run("token is redacted", func(t *testing.T) {
+		form := url.Values{"token": {"app-token"}, "message": {"hi"}}
+		req := httptest.NewRequest(http.MethodPost, "/1/messages.json", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		got, err := ParsePushoverMessage(req)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"token":"<redacted>","message":"hi"}`, string(got.Data.RawRequest))
+	})
+}
+
+func Test_ParsePushoverMessage_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		form  url.Values
+		param string
+	}{
+		{
+			name:  "missing token",
+			form:  url.Values{"message": {"hi"}},
+			param: "token",
+		},
+		{
+			name:  "blank message",
+			form:  url.Values{"token": {"t"}, "message": {"  "}},
+			param: "message",
+		},
+		{
+			name:  "priority out of range",
+			form:  url.Values{"token": {"t"}, "message": {"hi"}, "priority": {"3"}},
+			param: "priority",
+		},
+		{
+			name:  "priority not a number",
+			form:  url.Values{"token": {"t"}, "message": {"hi"}, "priority": {"high"}},
+			param: "priority",
+		},
+		{
+			name:  "html and monospace",
+			form:  url.Values{"token": {"t"}, "message": {"hi"}, "html": {"1"}, "monospace": {"1"}},
+			param: "html",
+		},
+		{
+			name:  "invalid timestamp",
+			form:  url.Values{"token": {"t"}, "message": {"hi"}, "timestamp": {"yesterday"}},
+			param: "timestamp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/1/messages.json", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			_, err := ParsePushoverMessage(req)
+
+			var perr *PushoverParamError
+			require.ErrorAs(t, err, &perr)
+			assert.Equal(t, tt.param, perr.Param)
+		})
+	}
+}
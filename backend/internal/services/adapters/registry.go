@@ -0,0 +1,244 @@
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hay-kot/hookfeed/backend/hookfeed"
+)
+
+const (
+	// MetadataKey is the metadata field recording the adapter applied to a message
+	MetadataKey = "adapter"
+	// GroupKeyMetadataKey is the metadata field grouping related notifications,
+	// see Resolver
+	GroupKeyMetadataKey = "groupKey"
+)
+
+// ErrUnknownAdapter is returned when an adapter reference does not match a
+// registered adapter
+var ErrUnknownAdapter = errors.New("unknown adapter")
+
+// ErrInvalidSignature is returned when a request fails adapter verification
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Adapter converts a webhook payload in a well known format into message fields
+type Adapter interface {
+	// Name is the adapter name used in feed configuration, e.g. "discord"
+	Name() string
+	// Version is the adapter version used in feed configuration, e.g. "v2"
+	Version() string
+	// Detect reports whether the payload is in the format handled by the adapter
+	Detect(payload map[string]any, headers map[string]string) bool
+	// Transform maps the payload to message fields. Zero values are left unset.
+	Transform(payload map[string]any, headers map[string]string) (*hookfeed.Payload, error)
+}
+
+// Verifier is implemented by adapters that authenticate requests with a shared
+// secret, e.g. by checking an HMAC signature header against the raw body
+type Verifier interface {
+	// Verify returns an error wrapping ErrInvalidSignature when the request
+	// was not signed with the secret
+	Verify(secret string, body []byte, headers map[string]string) error
+}
+
+// Resolver is implemented by adapters with a firing/resolved lifecycle. The
+// adapter records the group of a notification under GroupKeyMetadataKey, and a
+// resolving notification moves the open messages of its group to resolved.
+type Resolver interface {
+	// Resolved reports whether the payload resolves its group
+	Resolved(payload map[string]any, headers map[string]string) bool
+}
+
+// Ref returns the "name@version" reference of an adapter
+func Ref(a Adapter) string {
+	return a.Name() + "@" + a.Version()
+}
+
+// Registry resolves adapter references of the form "name@version". A reference
+// without a version resolves to the latest registered version of the adapter.
+type Registry struct {
+	adapters []Adapter          // registration order, used for auto-detection
+	byRef    map[string]Adapter // name@version => adapter
+	latest   map[string]Adapter // name => latest version
+}
+
+// NewRegistry creates a Registry with the given adapters registered in order
+func NewRegistry(adapters ...Adapter) *Registry {
+	r := &Registry{
+		byRef:  make(map[string]Adapter),
+		latest: make(map[string]Adapter),
+	}
+
+	for _, a := range adapters {
+		r.Register(a)
+	}
+
+	return r
+}
+
+// DefaultRegistry returns a Registry with all built-in adapters
+func DefaultRegistry() *Registry {
+	return NewRegistry(
+		GitHubAdapter{},
+		SentryAdapter{},
+		DiscordAdapter{},
+		SlackAdapter{},
+		GrafanaAdapter{},
+		AlertmanagerAdapter{},
+		NtfyAdapter{},
+	)
+}
+
+// Register adds an adapter, replacing any adapter with the same reference
+func (r *Registry) Register(a Adapter) {
+	ref := Ref(a)
+	if _, ok := r.byRef[ref]; ok {
+		r.adapters = slices.DeleteFunc(r.adapters, func(existing Adapter) bool { return Ref(existing) == ref })
+	}
+	r.adapters = append(r.adapters, a)
+	r.byRef[ref] = a
+
+	if current, ok := r.latest[a.Name()]; !ok || !versionLess(a.Version(), current.Version()) {
+		r.latest[a.Name()] = a
+	}
+}
+
+// Resolve returns the adapter for a "name@version" or "name" reference
+func (r *Registry) Resolve(ref string) (Adapter, error) {
+	name, version, hasVersion := strings.Cut(strings.TrimSpace(ref), "@")
+
+	var (
+		a  Adapter
+		ok bool
+	)
+	if hasVersion {
+		a, ok = r.byRef[name+"@"+version]
+	} else {
+		a, ok = r.latest[name]
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAdapter, ref)
+	}
+
+	return a, nil
+}
+
+// Select returns the first adapter that detects the payload. The adapters in
+// refs are tried in order, when refs is empty the latest version of every
+// registered adapter is tried in registration order.
+//
+// A nil adapter is returned when nothing matches. References that cannot be
+// resolved are skipped and reported in the returned error.
+func (r *Registry) Select(refs []string, payload map[string]any, headers map[string]string) (Adapter, error) {
+	if len(refs) == 0 {
+		for _, a := range r.adapters {
+			if Ref(r.latest[a.Name()]) == Ref(a) && a.Detect(payload, headers) {
+				return a, nil
+			}
+		}
+		return nil, nil
+	}
+
+	var errs []error
+	for _, ref := range refs {
+		a, err := r.Resolve(ref)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if a.Detect(payload, headers) {
+			return a, errors.Join(errs...)
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// Verify checks a request against every adapter with a configured secret, so a
+// feed with a secret only accepts requests signed with it. Secrets are keyed by
+// adapter reference, references that cannot be resolved or that do not
+// implement Verifier are rejected.
+func (r *Registry) Verify(secrets map[string]string, body []byte, headers map[string]string) error {
+	for _, ref := range slices.Sorted(maps.Keys(secrets)) {
+		a, err := r.Resolve(ref)
+		if err != nil {
+			return err
+		}
+
+		v, ok := a.(Verifier)
+		if !ok {
+			return fmt.Errorf("adapter %s does not support verification", Ref(a))
+		}
+
+		if err := v.Verify(secrets[ref], body, headers); err != nil {
+			return fmt.Errorf("adapter %s: %w", Ref(a), err)
+		}
+	}
+
+	return nil
+}
+
+// Apply transforms the payload with the adapter and writes the result into p.
+// Fields left empty by the adapter keep their current value, metadata is
+// merged and the adapter reference is recorded under MetadataKey.
+func Apply(a Adapter, p *hookfeed.Payload) error {
+	out, err := a.Transform(p.Raw, p.Headers)
+	if err != nil {
+		return err
+	}
+
+	if out.Title != "" {
+		p.Title = out.Title
+	}
+	if out.Message != "" {
+		p.Message = out.Message
+	}
+	if out.Priority != 0 {
+		p.Priority = out.Priority
+	}
+	p.Logs = append(p.Logs, out.Logs...)
+
+	if p.Metadata == nil {
+		p.Metadata = map[string]any{}
+	}
+	maps.Copy(p.Metadata, out.Metadata)
+	p.Metadata[MetadataKey] = Ref(a)
+
+	return nil
+}
+
+// versionLess reports whether version a sorts before b. Versions of the form
+// "v<number>" are compared numerically, others lexically.
+func versionLess(a, b string) bool {
+	an, aErr := strconv.Atoi(strings.TrimPrefix(a, "v"))
+	bn, bErr := strconv.Atoi(strings.TrimPrefix(b, "v"))
+	if aErr == nil && bErr == nil {
+		return an < bn
+	}
+	return a < b
+}
+
+// headerValue returns the value of a header from a map of first header values,
+// matching the key case-insensitively
+func headerValue(headers map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := headers[http.CanonicalHeaderKey(key)]; ok && v != "" {
+			return v
+		}
+
+		for k, v := range headers {
+			if v != "" && strings.EqualFold(k, key) {
+				return v
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/hay-kot/hookfeed/backend/hookfeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAdapter detects payloads holding its name under the "kind" key
+type fakeAdapter struct {
+	name    string
+	version string
+}
+
+func (f fakeAdapter) Name() string    { return f.name }
+func (f fakeAdapter) Version() string { return f.version }
+
+func (f fakeAdapter) Detect(payload map[string]any, _ map[string]string) bool {
+	return payload["kind"] == f.name
+}
+
+func (f fakeAdapter) Transform(_ map[string]any, _ map[string]string) (*hookfeed.Payload, error) {
+	return &hookfeed.Payload{
+		Title:    f.name + " " + f.version,
+		Metadata: map[string]any{"from": f.name},
+	}, nil
+}
+
+func Test_Registry_Resolve(t *testing.T) {
+	r := NewRegistry(
+		fakeAdapter{"alpha", "v2"},
+		fakeAdapter{"alpha", "v10"},
+		fakeAdapter{"alpha", "v1"},
+		fakeAdapter{"beta", "v1"},
+	)
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "alpha@v1", want: "alpha@v1"},
+		{ref: "alpha@v2", want: "alpha@v2"},
+		{ref: "alpha", want: "alpha@v10"},
+		{ref: " beta@v1 ", want: "beta@v1"},
+		{ref: "alpha@v3", wantErr: true},
+		{ref: "gamma", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			a, err := r.Resolve(tt.ref)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrUnknownAdapter)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, Ref(a))
+		})
+	}
+}
+
+func Test_Registry_Select(t *testing.T) {
+	r := NewRegistry(
+		fakeAdapter{"alpha", "v1"},
+		fakeAdapter{"alpha", "v2"},
+		fakeAdapter{"beta", "v1"},
+	)
+
+	t.Run("configured order", func(t *testing.T) {
+		a, err := r.Select([]string{"beta@v1", "alpha@v1"}, map[string]any{"kind": "alpha"}, nil)
+		require.NoError(t, err)
+		require.NotNil(t, a)
+		assert.Equal(t, "alpha@v1", Ref(a))
+	})
+
+	t.Run("auto-detect uses latest versions", func(t *testing.T) {
+		a, err := r.Select([]string{}, map[string]any{"kind": "alpha"}, nil)
+		require.NoError(t, err)
+		require.NotNil(t, a)
+		assert.Equal(t, "alpha@v2", Ref(a))
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		a, err := r.Select([]string{"beta@v1"}, map[string]any{"kind": "alpha"}, nil)
+		require.NoError(t, err)
+		assert.Nil(t, a)
+
+		a, err = r.Select(nil, map[string]any{"kind": "gamma"}, nil)
+		require.NoError(t, err)
+		assert.Nil(t, a)
+	})
+
+	t.Run("unknown references are reported and skipped", func(t *testing.T) {
+		a, err := r.Select([]string{"discord@v2", "beta"}, map[string]any{"kind": "beta"}, nil)
+		require.ErrorIs(t, err, ErrUnknownAdapter)
+		require.NotNil(t, a)
+		assert.Equal(t, "beta@v1", Ref(a))
+	})
+}
+
+func Test_Apply(t *testing.T) {
+	p := hookfeed.NewContext(map[string]any{"kind": "alpha"}, nil, nil).Payload
+	p.Message = "kept"
+	p.Priority = 4
+	p.Metadata["existing"] = true
+
+	err := Apply(fakeAdapter{"alpha", "v1"}, &p)
+	require.NoError(t, err)
+
+	assert.Equal(t, "alpha v1", p.Title)
+	assert.Equal(t, "kept", p.Message)
+	assert.Equal(t, int32(4), p.Priority)
+	assert.Equal(t, map[string]any{
+		"existing": true,
+		"from":     "alpha",
+		"adapter":  "alpha@v1",
+	}, p.Metadata)
+}
+
+func Test_NtfyAdapter(t *testing.T) {
+	a := NtfyAdapter{}
+
+	payload := map[string]any{
+		"topic":    "alerts",
+		"title":    "Body Title",
+		"message":  "Disk full",
+		"priority": float64(5),
+		"tags":     []any{"warning", "disk"},
+	}
+
+	assert.True(t, a.Detect(payload, nil))
+	assert.True(t, a.Detect(map[string]any{}, map[string]string{"X-Click": "https://example.com"}))
+	assert.False(t, a.Detect(map[string]any{}, map[string]string{"X-Priority": "high"}))
+	assert.False(t, a.Detect(map[string]any{"content": "hi"}, map[string]string{}))
+
+	out, err := a.Transform(payload, map[string]string{"X-Title": "Header Title"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Header Title", out.Title)
+	assert.Equal(t, "Disk full", out.Message)
+	assert.Equal(t, int32(5), out.Priority)
+	assert.Equal(t, []any{"warning", "disk"}, out.Metadata["tags"])
+	assert.Equal(t, "alerts", out.Metadata["topic"])
+}
@@ -0,0 +1,192 @@
+package adapters
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hay-kot/hookfeed/backend/hookfeed"
+)
+
+// sentryLevelPriorities maps Sentry event levels and metric alert actions to
+// priorities
+var sentryLevelPriorities = map[string]int32{
+	"fatal":    5,
+	"critical": 5,
+	"error":    5,
+	"warning":  4,
+	"info":     3,
+	"resolved": 2,
+	"debug":    1,
+}
+
+// SentryAdapter adapts Sentry integration platform webhooks, selected by the
+// Sentry-Hook-Resource header: issue, event_alert, error and metric_alert.
+// Resolved issues and metric alerts resolve the earlier messages of the issue
+// or alert.
+//
+//   - issue/event title → title
+//   - culprit, level, project and web URL → message
+//   - level, or the metric alert action → priority
+//   - Sentry-Hook-Resource, action → metadata.sentryResource, metadata.sentryAction
+//   - project, level, web URL → metadata.sentryProject, metadata.sentryLevel, metadata.sentryUrl
+type SentryAdapter struct{}
+
+var (
+	_ Adapter  = SentryAdapter{}
+	_ Verifier = SentryAdapter{}
+	_ Resolver = SentryAdapter{}
+)
+
+func (SentryAdapter) Name() string    { return "sentry" }
+func (SentryAdapter) Version() string { return "v1" }
+
+// Detect matches requests with a Sentry-Hook-Resource header
+func (SentryAdapter) Detect(_ map[string]any, headers map[string]string) bool {
+	return headerValue(headers, "Sentry-Hook-Resource") != ""
+}
+
+// Verify checks the Sentry-Hook-Signature header, a hex encoded HMAC-SHA256 of
+// the body keyed with the integration's client secret
+func (SentryAdapter) Verify(secret string, body []byte, headers map[string]string) error {
+	signature := headerValue(headers, "Sentry-Hook-Signature")
+	if signature == "" {
+		return fmt.Errorf("%w: missing Sentry-Hook-Signature header", ErrInvalidSignature)
+	}
+
+	if !validHexHMAC(secret, body, signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Resolved reports whether an issue or metric alert was resolved
+func (SentryAdapter) Resolved(payload map[string]any, headers map[string]string) bool {
+	switch headerValue(headers, "Sentry-Hook-Resource") {
+	case "issue", "metric_alert":
+		return stringAt(payload, "action") == "resolved"
+	}
+	return false
+}
+
+func (SentryAdapter) Transform(payload map[string]any, headers map[string]string) (*hookfeed.Payload, error) {
+	out := &hookfeed.Payload{Metadata: map[string]any{}}
+
+	resource := headerValue(headers, "Sentry-Hook-Resource")
+	action := stringAt(payload, "action")
+	data, _ := payload["data"].(map[string]any)
+
+	var (
+		title, culprit, level, project, url string
+		groupKey                            string
+	)
+
+	switch resource {
+	case "issue":
+		issue, _ := data["issue"].(map[string]any)
+		title = stringAt(issue, "title")
+		culprit = stringAt(issue, "culprit")
+		level = stringAt(issue, "level")
+		project = firstString(mapAt(issue, "project"), "slug", "name")
+		url = firstString(issue, "web_url", "permalink")
+		if id := idAt(issue, "id"); id != "" {
+			groupKey = "sentry:issue:" + id
+		}
+	case "event_alert", "error":
+		event, _ := data["event"].(map[string]any)
+		if resource == "error" {
+			event, _ = data["error"].(map[string]any)
+		}
+		title = stringAt(event, "title")
+		culprit = stringAt(event, "culprit")
+		level = stringAt(event, "level")
+		project = idAt(event, "project")
+		url = stringAt(event, "web_url")
+		if id := idAt(event, "issue_id"); id != "" {
+			groupKey = "sentry:issue:" + id
+		}
+		if rule := stringAt(data, "triggered_rule"); rule != "" {
+			out.Metadata["sentryRule"] = rule
+		}
+	case "metric_alert":
+		alert, _ := data["metric_alert"].(map[string]any)
+		title = stringAt(data, "description_title")
+		if title == "" {
+			title = stringAt(alert, "alert_rule", "name")
+		}
+		culprit = stringAt(data, "description_text")
+		level = action
+		project = strings.Join(stringSlice(mapAt(alert, "alert_rule")["projects"]), ", ")
+		url = stringAt(data, "web_url")
+		if id := idAt(alert, "id"); id != "" {
+			groupKey = "sentry:metric_alert:" + id
+		}
+	default:
+		title = strings.TrimSpace(resource + " " + action)
+	}
+
+	out.Title = title
+	if resource == "issue" && action != "created" && action != "" {
+		out.Title = fmt.Sprintf("Issue %s: %s", action, title)
+	}
+
+	lines := []string{culprit}
+	if level != "" {
+		lines = append(lines, "Level: "+level)
+	}
+	if project != "" {
+		lines = append(lines, "Project: "+project)
+	}
+	lines = append(lines, url)
+	out.Message = strings.Join(compact(lines), "\n")
+
+	out.Priority = sentryLevelPriorities[strings.ToLower(level)]
+	if resource == "issue" && action == "resolved" {
+		out.Priority = sentryLevelPriorities["resolved"]
+	}
+
+	for key, value := range map[string]string{
+		"sentryResource":    resource,
+		"sentryAction":      action,
+		"sentryProject":     project,
+		"sentryLevel":       level,
+		"sentryUrl":         url,
+		GroupKeyMetadataKey: groupKey,
+	} {
+		if value != "" {
+			out.Metadata[key] = value
+		}
+	}
+
+	return out, nil
+}
+
+// mapAt returns the object under key, or nil
+func mapAt(m map[string]any, key string) map[string]any {
+	v, _ := m[key].(map[string]any)
+	return v
+}
+
+// idAt returns the identifier under key, sent as a string or a JSON number
+// depending on the resource
+func idAt(m map[string]any, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return ""
+}
+
+// stringSlice returns the strings of a JSON array
+func stringSlice(v any) []string {
+	var out []string
+	for _, item := range asSlice(v) {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,123 @@
+package adapters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SentryAdapter_Transform(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		body     string
+		title    string
+		message  string
+		priority int32
+		groupKey string
+		resolved bool
+	}{
+		{
+			name:     "issue created",
+			resource: "issue",
+			body: `{"action": "created", "data": {"issue": {"id": "1170820242", "title": "TypeError: x is undefined",
+				"culprit": "app/components/login", "level": "error", "project": {"slug": "web", "name": "Web"},
+				"web_url": "https://sentry.io/organizations/acme/issues/1170820242/"}}}`,
+			title:    "TypeError: x is undefined",
+			message:  "app/components/login\nLevel: error\nProject: web\nhttps://sentry.io/organizations/acme/issues/1170820242/",
+			priority: 5,
+			groupKey: "sentry:issue:1170820242",
+		},
+		{
+			name:     "issue resolved",
+			resource: "issue",
+			body: `{"action": "resolved", "data": {"issue": {"id": "1170820242", "title": "TypeError: x is undefined",
+				"level": "error", "project": {"slug": "web"}}}}`,
+			title:    "Issue resolved: TypeError: x is undefined",
+			message:  "Level: error\nProject: web",
+			priority: 2,
+			groupKey: "sentry:issue:1170820242",
+			resolved: true,
+		},
+		{
+			name:     "event alert",
+			resource: "event_alert",
+			body: `{"action": "triggered", "data": {"triggered_rule": "Send a notification for new issues",
+				"event": {"title": "ZeroDivisionError", "culprit": "billing.tasks", "level": "warning", "project": 1,
+				"issue_id": "42", "web_url": "https://sentry.io/organizations/acme/issues/42/events/abc/"}}}`,
+			title:    "ZeroDivisionError",
+			message:  "billing.tasks\nLevel: warning\nProject: 1\nhttps://sentry.io/organizations/acme/issues/42/events/abc/",
+			priority: 4,
+			groupKey: "sentry:issue:42",
+		},
+		{
+			name:     "error",
+			resource: "error",
+			body:     `{"action": "created", "data": {"error": {"title": "Crash", "level": "fatal", "issue_id": "7"}}}`,
+			title:    "Crash",
+			message:  "Level: fatal",
+			priority: 5,
+			groupKey: "sentry:issue:7",
+		},
+		{
+			name:     "metric alert",
+			resource: "metric_alert",
+			body: `{"action": "critical", "data": {"description_title": "Error rate above 5%", "description_text": "1000 events in the last 10 minutes",
+				"web_url": "https://sentry.io/organizations/acme/alerts/rules/details/7/",
+				"metric_alert": {"id": "7", "alert_rule": {"name": "Error rate", "projects": ["web", "api"]}}}}`,
+			title:    "Error rate above 5%",
+			message:  "1000 events in the last 10 minutes\nLevel: critical\nProject: web, api\nhttps://sentry.io/organizations/acme/alerts/rules/details/7/",
+			priority: 5,
+			groupKey: "sentry:metric_alert:7",
+		},
+		{
+			name:     "metric alert resolved",
+			resource: "metric_alert",
+			body:     `{"action": "resolved", "data": {"metric_alert": {"id": "7", "alert_rule": {"name": "Error rate"}}}}`,
+			title:    "Error rate",
+			message:  "Level: resolved",
+			priority: 2,
+			groupKey: "sentry:metric_alert:7",
+			resolved: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload map[string]any
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &payload))
+
+			a := SentryAdapter{}
+			headers := map[string]string{"Sentry-Hook-Resource": tt.resource}
+			require.True(t, a.Detect(payload, headers))
+
+			out, err := a.Transform(payload, headers)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.title, out.Title)
+			assert.Equal(t, tt.message, out.Message)
+			assert.Equal(t, tt.priority, out.Priority)
+			assert.Equal(t, tt.groupKey, out.Metadata[GroupKeyMetadataKey])
+			assert.Equal(t, tt.resource, out.Metadata["sentryResource"])
+			assert.Equal(t, tt.resolved, a.Resolved(payload, headers))
+		})
+	}
+}
+
+func Test_SentryAdapter_Verify(t *testing.T) {
+	body := []byte(`{"action":"created"}`)
+	secret := "client-secret"
+	// hex(hmac_sha256("client-secret", body))
+	signature := "1c20166a5a6576f55b72d626aefc22c6e73a252e1f4cc8e87dc0ede1cdf4b5bc"
+
+	err := SentryAdapter{}.Verify(secret, body, map[string]string{"Sentry-Hook-Signature": signature})
+	require.NoError(t, err)
+
+	err = SentryAdapter{}.Verify("other-secret", body, map[string]string{"Sentry-Hook-Signature": signature})
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	err = SentryAdapter{}.Verify(secret, body, map[string]string{})
+	require.ErrorIs(t, err, ErrInvalidSignature)
+}
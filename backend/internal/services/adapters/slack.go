@@ -0,0 +1,371 @@
+package adapters
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hay-kot/hookfeed/backend/hookfeed"
+)
+
+// slackColorPriorities maps the named attachment colors to priorities. Hex
+// colors are mapped like Discord embed colors, see discordColorPriority.
+var slackColorPriorities = map[string]int32{
+	"danger":  5,
+	"warning": 4,
+	"good":    3,
+}
+
+// slackMessageKeys are the top level keys of a plain incoming-webhook message
+var slackMessageKeys = map[string]bool{
+	"text":         true,
+	"channel":      true,
+	"username":     true,
+	"icon_emoji":   true,
+	"icon_url":     true,
+	"mrkdwn":       true,
+	"link_names":   true,
+	"unfurl_links": true,
+	"unfurl_media": true,
+	"thread_ts":    true,
+}
+
+// slackLinkRe matches Slack mrkdwn links and mentions such as <https://x|label>
+var slackLinkRe = regexp.MustCompile(`<([^<>|]+)(?:\|([^<>]*))?>`)
+
+// SlackAdapter adapts Slack incoming-webhook payloads so tools that post to a
+// Slack webhook URL can target a feed unchanged.
+//
+//   - first header block, or attachments[0].title → title
+//   - text, section/context/rich_text blocks and attachments → message
+//   - attachments[].color → priority (highest wins)
+//   - blocks → metadata.slackBlocks
+//   - attachments[].fields → metadata.slackFields
+//   - username, channel → metadata.slackUsername, metadata.slackChannel
+type SlackAdapter struct{}
+
+var _ Adapter = SlackAdapter{}
+
+func (SlackAdapter) Name() string    { return "slack" }
+func (SlackAdapter) Version() string { return "v1" }
+
+// Detect matches payloads with a blocks or attachments array, or a plain text
+// message that only uses incoming-webhook keys
+func (SlackAdapter) Detect(payload map[string]any, _ map[string]string) bool {
+	if _, ok := payload["blocks"].([]any); ok {
+		return true
+	}
+
+	if _, ok := payload["attachments"].([]any); ok {
+		return true
+	}
+
+	if text, ok := payload["text"].(string); !ok || text == "" {
+		return false
+	}
+
+	for k := range payload {
+		if !slackMessageKeys[k] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (SlackAdapter) Transform(payload map[string]any, _ map[string]string) (*hookfeed.Payload, error) {
+	out := &hookfeed.Payload{Metadata: map[string]any{}}
+
+	var parts []string
+	if text, _ := payload["text"].(string); text != "" {
+		parts = append(parts, text)
+	}
+
+	if blocks, ok := payload["blocks"].([]any); ok {
+		title, lines := slackBlocksText(blocks)
+		out.Title = title
+		parts = append(parts, lines...)
+		out.Metadata["slackBlocks"] = blocks
+	}
+
+	fields := map[string]any{}
+	attachments, _ := payload["attachments"].([]any)
+	for _, item := range attachments {
+		att, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		title, _ := att["title"].(string)
+		if out.Title == "" {
+			out.Title = title
+			title = ""
+		}
+
+		if color, _ := att["color"].(string); color != "" {
+			out.Priority = max(out.Priority, slackColorPriority(color))
+		}
+
+		lines := slackAttachmentText(att, title)
+		parts = append(parts, lines...)
+
+		for _, f := range asSlice(att["fields"]) {
+			field, _ := f.(map[string]any)
+			name, _ := field["title"].(string)
+			if name == "" {
+				continue
+			}
+			fields[name] = field["value"]
+		}
+
+		if blocks, ok := att["blocks"].([]any); ok {
+			_, lines := slackBlocksText(blocks)
+			parts = append(parts, lines...)
+		}
+	}
+
+	if len(fields) > 0 {
+		out.Metadata["slackFields"] = fields
+	}
+
+	for i, s := range parts {
+		parts[i] = slackMrkdwn(strings.TrimSpace(s))
+	}
+	out.Message = strings.Join(compact(parts), "\n\n")
+	out.Title = slackMrkdwn(out.Title)
+
+	if username, _ := payload["username"].(string); username != "" {
+		out.Metadata["slackUsername"] = username
+	}
+	if channel, _ := payload["channel"].(string); channel != "" {
+		out.Metadata["slackChannel"] = channel
+	}
+
+	return out, nil
+}
+
+// slackAttachmentText renders a legacy attachment as message paragraphs. The
+// title is only included when it was not used as the message title.
+func slackAttachmentText(att map[string]any, title string) []string {
+	var parts []string
+
+	if pretext, _ := att["pretext"].(string); pretext != "" {
+		parts = append(parts, pretext)
+	}
+
+	if title != "" {
+		parts = append(parts, title)
+	}
+
+	if text, _ := att["text"].(string); text != "" {
+		parts = append(parts, text)
+	}
+
+	var fields []string
+	for _, f := range asSlice(att["fields"]) {
+		field, _ := f.(map[string]any)
+		name, _ := field["title"].(string)
+		value, _ := field["value"].(string)
+		switch {
+		case name != "" && value != "":
+			fields = append(fields, "*"+name+"*: "+value)
+		case value != "":
+			fields = append(fields, value)
+		}
+	}
+	if len(fields) > 0 {
+		parts = append(parts, strings.Join(fields, "\n"))
+	}
+
+	if footer, _ := att["footer"].(string); footer != "" {
+		parts = append(parts, footer)
+	}
+
+	if len(parts) == 0 {
+		if fallback, _ := att["fallback"].(string); fallback != "" {
+			parts = append(parts, fallback)
+		}
+	}
+
+	return parts
+}
+
+// slackBlocksText renders Block Kit blocks as message paragraphs. The first
+// header block is returned as the title.
+func slackBlocksText(blocks []any) (string, []string) {
+	var (
+		title string
+		parts []string
+	)
+
+	for _, item := range blocks {
+		block, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		switch block["type"] {
+		case "header":
+			text := slackTextObject(block["text"])
+			if title == "" {
+				title = text
+			} else if text != "" {
+				parts = append(parts, "*"+text+"*")
+			}
+		case "section":
+			if text := slackTextObject(block["text"]); text != "" {
+				parts = append(parts, text)
+			}
+
+			var fields []string
+			for _, f := range asSlice(block["fields"]) {
+				if text := slackTextObject(f); text != "" {
+					fields = append(fields, text)
+				}
+			}
+			if len(fields) > 0 {
+				parts = append(parts, strings.Join(fields, "\n"))
+			}
+		case "context":
+			var texts []string
+			for _, el := range asSlice(block["elements"]) {
+				if text := slackTextObject(el); text != "" {
+					texts = append(texts, text)
+				}
+			}
+			if len(texts) > 0 {
+				parts = append(parts, strings.Join(texts, " "))
+			}
+		case "rich_text":
+			if text := slackRichText(block["elements"]); text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+
+	return title, parts
+}
+
+// slackTextObject returns the text of a plain_text or mrkdwn text object
+func slackTextObject(v any) string {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	switch obj["type"] {
+	case "plain_text", "mrkdwn":
+		text, _ := obj["text"].(string)
+		return text
+	}
+
+	return ""
+}
+
+// slackRichText flattens rich_text elements into plain text
+func slackRichText(v any) string {
+	var sb strings.Builder
+
+	for _, item := range asSlice(v) {
+		el, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		switch el["type"] {
+		case "text":
+			text, _ := el["text"].(string)
+			sb.WriteString(text)
+		case "link":
+			text, _ := el["text"].(string)
+			if text == "" {
+				text, _ = el["url"].(string)
+			}
+			sb.WriteString(text)
+		case "emoji":
+			name, _ := el["name"].(string)
+			sb.WriteString(":" + name + ":")
+		case "user":
+			id, _ := el["user_id"].(string)
+			sb.WriteString("@" + id)
+		case "channel":
+			id, _ := el["channel_id"].(string)
+			sb.WriteString("#" + id)
+		case "rich_text_list":
+			for _, li := range asSlice(el["elements"]) {
+				if text := slackRichText([]any{li}); text != "" {
+					sb.WriteString("• " + text + "\n")
+				}
+			}
+		case "rich_text_preformatted":
+			sb.WriteString("```\n" + slackRichText(el["elements"]) + "\n```\n")
+		default:
+			// rich_text_section, rich_text_quote and unknown containers
+			sb.WriteString(slackRichText(el["elements"]) + "\n")
+		}
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+// slackMrkdwn converts Slack link syntax into markdown and unescapes the
+// control characters Slack requires to be escaped
+func slackMrkdwn(s string) string {
+	s = slackLinkRe.ReplaceAllStringFunc(s, func(match string) string {
+		groups := slackLinkRe.FindStringSubmatch(match)
+		target, label := groups[1], groups[2]
+
+		switch {
+		case strings.HasPrefix(target, "@"), strings.HasPrefix(target, "#"):
+			if label != "" {
+				return target[:1] + label
+			}
+			return target
+		case strings.HasPrefix(target, "!"):
+			return "@" + strings.TrimPrefix(target, "!")
+		case label != "":
+			return "[" + label + "](" + target + ")"
+		default:
+			return target
+		}
+	})
+
+	return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(s)
+}
+
+// slackColorPriority maps an attachment color to a priority. Zero means the
+// color was not set or could not be parsed.
+func slackColorPriority(color string) int32 {
+	if p, ok := slackColorPriorities[color]; ok {
+		return p
+	}
+
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	n, err := strconv.ParseInt(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return 0
+	}
+
+	return discordColorPriority(int(n))
+}
+
+// asSlice returns v as a slice, or nil when it is not one
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+// compact returns parts without empty strings
+func compact(parts []string) []string {
+	out := parts[:0]
+	for _, s := range parts {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,155 @@
+package adapters
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SlackAdapter_Detect(t *testing.T) {
+	a := SlackAdapter{}
+
+	tests := []struct {
+		name    string
+		payload map[string]any
+		want    bool
+	}{
+		{"text", map[string]any{"text": "hello", "username": "bot", "icon_emoji": ":ghost:"}, true},
+		{"blocks", map[string]any{"blocks": []any{}}, true},
+		{"attachments", map[string]any{"attachments": []any{}}, true},
+		{"text with unknown keys", map[string]any{"text": "hello", "status": "ok"}, false},
+		{"empty text", map[string]any{"text": ""}, false},
+		{"discord", map[string]any{"content": "hello"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, a.Detect(tt.payload, nil))
+		})
+	}
+}
+
+func Test_SlackAdapter_Attachments(t *testing.T) {
+	body := `{
+  "text": "Run <https://app.terraform.io/runs/1|run-1> needs attention",
+  "username": "Terraform Cloud",
+  "channel": "#infra",
+  "attachments": [
+    {
+      "color": "warning",
+      "title": "Plan errored",
+      "text": "Error: invalid provider configuration",
+      "fields": [
+        { "title": "Workspace", "value": "prod", "short": true },
+        { "title": "Status", "value": "errored", "short": true }
+      ],
+      "footer": "Terraform Cloud"
+    },
+    {
+      "color": "#d50200",
+      "fallback": "Second attachment"
+    }
+  ]
+}`
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &payload))
+
+	out, err := SlackAdapter{}.Transform(payload, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Plan errored", out.Title)
+	assert.Equal(t, "Run [run-1](https://app.terraform.io/runs/1) needs attention\n\n"+
+		"Error: invalid provider configuration\n\n"+
+		"*Workspace*: prod\n*Status*: errored\n\n"+
+		"Terraform Cloud\n\n"+
+		"Second attachment", out.Message)
+	assert.Equal(t, int32(5), out.Priority)
+	assert.Equal(t, map[string]any{
+		"slackUsername": "Terraform Cloud",
+		"slackChannel":  "#infra",
+		"slackFields": map[string]any{
+			"Workspace": "prod",
+			"Status":    "errored",
+		},
+	}, out.Metadata)
+}
+
+func Test_SlackAdapter_Blocks(t *testing.T) {
+	body := `{
+  "text": "fallback text",
+  "blocks": [
+    { "type": "header", "text": { "type": "plain_text", "text": "New issue in api" } },
+    { "type": "section", "text": { "type": "mrkdwn", "text": "*TypeError*: x is undefined &amp; more" } },
+    { "type": "section", "fields": [
+      { "type": "mrkdwn", "text": "*Env:* production" },
+      { "type": "mrkdwn", "text": "*Level:* error" }
+    ] },
+    { "type": "divider" },
+    { "type": "context", "elements": [
+      { "type": "mrkdwn", "text": "Seen 3 times" },
+      { "type": "image", "image_url": "https://example.com/i.png", "alt_text": "icon" }
+    ] },
+    { "type": "rich_text", "elements": [
+      { "type": "rich_text_section", "elements": [
+        { "type": "text", "text": "See " },
+        { "type": "link", "url": "https://sentry.io/issues/1", "text": "issue" }
+      ] }
+    ] }
+  ]
+}`
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &payload))
+
+	out, err := SlackAdapter{}.Transform(payload, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "New issue in api", out.Title)
+	assert.Equal(t, "fallback text\n\n"+
+		"*TypeError*: x is undefined & more\n\n"+
+		"*Env:* production\n*Level:* error\n\n"+
+		"Seen 3 times\n\n"+
+		"See issue", out.Message)
+	assert.Equal(t, int32(0), out.Priority)
+	assert.Equal(t, payload["blocks"], out.Metadata["slackBlocks"])
+}
+
+func Test_slackMrkdwn(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"<https://example.com>", "https://example.com"},
+		{"<https://example.com|Example>", "[Example](https://example.com)"},
+		{"<@U123|alice> and <#C123|general>", "@alice and #general"},
+		{"<!channel> deploy", "@channel deploy"},
+		{"a &lt; b &amp;&amp; c &gt; d", "a < b && c > d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, slackMrkdwn(tt.in))
+		})
+	}
+}
+
+func Test_slackColorPriority(t *testing.T) {
+	tests := map[string]int32{
+		"danger":  5,
+		"warning": 4,
+		"good":    3,
+		"#ff0000": 5,
+		"f90":     4,
+		"#2eb886": 3,
+		"":        0,
+		"blue":    0,
+	}
+
+	for color, want := range tests {
+		assert.Equal(t, want, slackColorPriority(color), "color %q", color)
+	}
+}
@@ -0,0 +1,337 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+)
+
+// ErrInvalidSyslog is returned for syslog messages without a valid priority
+var ErrInvalidSyslog = errors.New("invalid syslog message")
+
+// SyslogFacilities are the facility names indexed by their code
+var SyslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// SyslogSeverities are the severity names indexed by their code
+var SyslogSeverities = []string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+// syslogSeverityPriorities maps severity codes to priorities
+var syslogSeverityPriorities = []int32{5, 5, 5, 4, 3, 2, 2, 1}
+
+// SyslogMessage is a message in the RFC 5424 or BSD (RFC 3164) syslog format.
+// Fields that are not sent are empty.
+type SyslogMessage struct {
+	Format         string                       `json:"format"` // rfc5424 or rfc3164
+	Facility       int                          `json:"facility"`
+	Severity       int                          `json:"severity"`
+	Timestamp      time.Time                    `json:"timestamp"`
+	Hostname       string                       `json:"hostname,omitempty"`
+	AppName        string                       `json:"appName,omitempty"`
+	ProcID         string                       `json:"procId,omitempty"`
+	MsgID          string                       `json:"msgId,omitempty"`
+	StructuredData map[string]map[string]string `json:"structuredData,omitempty"`
+	Message        string                       `json:"message"`
+}
+
+// FacilityName returns the name of the facility, e.g. local7
+func (m SyslogMessage) FacilityName() string {
+	return SyslogFacilities[m.Facility]
+}
+
+// SeverityName returns the name of the severity, e.g. warning
+func (m SyslogMessage) SeverityName() string {
+	return SyslogSeverities[m.Severity]
+}
+
+// sanitized returns a copy of the message with its text fields made valid
+// UTF-8 without NUL bytes
+func (m SyslogMessage) sanitized() SyslogMessage {
+	m.Hostname = syslogText(m.Hostname)
+	m.AppName = syslogText(m.AppName)
+	m.ProcID = syslogText(m.ProcID)
+	m.MsgID = syslogText(m.MsgID)
+	m.Message = syslogText(m.Message)
+
+	if len(m.StructuredData) > 0 {
+		elements := make(map[string]map[string]string, len(m.StructuredData))
+		for id, params := range m.StructuredData {
+			clean := make(map[string]string, len(params))
+			for name, value := range params {
+				clean[syslogText(name)] = syslogText(value)
+			}
+			elements[syslogText(id)] = clean
+		}
+		m.StructuredData = elements
+	}
+
+	return m
+}
+
+// syslogText replaces invalid UTF-8 with U+FFFD and removes NUL bytes
+func syslogText(s string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(s, "\ufffd"), "\x00", "")
+}
+
+// ParseSyslog parses a syslog message. Messages with a version after the
+// priority are parsed as RFC 5424, others as BSD syslog. BSD timestamps have
+// no year and are assumed to be in the past year relative to now; messages
+// without a timestamp are stamped with now.
+func ParseSyslog(data []byte, now time.Time) (SyslogMessage, error) {
+	s := strings.TrimRight(string(bytes.TrimPrefix(data, []byte("\ufeff"))), "\r\n\x00")
+
+	pri, rest, err := syslogPriority(s)
+	if err != nil {
+		return SyslogMessage{}, err
+	}
+
+	msg := SyslogMessage{
+		Facility:  pri / 8,
+		Severity:  pri % 8,
+		Timestamp: now,
+	}
+
+	if v, after, ok := strings.Cut(rest, " "); ok && v == "1" {
+		msg.Format = "rfc5424"
+		parseRFC5424(&msg, after)
+	} else {
+		msg.Format = "rfc3164"
+		parseRFC3164(&msg, rest, now)
+	}
+
+	return msg, nil
+}
+
+// syslogPriority parses the "<PRI>" prefix of a message
+func syslogPriority(s string) (int, string, error) {
+	if !strings.HasPrefix(s, "<") {
+		return 0, "", fmt.Errorf("%w: missing priority", ErrInvalidSyslog)
+	}
+
+	end := strings.IndexByte(s, '>')
+	if end < 2 || end > 4 {
+		return 0, "", fmt.Errorf("%w: malformed priority", ErrInvalidSyslog)
+	}
+
+	pri, err := strconv.Atoi(s[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, "", fmt.Errorf("%w: malformed priority", ErrInvalidSyslog)
+	}
+
+	return pri, s[end+1:], nil
+}
+
+// parseRFC5424 parses the header after the version:
+// TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func parseRFC5424(msg *SyslogMessage, s string) {
+	fields := make([]string, 5)
+	for i := range fields {
+		fields[i], s, _ = strings.Cut(s, " ")
+		if fields[i] == "-" {
+			fields[i] = ""
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, fields[0]); err == nil {
+		msg.Timestamp = t
+	}
+
+	msg.Hostname = fields[1]
+	msg.AppName = fields[2]
+	msg.ProcID = fields[3]
+	msg.MsgID = fields[4]
+
+	if strings.HasPrefix(s, "-") {
+		s = s[1:]
+	} else {
+		msg.StructuredData, s = parseStructuredData(s)
+	}
+
+	msg.Message = strings.TrimPrefix(strings.TrimPrefix(s, " "), "\ufeff")
+}
+
+// parseStructuredData parses "[id name="value" ...]" elements, returning the
+// elements and the remainder of the message
+func parseStructuredData(s string) (map[string]map[string]string, string) {
+	elements := map[string]map[string]string{}
+
+	for strings.HasPrefix(s, "[") {
+		s = s[1:]
+
+		var id string
+		end := strings.IndexAny(s, " ]")
+		if end < 0 {
+			return elements, ""
+		}
+		id, s = s[:end], s[end:]
+
+		params := map[string]string{}
+		for strings.HasPrefix(s, " ") {
+			s = strings.TrimLeft(s, " ")
+
+			name, after, ok := strings.Cut(s, `="`)
+			if !ok {
+				return elements, ""
+			}
+
+			// Values escape '"', '\' and ']' with a backslash
+			var value strings.Builder
+			i := 0
+			for ; i < len(after) && after[i] != '"'; i++ {
+				if after[i] == '\\' && i+1 < len(after) && strings.IndexByte(`"\]`, after[i+1]) >= 0 {
+					i++
+				}
+				value.WriteByte(after[i])
+			}
+			if i >= len(after) {
+				return elements, ""
+			}
+
+			params[name] = value.String()
+			s = after[i+1:]
+		}
+
+		elements[id] = params
+		s = strings.TrimPrefix(s, "]")
+	}
+
+	return elements, s
+}
+
+// parseRFC3164 parses a BSD syslog message: TIMESTAMP HOSTNAME TAG[PID]: MSG.
+// Senders commonly leave out the timestamp or hostname, or use an RFC 3339
+// timestamp.
+func parseRFC3164(msg *SyslogMessage, s string, now time.Time) {
+	stamped := false
+
+	// "Jan  2 15:04:05", the day is space padded
+	if len(s) >= 16 && s[15] == ' ' {
+		if t, err := time.ParseInLocation(time.Stamp, s[:15], now.Location()); err == nil {
+			t = t.AddDate(now.Year(), 0, 0)
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			msg.Timestamp = t
+			s = s[16:]
+			stamped = true
+		}
+	} else if ts, after, ok := strings.Cut(s, " "); ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			msg.Timestamp = t
+			s = after
+			stamped = true
+		}
+	}
+
+	// The hostname follows the timestamp, unless the first word is the tag
+	if word, after, ok := strings.Cut(s, " "); ok && stamped && !strings.HasSuffix(word, ":") && !strings.Contains(word, "[") {
+		msg.Hostname = word
+		s = after
+	}
+
+	// TAG is alphanumeric, followed by an optional [PID] and a colon
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_./", r)
+	})
+	if end > 0 && end <= 48 {
+		tag, rest := s[:end], s[end:]
+
+		if strings.HasPrefix(rest, "[") {
+			if pid, after, ok := strings.Cut(rest[1:], "]"); ok {
+				msg.ProcID = pid
+				rest = after
+			}
+		}
+
+		if strings.HasPrefix(rest, ":") {
+			msg.AppName = tag
+			s = strings.TrimPrefix(rest[1:], " ")
+		}
+	}
+
+	msg.Message = s
+}
+
+// SyslogPriority maps a syslog severity to a priority: emerg, alert and crit
+// are urgent, err high, warning normal, notice and info low and debug min
+func SyslogPriority(severity int) int32 {
+	if severity < 0 || severity >= len(syslogSeverityPriorities) {
+		return 3
+	}
+	return syslogSeverityPriorities[severity]
+}
+
+// NewSyslogFeedMessage creates a message for the feed from a syslog message:
+//
+//   - hostname and app name → title
+//   - message → message
+//   - severity → priority
+//   - timestamp → receivedAt
+//   - facility, severity, hostname, app name, proc id, msg id, structured data
+//     → metadata.syslogFacility, metadata.syslogSeverity, ...
+//
+// The raw request holds the parsed message and the sender address. Invalid
+// UTF-8 is replaced and NUL bytes are removed from every field, Postgres does
+// not store either in text or JSON values.
+func NewSyslogFeedMessage(msg SyslogMessage, feedID, remoteAddr string) (dtos.FeedMessageCreate, error) {
+	msg = msg.sanitized()
+
+	data := dtos.FeedMessageCreateNew()
+	data.FeedID = feedID
+	data.ReceivedAt = msg.Timestamp
+	data.Priority = SyslogPriority(msg.Severity)
+
+	title := strings.Join(compact([]string{msg.Hostname, msg.AppName}), " ")
+	if title != "" {
+		data.Title = title
+	}
+	if msg.Message != "" {
+		data.Message = msg.Message
+	}
+
+	metadata := map[string]any{
+		"syslogFacility": msg.FacilityName(),
+		"syslogSeverity": msg.SeverityName(),
+		"syslogFormat":   msg.Format,
+	}
+	for key, value := range map[string]string{
+		"syslogHostname": msg.Hostname,
+		"syslogAppName":  msg.AppName,
+		"syslogProcId":   msg.ProcID,
+		"syslogMsgId":    msg.MsgID,
+	} {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	if len(msg.StructuredData) > 0 {
+		metadata["syslogStructuredData"] = msg.StructuredData
+	}
+
+	raw := struct {
+		SyslogMessage
+		RemoteAddr string `json:"remoteAddr,omitempty"`
+	}{msg, remoteAddr}
+
+	var err error
+	if data.RawRequest, err = json.Marshal(raw); err != nil {
+		return dtos.FeedMessageCreate{}, err
+	}
+	if data.Metadata, err = json.Marshal(metadata); err != nil {
+		return dtos.FeedMessageCreate{}, err
+	}
+
+	return data, nil
+}
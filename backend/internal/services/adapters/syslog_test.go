@@ -0,0 +1,185 @@
+package adapters
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var syslogNow = time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+func Test_ParseSyslog_RFC5424(t *testing.T) {
+	raw := `<165>1 2024-03-10T11:59:58.003Z switch-01 sshd 1234 ID47 [exampleSDID@32473 iut="3" eventSource="App\"lication"][meta sequenceId="1"] ` + "\ufeff" + "login failed\n"
+
+	got, err := ParseSyslog([]byte(raw), syslogNow)
+	require.NoError(t, err)
+
+	assert.Equal(t, "rfc5424", got.Format)
+	assert.Equal(t, "local4", got.FacilityName())
+	assert.Equal(t, "notice", got.SeverityName())
+	assert.Equal(t, time.Date(2024, time.March, 10, 11, 59, 58, 3_000_000, time.UTC), got.Timestamp)
+	assert.Equal(t, "switch-01", got.Hostname)
+	assert.Equal(t, "sshd", got.AppName)
+	assert.Equal(t, "1234", got.ProcID)
+	assert.Equal(t, "ID47", got.MsgID)
+	assert.Equal(t, map[string]map[string]string{
+		"exampleSDID@32473": {"iut": "3", "eventSource": `App"lication`},
+		"meta":              {"sequenceId": "1"},
+	}, got.StructuredData)
+	assert.Equal(t, "login failed", got.Message)
+}
+
+func Test_ParseSyslog_RFC5424_NilValues(t *testing.T) {
+	got, err := ParseSyslog([]byte("<14>1 - - - - - - started"), syslogNow)
+	require.NoError(t, err)
+
+	assert.Equal(t, syslogNow, got.Timestamp)
+	assert.Empty(t, got.Hostname)
+	assert.Empty(t, got.AppName)
+	assert.Nil(t, got.StructuredData)
+	assert.Equal(t, "started", got.Message)
+}
+
+func Test_ParseSyslog_RFC3164(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		time     time.Time
+		hostname string
+		appName  string
+		procID   string
+		message  string
+	}{
+		{
+			name:     "hostname and tag with pid",
+			raw:      "<34>Mar  9 22:14:15 mymachine su[230]: 'su root' failed",
+			time:     time.Date(2024, time.March, 9, 22, 14, 15, 0, time.UTC),
+			hostname: "mymachine",
+			appName:  "su",
+			procID:   "230",
+			message:  "'su root' failed",
+		},
+		{
+			name:    "no hostname",
+			raw:     "<34>Mar 10 11:00:00 cron: job done",
+			time:    time.Date(2024, time.March, 10, 11, 0, 0, 0, time.UTC),
+			appName: "cron",
+			message: "job done",
+		},
+		{
+			name:    "no timestamp",
+			raw:     "<34>kernel: eth0 link down",
+			time:    syslogNow,
+			appName: "kernel",
+			message: "eth0 link down",
+		},
+		{
+			name:     "rfc3339 timestamp",
+			raw:      "<34>2024-03-10T10:00:00Z router dnsmasq[5]: query",
+			time:     time.Date(2024, time.March, 10, 10, 0, 0, 0, time.UTC),
+			hostname: "router",
+			appName:  "dnsmasq",
+			procID:   "5",
+			message:  "query",
+		},
+		{
+			name:    "no tag",
+			raw:     "<34>just a message",
+			time:    syslogNow,
+			message: "just a message",
+		},
+		{
+			name:     "timestamp from last year",
+			raw:      "<34>Dec 31 23:59:59 host app: new year",
+			time:     time.Date(2023, time.December, 31, 23, 59, 59, 0, time.UTC),
+			hostname: "host",
+			appName:  "app",
+			message:  "new year",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSyslog([]byte(tt.raw), syslogNow)
+			require.NoError(t, err)
+
+			assert.Equal(t, "rfc3164", got.Format)
+			assert.Equal(t, "auth", got.FacilityName())
+			assert.Equal(t, "crit", got.SeverityName())
+			assert.Equal(t, tt.time, got.Timestamp)
+			assert.Equal(t, tt.hostname, got.Hostname)
+			assert.Equal(t, tt.appName, got.AppName)
+			assert.Equal(t, tt.procID, got.ProcID)
+			assert.Equal(t, tt.message, got.Message)
+		})
+	}
+}
+
+func Test_ParseSyslog_InvalidPriority(t *testing.T) {
+	for _, raw := range []string{"", "no priority", "<>1 -", "<192>msg", "<abc>msg", "<13"} {
+		_, err := ParseSyslog([]byte(raw), syslogNow)
+		require.ErrorIs(t, err, ErrInvalidSyslog, raw)
+	}
+}
+
+func Test_SyslogPriority(t *testing.T) {
+	want := []int32{5, 5, 5, 4, 3, 2, 2, 1}
+	for severity, priority := range want {
+		assert.Equal(t, priority, SyslogPriority(severity), SyslogSeverities[severity])
+	}
+	assert.Equal(t, int32(3), SyslogPriority(8))
+}
+
+func Test_NewSyslogFeedMessage(t *testing.T) {
+	msg, err := ParseSyslog([]byte(`<187>1 2024-03-10T11:59:58Z switch-01 ifmgr - LINK [port id="7"] port 7 down`), syslogNow)
+	require.NoError(t, err)
+
+	got, err := NewSyslogFeedMessage(msg, "network", "10.0.0.2:51000")
+	require.NoError(t, err)
+
+	assert.Equal(t, "network", got.FeedID)
+	assert.Equal(t, "switch-01 ifmgr", got.Title)
+	assert.Equal(t, "port 7 down", got.Message)
+	assert.Equal(t, int32(4), got.Priority)
+	assert.Equal(t, msg.Timestamp, got.ReceivedAt)
+
+	var metadata map[string]any
+	require.NoError(t, json.Unmarshal(got.Metadata, &metadata))
+	assert.Equal(t, "local7", metadata["syslogFacility"])
+	assert.Equal(t, "err", metadata["syslogSeverity"])
+	assert.Equal(t, "rfc5424", metadata["syslogFormat"])
+	assert.Equal(t, "switch-01", metadata["syslogHostname"])
+	assert.Equal(t, "ifmgr", metadata["syslogAppName"])
+	assert.Equal(t, "LINK", metadata["syslogMsgId"])
+	assert.NotContains(t, metadata, "syslogProcId")
+	assert.Equal(t, map[string]any{"port": map[string]any{"id": "7"}}, metadata["syslogStructuredData"])
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(got.RawRequest, &raw))
+	assert.Equal(t, "10.0.0.2:51000", raw["remoteAddr"])
+}
+
+func Test_NewSyslogFeedMessage_InvalidText(t *testing.T) {
+	msg, err := ParseSyslog([]byte("<14>1 2024-03-10T11:59:58Z host\x00-01 app\xff - - [meta key=\"v\x00al\xfe\"] disk\x00 \xc3\x28full"), syslogNow)
+	require.NoError(t, err)
+
+	got, err := NewSyslogFeedMessage(msg, "servers", "10.0.0.2:51000")
+	require.NoError(t, err)
+
+	assert.Equal(t, "host-01 app\ufffd", got.Title)
+	assert.Equal(t, "disk \ufffd(full", got.Message)
+
+	for _, b := range [][]byte{got.Metadata, got.RawRequest} {
+		assert.True(t, utf8.Valid(b))
+		assert.NotContains(t, string(b), `\u0000`)
+	}
+
+	var metadata map[string]any
+	require.NoError(t, json.Unmarshal(got.Metadata, &metadata))
+	assert.Equal(t, "host-01", metadata["syslogHostname"])
+	assert.Equal(t, map[string]any{"meta": map[string]any{"key": "val\ufffd"}}, metadata["syslogStructuredData"])
+}
@@ -2,6 +2,9 @@ package adapters
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -99,7 +102,7 @@ func sanitizeSecrets(key, value string) string {
 	case "cookie", "set-cookie":
 		return redactedValue
 
-	case "x-api-key", "x-auth-token", "api-key", "apikey":
+	case "x-api-key", "x-auth-token", "api-key", "apikey", "x-gotify-key":
 		return redactedValue
 	}
 
@@ -187,6 +190,11 @@ func copyBody(r *http.Request) ([]byte, error) {
 
 	r.Body = io.NopCloser(bytes.NewReader(body))
 
+	return wrapBody(body)
+}
+
+// wrapBody returns a body as JSON, see [copyBody]
+func wrapBody(body []byte) ([]byte, error) {
 	// If body is completely empty, return empty JSON object
 	trimmed := bytes.TrimSpace(body)
 	if len(trimmed) == 0 {
@@ -207,3 +215,34 @@ func copyBody(r *http.Request) ([]byte, error) {
 
 	return json.Marshal(wrapped)
 }
+
+// DecodeBody decodes a payload that did not arrive over HTTP, such as an MQTT
+// message, into the body seen by middleware and adapters and the raw request
+// stored with the message. JSON objects are decoded, other payloads are
+// wrapped in {"$body": "content"} as done by [copyBody] for text.
+func DecodeBody(payload []byte) (map[string]any, []byte, error) {
+	raw, err := wrapBody(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body := map[string]any{}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		body = map[string]any{"$body": string(payload)}
+	}
+
+	return body, raw, nil
+}
+
+// validHexHMAC reports whether signature is the hex encoded HMAC-SHA256 of body
+// keyed with secret
+func validHexHMAC(secret string, body []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
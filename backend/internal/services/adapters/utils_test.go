@@ -319,3 +319,47 @@ func Test_copyAndTransformValues(t *testing.T) {
 		})
 	}
 }
+
+func Test_DecodeBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		wantBody map[string]any
+		wantRaw  string
+	}{
+		{
+			name:     "json object",
+			payload:  `{"title":"Door open","level":2}`,
+			wantBody: map[string]any{"title": "Door open", "level": float64(2)},
+			wantRaw:  `{"title":"Door open","level":2}`,
+		},
+		{
+			name:     "plain text",
+			payload:  "motion detected",
+			wantBody: map[string]any{"$body": "motion detected"},
+			wantRaw:  `{"$body":"motion detected"}`,
+		},
+		{
+			name:     "json scalar",
+			payload:  "21.5",
+			wantBody: map[string]any{"$body": "21.5"},
+			wantRaw:  "21.5",
+		},
+		{
+			name:     "empty",
+			payload:  "",
+			wantBody: map[string]any{},
+			wantRaw:  "{}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, raw, err := DecodeBody([]byte(tt.payload))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantBody, body)
+			assert.JSONEq(t, tt.wantRaw, string(raw))
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hay-kot/hookfeed/backend/internal/core/blobstore"
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/data/db"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+)
+
+var (
+	// ErrAttachmentTooLarge is returned for files above the size limit of a feed
+	ErrAttachmentTooLarge = errors.New("attachment too large")
+	// ErrAttachmentQuota is returned when a file would exceed the total
+	// attachment quota of a feed
+	ErrAttachmentQuota = errors.New("attachment quota exceeded")
+)
+
+// unlinkedBatchSize is the number of unlinked attachments removed per query
+const unlinkedBatchSize = 100
+
+// maxAttachmentName is the length of the attachments.name column
+const maxAttachmentName = 255
+
+// AttachmentService stores the files of feed messages. The content is kept in
+// the blob store, the database holds the metadata and links it to a message.
+type AttachmentService struct {
+	l      zerolog.Logger
+	db     *db.QueriesExt
+	store  blobstore.Store
+	feeds  *FeedService
+	mapper dtos.MapFunc[db.Attachment, dtos.Attachment]
+}
+
+func NewAttachmentService(l zerolog.Logger, db *db.QueriesExt, store blobstore.Store, feeds *FeedService) *AttachmentService {
+	return &AttachmentService{
+		l:      l.With().Str("service", "attachments").Logger(),
+		db:     db,
+		store:  store,
+		feeds:  feeds,
+		mapper: dtos.MapAttachment,
+	}
+}
+
+// Check returns ErrAttachmentTooLarge or ErrAttachmentQuota when files of the
+// given sizes cannot be stored for the feed. Callers check before saving a
+// message so a rejected upload does not leave a message behind.
+func (s *AttachmentService) Check(ctx context.Context, feedID string, sizes ...int64) error {
+	limits := s.limits(feedID)
+
+	var total int64
+	for _, size := range sizes {
+		if size > limits.MaxSize {
+			return fmt.Errorf("%w: %d bytes, the limit is %d bytes", ErrAttachmentTooLarge, size, limits.MaxSize)
+		}
+		total += size
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	usage, err := s.db.AttachmentFeedUsage(ctx, feedID)
+	if err != nil {
+		return err
+	}
+
+	if usage+total > limits.Quota {
+		return fmt.Errorf("%w: %d of %d bytes used", ErrAttachmentQuota, usage, limits.Quota)
+	}
+
+	return nil
+}
+
+// Create stores a file of a message
+func (s *AttachmentService) Create(ctx context.Context, feedID string, messageID uuid.UUID, upload dtos.AttachmentUpload) (dtos.Attachment, error) {
+	size := int64(len(upload.Data))
+	if err := s.Check(ctx, feedID, size); err != nil {
+		return dtos.Attachment{}, err
+	}
+
+	key := feedID + "/" + uuid.NewString()
+
+	_, err := s.store.Put(ctx, key, bytes.NewReader(upload.Data))
+	if err != nil {
+		return dtos.Attachment{}, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	row, err := s.db.AttachmentCreate(ctx, db.AttachmentCreateParams{
+		FeedMessageID: pgtype.UUID{Bytes: messageID, Valid: true},
+		FeedSlug:      feedID,
+		Name:          attachmentName(upload.Name),
+		ContentType:   cmp.Or(upload.ContentType, http.DetectContentType(upload.Data)),
+		Size:          size,
+		StorageKey:    key,
+	})
+	if err != nil {
+		if err := s.store.Delete(ctx, key); err != nil {
+			s.l.Warn().Err(err).Str("key", key).Msg("failed to delete blob of unsaved attachment")
+		}
+		return dtos.Attachment{}, err
+	}
+
+	return s.mapper(row), nil
+}
+
+// Get returns an attachment of a message that has not been deleted
+func (s *AttachmentService) Get(ctx context.Context, id uuid.UUID) (dtos.Attachment, error) {
+	return s.mapper.Err(s.db.AttachmentByID(ctx, id))
+}
+
+// GetByMessage returns the attachments of a message
+func (s *AttachmentService) GetByMessage(ctx context.Context, messageID uuid.UUID) ([]dtos.Attachment, error) {
+	rows, err := s.db.AttachmentsByFeedMessageID(ctx, pgtype.UUID{Bytes: messageID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.mapper.Slice(rows), nil
+}
+
+// Open returns the content of an attachment, the caller closes it
+func (s *AttachmentService) Open(ctx context.Context, a dtos.Attachment) (io.ReadCloser, error) {
+	return s.store.Open(ctx, a.StorageKey)
+}
+
+// DeleteUnlinked removes the attachments of deleted messages, whichever way
+// they were deleted, and returns the number removed
+func (s *AttachmentService) DeleteUnlinked(ctx context.Context) (int64, error) {
+	var deleted int64
+
+	for {
+		rows, err := s.db.AttachmentsUnlinked(ctx, unlinkedBatchSize)
+		if err != nil {
+			return deleted, err
+		}
+
+		for _, row := range rows {
+			// The blob goes first, a row without a blob is retried on the next run
+			if err := s.store.Delete(ctx, row.StorageKey); err != nil {
+				return deleted, fmt.Errorf("failed to delete blob %s: %w", row.StorageKey, err)
+			}
+
+			if err := s.db.AttachmentDeleteByID(ctx, row.ID); err != nil {
+				return deleted, err
+			}
+
+			deleted++
+		}
+
+		if len(rows) < unlinkedBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// limits returns the attachment limits of a feed, the defaults when the feed
+// is unknown
+func (s *AttachmentService) limits(feedID string) feeds.AttachmentsParsed {
+	if s.feeds != nil && s.feeds.GetCache() != nil {
+		if ok, feed := s.feeds.GetCache().GetByID(feedID); ok {
+			return feed.Attachments
+		}
+	}
+
+	return feeds.Feed{}.IntoParsed().Attachments
+}
+
+// attachmentName returns the base name of an uploaded file name, shortened to
+// fit the database column
+func attachmentName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		name = "attachment"
+	}
+
+	if len(name) > maxAttachmentName {
+		name = strings.ToValidUTF8(name[:maxAttachmentName], "")
+	}
+
+	return name
+}
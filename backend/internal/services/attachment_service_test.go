@@ -0,0 +1,94 @@
+package services_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/hay-kot/hookfeed/backend/internal/core/blobstore"
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/testlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AttachmentService(t *testing.T) {
+	testlib.IntegrationGuard(t)
+	var (
+		st       = SetupServiceTest(t)
+		messages = services.NewFeedMessageService(st.logger, st.db)
+		ctx      = context.Background()
+	)
+
+	store, err := blobstore.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	s := services.NewAttachmentService(st.logger, st.db, store, nil)
+
+	data := dtos.FeedMessageCreateNew()
+	data.FeedID = "uploads"
+	msg, err := messages.Create(ctx, data)
+	require.NoError(t, err)
+
+	created, err := s.Create(ctx, "uploads", msg.ID, dtos.AttachmentUpload{
+		Name: "../logs/crash.txt",
+		Data: []byte("panic: runtime error"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "crash.txt", created.Name)
+	assert.Equal(t, "text/plain; charset=utf-8", created.ContentType)
+	assert.Equal(t, int64(20), created.Size)
+
+	got, err := s.GetByMessage(ctx, msg.ID)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, created.ID, got[0].ID)
+
+	content, err := s.Open(ctx, got[0])
+	require.NoError(t, err)
+	body, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	assert.Equal(t, "panic: runtime error", string(body))
+
+	// Deleting the message unlinks the attachment until the cleanup removes it
+	require.NoError(t, messages.Delete(ctx, msg.ID))
+
+	_, err = s.Get(ctx, created.ID)
+	require.Error(t, err)
+
+	n, err := s.DeleteUnlinked(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = s.Open(ctx, created)
+	assert.ErrorIs(t, err, blobstore.ErrNotFound)
+}
+
+func Test_AttachmentService_Check(t *testing.T) {
+	testlib.IntegrationGuard(t)
+	var (
+		st  = SetupServiceTest(t)
+		ctx = context.Background()
+	)
+
+	store, err := blobstore.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	s := services.NewAttachmentService(st.logger, st.db, store, nil)
+
+	require.NoError(t, s.Check(ctx, "uploads", 1024))
+
+	err = s.Check(ctx, "uploads", feeds.DefaultAttachmentMaxSize+1)
+	require.ErrorIs(t, err, services.ErrAttachmentTooLarge)
+
+	err = s.Check(ctx, "uploads", feeds.DefaultAttachmentMaxSize, feeds.DefaultAttachmentQuota)
+	require.ErrorIs(t, err, services.ErrAttachmentTooLarge)
+
+	sizes := make([]int64, 7)
+	for i := range sizes {
+		sizes[i] = feeds.DefaultAttachmentMaxSize
+	}
+	err = s.Check(ctx, "uploads", sizes...)
+	require.ErrorIs(t, err, services.ErrAttachmentQuota)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hay-kot/hookfeed/backend/hookfeed"
+	"github.com/hay-kot/hookfeed/backend/internal/data/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+var _ hookfeed.KVStore = &FeedKVService{}
+
+// FeedKVService stores the per-feed key/value state of middleware scripts.
+// Every operation is a single statement so concurrent webhooks for the same
+// feed never lose updates.
+type FeedKVService struct {
+	l  zerolog.Logger
+	db *db.QueriesExt
+}
+
+func NewFeedKVService(l zerolog.Logger, db *db.QueriesExt) *FeedKVService {
+	return &FeedKVService{
+		l:  l,
+		db: db,
+	}
+}
+
+func (s *FeedKVService) Get(ctx context.Context, feed, key string) ([]byte, bool, error) {
+	value, err := s.db.FeedKVGet(ctx, db.FeedKVGetParams{
+		FeedSlug: feed,
+		Key:      key,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+func (s *FeedKVService) Set(ctx context.Context, feed, key string, value []byte, ttl time.Duration) error {
+	return s.db.FeedKVSet(ctx, db.FeedKVSetParams{
+		FeedSlug:   feed,
+		Key:        key,
+		Value:      value,
+		TtlSeconds: ttlSeconds(ttl),
+	})
+}
+
+func (s *FeedKVService) Incr(ctx context.Context, feed, key string, delta int64, ttl time.Duration) (int64, error) {
+	return s.db.FeedKVIncr(ctx, db.FeedKVIncrParams{
+		FeedSlug:   feed,
+		Key:        key,
+		Delta:      delta,
+		TtlSeconds: ttlSeconds(ttl),
+	})
+}
+
+func (s *FeedKVService) Delete(ctx context.Context, feed, key string) (bool, error) {
+	n, err := s.db.FeedKVDelete(ctx, db.FeedKVDeleteParams{
+		FeedSlug: feed,
+		Key:      key,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// DeleteExpired removes all expired keys and returns the number deleted
+func (s *FeedKVService) DeleteExpired(ctx context.Context) (int64, error) {
+	return s.db.FeedKVDeleteExpired(ctx)
+}
+
+// ttlSeconds converts a ttl to the nullable seconds used by the queries, zero
+// meaning no expiry
+func ttlSeconds(ttl time.Duration) *float64 {
+	if ttl <= 0 {
+		return nil
+	}
+
+	secs := ttl.Seconds()
+	return &secs
+}
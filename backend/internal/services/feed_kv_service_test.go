@@ -0,0 +1,103 @@
+package services_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/testlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FeedKVService_SetGetDelete(t *testing.T) {
+	testlib.IntegrationGuard(t)
+	var (
+		st  = SetupServiceTest(t)
+		s   = services.NewFeedKVService(st.logger, st.db)
+		ctx = context.Background()
+	)
+
+	_, ok, err := s.Get(ctx, "feed", "key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, s.Set(ctx, "feed", "key", []byte(`{"a":1}`), 0))
+
+	value, ok, err := s.Get(ctx, "feed", "key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"a":1}`, string(value))
+
+	// keys are scoped per feed
+	_, ok, err = s.Get(ctx, "other", "key")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	deleted, err := s.Delete(ctx, "feed", "key")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = s.Delete(ctx, "feed", "key")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func Test_FeedKVService_Expiry(t *testing.T) {
+	testlib.IntegrationGuard(t)
+	var (
+		st  = SetupServiceTest(t)
+		s   = services.NewFeedKVService(st.logger, st.db)
+		ctx = context.Background()
+	)
+
+	require.NoError(t, s.Set(ctx, "feed", "short", []byte(`true`), 100*time.Millisecond))
+	_, err := s.Incr(ctx, "feed", "counter", 5, 100*time.Millisecond)
+	require.NoError(t, err)
+
+	time.Sleep(200 * time.Millisecond)
+
+	_, ok, err := s.Get(ctx, "feed", "short")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// an expired counter starts again from zero
+	n, err := s.Incr(ctx, "feed", "counter", 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	deleted, err := s.DeleteExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
+
+func Test_FeedKVService_ConcurrentIncr(t *testing.T) {
+	testlib.IntegrationGuard(t)
+	var (
+		st  = SetupServiceTest(t)
+		s   = services.NewFeedKVService(st.logger, st.db)
+		ctx = context.Background()
+	)
+
+	const workers, perWorker = 8, 25
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWorker {
+				_, err := s.Incr(ctx, "feed", "hits", 1, time.Hour)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	value, ok, err := s.Get(ctx, "feed", "hits")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "200", string(value))
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/rs/zerolog"
+)
+
+// subscriberBuffer is the number of messages queued for a subscriber before
+// new messages are dropped
+const subscriberBuffer = 64
+
+type subscriber struct {
+	feeds []string // empty subscribes to all feeds
+	ch    chan dtos.FeedMessage
+}
+
+// messageBroker fans out created messages to in-process subscribers, such as
+// the ntfy and Gotify streaming endpoints
+type messageBroker struct {
+	l    zerolog.Logger
+	mu   sync.RWMutex
+	subs map[*subscriber]struct{}
+}
+
+func newMessageBroker(l zerolog.Logger) *messageBroker {
+	return &messageBroker{
+		l:    l,
+		subs: map[*subscriber]struct{}{},
+	}
+}
+
+// subscribe returns a channel receiving the messages created for the feeds,
+// or for every feed when none are given. The channel is closed when ctx is
+// done.
+func (b *messageBroker) subscribe(ctx context.Context, feeds ...string) <-chan dtos.FeedMessage {
+	sub := &subscriber{
+		feeds: feeds,
+		ch:    make(chan dtos.FeedMessage, subscriberBuffer),
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		delete(b.subs, sub)
+		close(sub.ch)
+		b.mu.Unlock()
+	}()
+
+	return sub.ch
+}
+
+// publish sends the message to the matching subscribers without blocking,
+// subscribers that are not keeping up miss the message
+func (b *messageBroker) publish(msg dtos.FeedMessage) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		if len(sub.feeds) > 0 && !slices.Contains(sub.feeds, msg.FeedSlug) {
+			continue
+		}
+
+		select {
+		case sub.ch <- msg:
+		default:
+			b.l.Warn().
+				Str("feed_id", msg.FeedSlug).
+				Str("message_id", msg.ID.String()).
+				Msg("subscriber is not keeping up, dropping message")
+		}
+	}
+}
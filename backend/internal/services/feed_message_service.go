@@ -21,6 +21,7 @@ type FeedMessageService struct {
 	l      zerolog.Logger
 	db     *db.QueriesExt
 	mapper dtos.MapFunc[db.FeedMessagesView, dtos.FeedMessage]
+	broker *messageBroker
 }
 
 func NewFeedMessageService(l zerolog.Logger, db *db.QueriesExt) *FeedMessageService {
@@ -28,6 +29,7 @@ func NewFeedMessageService(l zerolog.Logger, db *db.QueriesExt) *FeedMessageServ
 		l:      l,
 		db:     db,
 		mapper: dtos.MapFeedMessageView,
+		broker: newMessageBroker(l),
 	}
 }
 
@@ -109,6 +111,28 @@ func (s *FeedMessageService) GetByFeedSlug(ctx context.Context, feedSlug string,
 	}, nil
 }
 
+// GetBefore returns up to limit messages with a sequential number below
+// before, newest first, optionally restricted to a feed. Use math.MaxInt64 to
+// start from the newest message.
+func (s *FeedMessageService) GetBefore(ctx context.Context, feedID *string, before int64, limit int) ([]dtos.FeedMessage, error) {
+	rows, err := s.db.FeedMessagesBeforeSeq(ctx, db.FeedMessagesBeforeSeqParams{
+		FeedSlug: feedID,
+		Before:   before,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract views from row wrappers
+	views := make([]db.FeedMessagesView, len(rows))
+	for i, row := range rows {
+		views[i] = row.FeedMessagesView
+	}
+
+	return s.mapper.Slice(views), nil
+}
+
 func (s *FeedMessageService) Search(ctx context.Context, query dtos.FeedMessageQuery) (dtos.PaginationResponse[dtos.FeedMessage], error) {
 	count, err := s.db.FeedMessageSearchCount(ctx, db.FeedMessageSearchCountParams{
 		FeedSlug: query.FeedSlug,
@@ -149,6 +173,69 @@ func (s *FeedMessageService) Search(ctx context.Context, query dtos.FeedMessageQ
 }
 
 func (s *FeedMessageService) Create(ctx context.Context, data dtos.FeedMessageCreate) (dtos.FeedMessage, error) {
+	row, err := s.db.FeedMessageCreate(ctx, createParams(data))
+	if err != nil {
+		return dtos.FeedMessage{}, err
+	}
+
+	// Convert row to view type
+	view := db.FeedMessagesView(row)
+
+	msg := s.mapper(view)
+	s.broker.publish(msg)
+
+	return msg, nil
+}
+
+// CreateAll creates the messages in a single transaction, either all of them
+// are saved or none. Subscribers receive the messages once they are committed.
+func (s *FeedMessageService) CreateAll(ctx context.Context, data []dtos.FeedMessageCreate) ([]dtos.FeedMessage, error) {
+	msgs := make([]dtos.FeedMessage, 0, len(data))
+
+	err := s.db.WithinTx(ctx, func(q *db.QueriesExt) error {
+		for _, d := range data {
+			row, err := q.FeedMessageCreate(ctx, createParams(d))
+			if err != nil {
+				return err
+			}
+
+			msgs = append(msgs, s.mapper(db.FeedMessagesView(row)))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, msg := range msgs {
+		s.broker.publish(msg)
+	}
+
+	return msgs, nil
+}
+
+// Subscribe returns a channel receiving the messages created with Create for
+// the feeds, or for every feed when none are given. The channel is closed when
+// ctx is done. Slow subscribers miss messages instead of blocking creation.
+func (s *FeedMessageService) Subscribe(ctx context.Context, feedIDs ...string) <-chan dtos.FeedMessage {
+	return s.broker.subscribe(ctx, feedIDs...)
+}
+
+// CreateBatch inserts the messages with a single COPY and returns the number
+// of messages inserted. Unlike Create the inserted messages are not returned
+// or sent to subscribers.
+func (s *FeedMessageService) CreateBatch(ctx context.Context, data []dtos.FeedMessageCreate) (int64, error) {
+	params := make([]db.FeedMessageCreateBatchParams, len(data))
+	for i, d := range data {
+		params[i] = db.FeedMessageCreateBatchParams(createParams(d))
+	}
+
+	return s.db.FeedMessageCreateBatch(ctx, params)
+}
+
+// createParams converts a message into insert parameters, applying the
+// defaults for priority, state and received time
+func createParams(data dtos.FeedMessageCreate) db.FeedMessageCreateParams {
 	priority := data.Priority
 	if priority == 0 {
 		priority = 3
@@ -164,7 +251,7 @@ func (s *FeedMessageService) Create(ctx context.Context, data dtos.FeedMessageCr
 		receivedAt = time.Now()
 	}
 
-	row, err := s.db.FeedMessageCreate(ctx, db.FeedMessageCreateParams{
+	return db.FeedMessageCreateParams{
 		FeedSlug:       data.FeedID,
 		RawRequest:     []byte(data.RawRequest),
 		RawHeaders:     []byte(data.RawHeaders),
@@ -177,15 +264,7 @@ func (s *FeedMessageService) Create(ctx context.Context, data dtos.FeedMessageCr
 		State:          &state,
 		ReceivedAt:     receivedAt,
 		ProcessedAt:    timePtrToPgTimestamp(data.ProcessedAt),
-	})
-	if err != nil {
-		return dtos.FeedMessage{}, err
 	}
-
-	// Convert row to view type
-	view := db.FeedMessagesView(row)
-
-	return s.mapper(view), nil
 }
 
 func (s *FeedMessageService) UpdateState(ctx context.Context, id uuid.UUID, state string) (dtos.FeedMessage, error) {
@@ -203,6 +282,29 @@ func (s *FeedMessageService) UpdateState(ctx context.Context, id uuid.UUID, stat
 	return s.mapper(view), nil
 }
 
+// ResolveGroup moves the new and acknowledged messages of a feed that share the
+// group key to the resolved state, returning the resolved messages
+func (s *FeedMessageService) ResolveGroup(ctx context.Context, feedSlug, groupKey string) ([]dtos.FeedMessage, error) {
+	ids, err := s.db.FeedMessageOpenIDsByGroupKey(ctx, db.FeedMessageOpenIDsByGroupKeyParams{
+		FeedSlug: feedSlug,
+		GroupKey: groupKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]dtos.FeedMessage, 0, len(ids))
+	for _, id := range ids {
+		msg, err := s.UpdateState(ctx, id, string(dtos.MessageStateResolved))
+		if err != nil {
+			return resolved, err
+		}
+		resolved = append(resolved, msg)
+	}
+
+	return resolved, nil
+}
+
 func (s *FeedMessageService) BulkUpdateState(ctx context.Context, data dtos.FeedMessageBulkUpdateState) error {
 	return s.db.FeedMessageBulkUpdateState(ctx, db.FeedMessageBulkUpdateStateParams{
 		Column1: data.MessageIDs,
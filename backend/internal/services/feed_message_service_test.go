@@ -0,0 +1,150 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/testlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FeedMessageService_ResolveGroup(t *testing.T) {
+	testlib.IntegrationGuard(t)
+	var (
+		st  = SetupServiceTest(t)
+		s   = services.NewFeedMessageService(st.logger, st.db)
+		ctx = context.Background()
+	)
+
+	create := func(feed, groupKey, state string) dtos.FeedMessage {
+		data := dtos.FeedMessageCreateNew()
+		data.FeedID = feed
+		data.State = state
+		metadata, err := json.Marshal(map[string]string{"groupKey": groupKey})
+		require.NoError(t, err)
+		data.Metadata = metadata
+
+		msg, err := s.Create(ctx, data)
+		require.NoError(t, err)
+		return msg
+	}
+
+	firing := create("alerts", "{}:{alertname=\"HighCPU\"}", "new")
+	acked := create("alerts", "{}:{alertname=\"HighCPU\"}", "acknowledged")
+	archived := create("alerts", "{}:{alertname=\"HighCPU\"}", "archived")
+	otherGroup := create("alerts", "{}:{alertname=\"DiskFull\"}", "new")
+	otherFeed := create("other", "{}:{alertname=\"HighCPU\"}", "new")
+
+	resolved, err := s.ResolveGroup(ctx, "alerts", "{}:{alertname=\"HighCPU\"}")
+	require.NoError(t, err)
+	require.Len(t, resolved, 2)
+	assert.Equal(t, firing.ID, resolved[0].ID)
+	assert.Equal(t, acked.ID, resolved[1].ID)
+
+	want := map[*dtos.FeedMessage]string{
+		&firing:     "resolved",
+		&acked:      "resolved",
+		&archived:   "archived",
+		&otherGroup: "new",
+		&otherFeed:  "new",
+	}
+	for msg, state := range want {
+		got, err := s.Get(ctx, msg.ID)
+		require.NoError(t, err)
+		assert.Equal(t, state, got.State)
+	}
+}
+
+func Test_FeedMessageService_CreateBatch(t *testing.T) {
+	testlib.IntegrationGuard(t)
+	var (
+		st  = SetupServiceTest(t)
+		s   = services.NewFeedMessageService(st.logger, st.db)
+		ctx = context.Background()
+	)
+
+	batch := make([]dtos.FeedMessageCreate, 3)
+	for i := range batch {
+		batch[i] = dtos.FeedMessageCreateNew()
+		batch[i].FeedID = "syslog"
+		batch[i].Priority = int32(i + 1)
+	}
+
+	n, err := s.CreateBatch(ctx, batch)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	got, err := s.GetByFeedSlug(ctx, "syslog", dtos.FeedMessageQuery{Pagination: dtos.Pagination{Limit: 10}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, got.Total)
+}
+
+func Test_FeedMessageService_Subscribe(t *testing.T) {
+	testlib.IntegrationGuard(t)
+	var (
+		st          = SetupServiceTest(t)
+		s           = services.NewFeedMessageService(st.logger, st.db)
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+
+	sub := s.Subscribe(ctx, "alerts")
+
+	create := func(feed string) dtos.FeedMessage {
+		data := dtos.FeedMessageCreateNew()
+		data.FeedID = feed
+
+		msg, err := s.Create(ctx, data)
+		require.NoError(t, err)
+		return msg
+	}
+
+	create("other")
+	want := create("alerts")
+
+	got := <-sub
+	assert.Equal(t, want.ID, got.ID)
+	assert.Empty(t, sub, "messages of other feeds should not be received")
+
+	cancel()
+	_, ok := <-sub
+	assert.False(t, ok, "channel should be closed when the context is done")
+}
+
+func Test_FeedMessageService_GetBefore(t *testing.T) {
+	testlib.IntegrationGuard(t)
+	var (
+		st  = SetupServiceTest(t)
+		s   = services.NewFeedMessageService(st.logger, st.db)
+		ctx = context.Background()
+	)
+
+	create := func(feed string) dtos.FeedMessage {
+		data := dtos.FeedMessageCreateNew()
+		data.FeedID = feed
+		msg, err := s.Create(ctx, data)
+		require.NoError(t, err)
+		return msg
+	}
+
+	first := create("gotify")
+	second := create("gotify")
+	other := create("other")
+
+	got, err := s.GetBefore(ctx, nil, math.MaxInt64, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, other.ID, got[0].ID)
+	assert.Equal(t, first.ID, got[2].ID)
+	assert.Less(t, first.Seq, second.Seq)
+
+	feed := "gotify"
+	got, err = s.GetBefore(ctx, &feed, second.Seq, 10)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, first.ID, got[0].ID)
+}
@@ -37,6 +37,10 @@ func (f *FeedService) GetByKey(key string) (dtos.Feed, bool) {
 			MaxCount:   feed.Retention.MaxCount,
 			MaxAgeDays: feed.Retention.MaxAgeDays,
 		},
+		Attachments: dtos.AttachmentLimits{
+			MaxSize: feed.Attachments.MaxSize,
+			Quota:   feed.Attachments.Quota,
+		},
 	}, true
 }
 
@@ -56,6 +60,41 @@ func (f *FeedService) GetAllFeeds() []dtos.Feed {
 				MaxCount:   f.Retention.MaxCount,
 				MaxAgeDays: f.Retention.MaxAgeDays,
 			},
+			Attachments: dtos.AttachmentLimits{
+				MaxSize: f.Attachments.MaxSize,
+				Quota:   f.Attachments.Quota,
+			},
 		}
 	})
 }
+
+// SyslogFeed returns the id of the feed a syslog message is routed to, and
+// false when the message matches no route or the routed feed does not exist
+func (f *FeedService) SyslogFeed(facility, appName, hostname string) (string, bool) {
+	id, ok := f.cache.SyslogRouting().Match(facility, appName, hostname)
+	if !ok {
+		return "", false
+	}
+
+	exists, _ := f.cache.GetByID(id)
+	return id, exists
+}
+
+// MaxBodySize returns the largest request body accepted for a feed key, see
+// [feeds.AttachmentsParsed.MaxBodySize]. Unknown keys are limited to
+// [feeds.BodyAllowance].
+func (f *FeedService) MaxBodySize(key string) int64 {
+	ok, feed := f.cache.GetByKey(key)
+	if !ok {
+		return feeds.BodyAllowance
+	}
+
+	return feed.Attachments.MaxBodySize()
+}
+
+// NtfySubscribe reports whether the messages of the feed of a key may be read
+// with the ntfy subscribe endpoints, which feeds opt into with ntfy_subscribe
+func (f *FeedService) NtfySubscribe(key string) bool {
+	ok, feed := f.cache.GetByKey(key)
+	return ok && feed.NtfySubscribe
+}
@@ -0,0 +1,29 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FeedService_MaxBodySize(t *testing.T) {
+	var (
+		maxSize  = int64(4 << 20)
+		disabled = int64(0)
+	)
+
+	s := services.NewFeedService(feeds.NewCache(&feeds.Config{
+		Feeds: []feeds.Feed{
+			{ID: "default", Keys: []string{"default-key"}},
+			{ID: "large", Keys: []string{"large-key"}, Attachments: &feeds.Attachments{MaxSize: &maxSize}},
+			{ID: "none", Keys: []string{"none-key"}, Attachments: &feeds.Attachments{MaxSize: &disabled}},
+		},
+	}))
+
+	assert.Equal(t, int64(feeds.DefaultAttachmentMaxSize+feeds.BodyAllowance), s.MaxBodySize("default-key"))
+	assert.Equal(t, maxSize+feeds.BodyAllowance, s.MaxBodySize("large-key"))
+	assert.Equal(t, int64(feeds.BodyAllowance), s.MaxBodySize("none-key"))
+	assert.Equal(t, int64(feeds.BodyAllowance), s.MaxBodySize("unknown-key"))
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hay-kot/hookfeed/backend/internal/data/db"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/pkgs/hasher"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+// GotifyClientService manages the tokens of Gotify clients. Client tokens are
+// separate from session tokens so a token stored in a phone app can be
+// revoked without ending the web session of the user, and the reverse.
+type GotifyClientService struct {
+	l      zerolog.Logger
+	db     *db.QueriesExt
+	mapper dtos.MapFunc[db.User, dtos.User]
+}
+
+func NewGotifyClientService(l zerolog.Logger, db *db.QueriesExt) *GotifyClientService {
+	return &GotifyClientService{
+		l:      l,
+		db:     db,
+		mapper: dtos.MapUser,
+	}
+}
+
+// Create issues a client token for the user. Only the hash of the token is
+// stored, the returned client is the only place the token is available.
+func (s *GotifyClientService) Create(ctx context.Context, userID uuid.UUID, name string) (dtos.GotifyClient, error) {
+	token := hasher.GenerateToken()
+
+	client, err := s.db.GotifyClientCreate(ctx, db.GotifyClientCreateParams{
+		UserID: userID,
+		Name:   name,
+		Token:  token.Hash,
+	})
+	if err != nil {
+		return dtos.GotifyClient{}, err
+	}
+
+	created := dtos.MapGotifyClient(client)
+	created.Token = token.Raw
+	return created, nil
+}
+
+// Verify returns the user of a client token
+func (s *GotifyClientService) Verify(ctx context.Context, token string) (dtos.User, error) {
+	user, err := s.db.UserByGotifyClient(ctx, hasher.HashToken(token))
+	if err != nil {
+		return dtos.User{}, err
+	}
+
+	return s.mapper.Map(user), nil
+}
+
+// GetAll returns the clients of a user, without their tokens
+func (s *GotifyClientService) GetAll(ctx context.Context, userID uuid.UUID) ([]dtos.GotifyClient, error) {
+	clients, err := s.db.GotifyClientsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]dtos.GotifyClient, len(clients))
+	for i, client := range clients {
+		out[i] = dtos.MapGotifyClient(client)
+	}
+
+	return out, nil
+}
+
+// Delete revokes the client of the user with the Gotify id, pgx.ErrNoRows is
+// returned when the user has no such client
+func (s *GotifyClientService) Delete(ctx context.Context, userID uuid.UUID, id int64) error {
+	clients, err := s.db.GotifyClientsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, client := range clients {
+		if dtos.GotifyAppID(client.ID.String()) != id {
+			continue
+		}
+
+		_, err := s.db.GotifyClientDelete(ctx, db.GotifyClientDeleteParams{ID: client.ID, UserID: userID})
+		return err
+	}
+
+	return pgx.ErrNoRows
+}
@@ -0,0 +1,55 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/testlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GotifyClientService(t *testing.T) {
+	testlib.IntegrationGuard(t)
+	var (
+		st    = SetupServiceTest(t)
+		s     = services.NewGotifyClientService(st.logger, st.db)
+		users = services.NewUserService(st.logger, st.db)
+		ctx   = context.Background()
+	)
+
+	client, err := s.Create(ctx, st.dbuser.ID, "phone")
+	require.NoError(t, err)
+	require.NotEmpty(t, client.Token)
+	assert.Equal(t, "phone", client.Name)
+
+	user, err := s.Verify(ctx, client.Token)
+	require.NoError(t, err)
+	assert.Equal(t, st.dbuser.ID, user.ID)
+
+	// Session tokens are not client tokens
+	session, err := users.Authenticate(ctx, dtos.UserAuthenticate{Email: st.user.Email, Password: st.user.Password})
+	require.NoError(t, err)
+	_, err = s.Verify(ctx, session.Token)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	clients, err := s.GetAll(ctx, st.dbuser.ID)
+	require.NoError(t, err)
+	require.Len(t, clients, 1)
+	assert.Equal(t, client.ID, clients[0].ID)
+	assert.Empty(t, clients[0].Token)
+
+	// Clients of other users cannot be deleted
+	require.ErrorIs(t, s.Delete(ctx, st.dbadmin.ID, client.ID), pgx.ErrNoRows)
+
+	require.NoError(t, s.Delete(ctx, st.dbuser.ID, client.ID))
+	_, err = s.Verify(ctx, client.Token)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	// The session of the user is not affected
+	_, err = users.SessionVerify(ctx, session.Token)
+	require.NoError(t, err)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/pkgs/utils"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitSweepInterval is how often limiters of idle source IPs are removed
+const rateLimitSweepInterval = time.Minute
+
+// RateLimitService limits the webhook requests accepted per feed and per
+// source IP. Limits and counters are kept in memory and reset on restart.
+type RateLimitService struct {
+	feeds *FeedService
+
+	mu        sync.Mutex
+	byFeed    map[string]*rate.Limiter // feed id => limiter
+	byIP      map[string]*rate.Limiter // source ip => limiter
+	stats     map[string]*dtos.RateLimitStats
+	lastSweep time.Time
+}
+
+func NewRateLimitService(feeds *FeedService) *RateLimitService {
+	return &RateLimitService{
+		feeds:     feeds,
+		byFeed:    map[string]*rate.Limiter{},
+		byIP:      map[string]*rate.Limiter{},
+		stats:     map[string]*dtos.RateLimitStats{},
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow reports whether a webhook request for the feed key from the source IP
+// is accepted. When it is not, the duration to wait before retrying is returned.
+// Requests for unknown feed keys are only limited per source IP.
+func (s *RateLimitService) Allow(feedKey, ip string) (time.Duration, bool) {
+	if s.feeds == nil || s.feeds.GetCache() == nil {
+		return 0, true
+	}
+
+	cache := s.feeds.GetCache()
+	known, feed := cache.GetByKey(feedKey)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	var ipRes *rate.Reservation
+	if limit := cache.RateLimit(); limit != nil {
+		lim, ok := s.byIP[ip]
+		if !ok {
+			lim = newLimiter(*limit)
+			s.byIP[ip] = lim
+		}
+
+		res, retry, allowed := reserve(lim, *limit, now)
+		if !allowed {
+			if known {
+				s.reject(feed.ID, now).RejectedByIP++
+			}
+			return retry, false
+		}
+		ipRes = res
+	}
+
+	if !known || feed.RateLimit == nil {
+		return 0, true
+	}
+
+	lim, ok := s.byFeed[feed.ID]
+	if !ok {
+		lim = newLimiter(*feed.RateLimit)
+		s.byFeed[feed.ID] = lim
+	}
+
+	_, retry, allowed := reserve(lim, *feed.RateLimit, now)
+	if !allowed {
+		// The request is not accepted, it does not count against the source IP
+		if ipRes != nil {
+			ipRes.CancelAt(now)
+		}
+		s.reject(feed.ID, now).Rejected++
+		return retry, false
+	}
+
+	return 0, true
+}
+
+// Stats returns the rejected requests of all feeds
+func (s *RateLimitService) Stats() []dtos.RateLimitStats {
+	if s.feeds == nil || s.feeds.GetCache() == nil {
+		return []dtos.RateLimitStats{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return utils.Map(s.feeds.GetCache().GetAll(), func(f feeds.FeedParsed) dtos.RateLimitStats {
+		stats := dtos.RateLimitStats{FeedID: f.ID}
+		if st, ok := s.stats[f.ID]; ok {
+			stats = *st
+		}
+		stats.Limited = f.RateLimit != nil
+		return stats
+	})
+}
+
+// reject returns the counters of a feed with the rejection time updated, the
+// caller holds the lock
+func (s *RateLimitService) reject(feedID string, now time.Time) *dtos.RateLimitStats {
+	st, ok := s.stats[feedID]
+	if !ok {
+		st = &dtos.RateLimitStats{FeedID: feedID}
+		s.stats[feedID] = st
+	}
+
+	st.LastRejectedAt = &now
+	return st
+}
+
+// sweep removes the limiters of source IPs that have refilled, they behave the
+// same as new ones. The caller holds the lock.
+func (s *RateLimitService) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for ip, lim := range s.byIP {
+		if lim.TokensAt(now) >= float64(lim.Burst()) {
+			delete(s.byIP, ip)
+		}
+	}
+}
+
+func newLimiter(limit feeds.RateLimit) *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(limit.PerSecond()), limit.Burst)
+}
+
+// reserve takes a token of the limiter. When none is available the
+// reservation is cancelled and the time until one is available is returned.
+func reserve(lim *rate.Limiter, limit feeds.RateLimit, now time.Time) (*rate.Reservation, time.Duration, bool) {
+	r := lim.ReserveN(now, 1)
+	if !r.OK() {
+		// Invalid limits without burst never allow a request
+		return nil, limit.Interval, false
+	}
+
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return nil, delay, false
+	}
+
+	return r, 0, true
+}
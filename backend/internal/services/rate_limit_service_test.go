@@ -0,0 +1,117 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRateLimitService(global *feeds.RateLimit, limited feeds.Feed) *services.RateLimitService {
+	cache := feeds.NewCache(&feeds.Config{
+		RateLimit: global,
+		Feeds: []feeds.Feed{
+			limited,
+			{ID: "open", Keys: []string{"open-key"}},
+		},
+	})
+
+	return services.NewRateLimitService(services.NewFeedService(cache))
+}
+
+func Test_RateLimitService_Feed(t *testing.T) {
+	s := newRateLimitService(nil, feeds.Feed{
+		ID:        "limited",
+		Keys:      []string{"limited-key"},
+		RateLimit: &feeds.RateLimit{Requests: 1, Interval: time.Hour, Burst: 2},
+	})
+
+	for range 2 {
+		_, ok := s.Allow("limited-key", "10.0.0.1")
+		require.True(t, ok)
+	}
+
+	// The limit applies to the feed, whichever address sends the request
+	retry, ok := s.Allow("limited-key", "10.0.0.2")
+	require.False(t, ok)
+	assert.Greater(t, retry, 59*time.Minute)
+	assert.LessOrEqual(t, retry, time.Hour)
+
+	for range 5 {
+		_, ok := s.Allow("open-key", "10.0.0.1")
+		assert.True(t, ok)
+	}
+
+	stats := s.Stats()
+	require.Len(t, stats, 2)
+
+	assert.Equal(t, "limited", stats[0].FeedID)
+	assert.True(t, stats[0].Limited)
+	assert.Equal(t, int64(1), stats[0].Rejected)
+	assert.Equal(t, int64(0), stats[0].RejectedByIP)
+	assert.NotNil(t, stats[0].LastRejectedAt)
+
+	assert.Equal(t, "open", stats[1].FeedID)
+	assert.False(t, stats[1].Limited)
+	assert.Equal(t, int64(0), stats[1].Rejected)
+	assert.Nil(t, stats[1].LastRejectedAt)
+}
+
+func Test_RateLimitService_IP(t *testing.T) {
+	s := newRateLimitService(
+		&feeds.RateLimit{Requests: 3, Interval: time.Hour},
+		feeds.Feed{
+			ID:        "limited",
+			Keys:      []string{"limited-key"},
+			RateLimit: &feeds.RateLimit{Requests: 1, Interval: time.Hour},
+		},
+	)
+
+	_, ok := s.Allow("open-key", "10.0.0.1")
+	require.True(t, ok)
+
+	// Requests rejected by the feed limit do not count against the address
+	_, ok = s.Allow("limited-key", "10.0.0.1")
+	require.True(t, ok)
+	_, ok = s.Allow("limited-key", "10.0.0.1")
+	require.False(t, ok)
+
+	_, ok = s.Allow("open-key", "10.0.0.1")
+	require.True(t, ok)
+	_, ok = s.Allow("open-key", "10.0.0.1")
+	require.False(t, ok)
+
+	// Unknown keys are limited per address as well
+	_, ok = s.Allow("unknown-key", "10.0.0.1")
+	require.False(t, ok)
+
+	_, ok = s.Allow("open-key", "10.0.0.2")
+	require.True(t, ok)
+
+	stats := s.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, int64(1), stats[0].Rejected)
+	assert.Equal(t, int64(1), stats[1].RejectedByIP)
+}
+
+func Test_RateLimitService_NoFeeds(t *testing.T) {
+	s := services.NewRateLimitService(nil)
+
+	_, ok := s.Allow("any-key", "10.0.0.1")
+	assert.True(t, ok)
+	assert.Empty(t, s.Stats())
+}
+
+func Test_RateLimit_IntoParsed(t *testing.T) {
+	parsed := feeds.RateLimit{Requests: 30}.IntoParsed()
+	assert.Equal(t, feeds.DefaultRateLimitInterval, parsed.Interval)
+	assert.Equal(t, 30, parsed.Burst)
+	assert.InDelta(t, 0.5, parsed.PerSecond(), 0.0001)
+
+	require.NoError(t, parsed.Validate())
+	require.Error(t, feeds.RateLimit{}.Validate())
+	require.Error(t, feeds.RateLimit{Requests: 1, Burst: -1}.Validate())
+}
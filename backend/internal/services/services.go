@@ -4,28 +4,54 @@ package services
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/hay-kot/hookfeed/backend/hookfeed"
+	"github.com/hay-kot/hookfeed/backend/internal/core/blobstore"
 	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
 	"github.com/hay-kot/hookfeed/backend/internal/core/tasks"
 	"github.com/hay-kot/hookfeed/backend/internal/data/db"
+	"github.com/hay-kot/hookfeed/backend/internal/services/adapters"
 	"github.com/rs/zerolog"
 )
 
 type Config struct {
-	CompanyName string `json:"company_name" conf:"default:Gottl Inc."            env:"COMPANY_NAME"`
-	WebURL      string `json:"web_url"      conf:"default:http://localhost:8080" env:"WEB_URL"`
-	FeedFile    string `json:"feed_file"    conf:"default:configs/feeds.yml"     env:"FEED_FILE"`
-	NtfyEnabled bool   `json:"ntfy_enabled" conf:"default:true"                  env:"NTFY_ENABLED"` // Enable ntfy-compatible endpoint
+	CompanyName   string `json:"company_name"   conf:"default:Gottl Inc."            env:"COMPANY_NAME"`
+	WebURL        string `json:"web_url"        conf:"default:http://localhost:8080" env:"WEB_URL"`
+	FeedFile      string `json:"feed_file"      conf:"default:configs/feeds.yml"     env:"FEED_FILE"`
+	MiddlewareDir string `json:"middleware_dir" conf:"default:"                      env:"MIDDLEWARE_DIR"`                   // Directory middleware scripts are resolved from
+	NtfyEnabled   bool   `json:"ntfy_enabled"   conf:"default:true"                  env:"NTFY_ENABLED"   envDefault:"true"` // Enable ntfy-compatible endpoint
+
+	LuaTimeout         time.Duration `json:"lua_timeout"          env:"LUA_TIMEOUT"          envDefault:"2s"`        // Wall-clock limit for a single middleware run
+	LuaMaxInstructions int64         `json:"lua_max_instructions" env:"LUA_MAX_INSTRUCTIONS" envDefault:"10000000"`  // VM instruction budget for a single middleware run, 0 disables
+	LuaMaxMemory       uint64        `json:"lua_max_memory"       env:"LUA_MAX_MEMORY"       envDefault:"134217728"` // Heap growth budget in bytes for a single middleware run, 0 disables
+
+	AttachmentDir string `json:"attachment_dir" env:"ATTACHMENT_DIR" envDefault:"data/attachments"` // Directory the files of messages are stored in
+}
+
+// LuaLimits returns the sandbox limits for middleware scripts
+func (c Config) LuaLimits() hookfeed.Limits {
+	limits := hookfeed.DefaultLimits
+	limits.Timeout = c.LuaTimeout
+	limits.MaxInstructions = c.LuaMaxInstructions
+	limits.MaxMemory = c.LuaMaxMemory
+	return limits
 }
 
 // Service is a collection of all services in the application
 type Service struct {
-	Admin        *AdminService
-	Users        *UserService
-	Passwords    *PasswordService
-	Feeds        *FeedService
-	Webhooks     *WebhookService
-	FeedMessages *FeedMessageService
+	Config        Config
+	Admin         *AdminService
+	Users         *UserService
+	Passwords     *PasswordService
+	Feeds         *FeedService
+	Webhooks      *WebhookService
+	FeedMessages  *FeedMessageService
+	FeedKV        *FeedKVService
+	Attachments   *AttachmentService
+	RateLimits    *RateLimitService
+	Signatures    *SignatureFailureService
+	GotifyClients *GotifyClientService
 	// $scaffold_inject_service
 }
 
@@ -36,7 +62,12 @@ func NewService(
 	queue tasks.Queue,
 ) (*Service, error) {
 	// Load feed file if path is provided
-	var feedService *FeedService
+	var (
+		feedService *FeedService
+		pipeline    *hookfeed.Pipeline
+		feedKV      = NewFeedKVService(l, db)
+		registry    = adapters.DefaultRegistry()
+	)
 	if cfg.FeedFile != "" {
 		file, err := os.Open(cfg.FeedFile)
 		if err != nil {
@@ -54,21 +85,105 @@ func NewService(
 			Int("middleware", len(feedFile.Middleware)).
 			Msg("loaded feed configuration")
 
+		for _, feed := range feedFile.Feeds {
+			for _, ref := range feed.Adapters {
+				if _, err := registry.Resolve(ref); err != nil {
+					l.Warn().Err(err).Str("feed_id", feed.ID).Msg("feed references an unknown adapter")
+				}
+			}
+
+			if feed.Signature != nil {
+				if err := feed.Signature.IntoParsed().Validate(); err != nil {
+					l.Warn().Err(err).Str("feed_id", feed.ID).Msg("feed has an invalid signature configuration, all requests will be rejected")
+				}
+			}
+
+			if feed.RateLimit != nil {
+				if err := feed.RateLimit.Validate(); err != nil {
+					l.Warn().Err(err).Str("feed_id", feed.ID).Msg("feed has an invalid rate limit configuration, all requests will be rejected")
+				}
+			}
+
+			if feed.Form != nil && feed.Form.Captcha != nil {
+				if err := feed.Form.Captcha.IntoParsed().Validate(); err != nil {
+					l.Warn().Err(err).Str("feed_id", feed.ID).Msg("feed has an invalid captcha configuration, all form posts will be rejected")
+				}
+			}
+
+			for ref := range feed.AdapterSecrets {
+				a, err := registry.Resolve(ref)
+				if err != nil {
+					l.Warn().Err(err).Str("feed_id", feed.ID).Msg("feed has a secret for an unknown adapter, all requests will be rejected")
+					continue
+				}
+
+				if _, ok := a.(adapters.Verifier); !ok {
+					l.Warn().Str("feed_id", feed.ID).Str("adapter", adapters.Ref(a)).Msg("feed has a secret for an adapter without verification, all requests will be rejected")
+				}
+			}
+		}
+
+		if feedFile.RateLimit != nil {
+			if err := feedFile.RateLimit.Validate(); err != nil {
+				l.Warn().Err(err).Msg("invalid rate limit configuration, all webhook requests will be rejected")
+			}
+		}
+
 		cache := feeds.NewCache(feedFile)
 
+		for _, route := range feedFile.Syslog.Routes {
+			if ok, _ := cache.GetByID(route.Feed); !ok {
+				l.Warn().Str("feed_id", route.Feed).Msg("syslog route references an unknown feed, matching messages will be dropped")
+			}
+
+			if err := route.Validate(); err != nil {
+				l.Warn().Err(err).Str("feed_id", route.Feed).Msg("syslog route has an invalid pattern")
+			}
+		}
+
+		if id := feedFile.Syslog.DefaultFeed; id != "" {
+			if ok, _ := cache.GetByID(id); !ok {
+				l.Warn().Str("feed_id", id).Msg("syslog default feed is unknown, unmatched messages will be dropped")
+			}
+		}
+
+		for _, sub := range feedFile.MQTT.Subscriptions {
+			if ok, _ := cache.GetByID(sub.Feed); !ok {
+				l.Warn().Str("feed_id", sub.Feed).Str("topic", sub.Topic).Msg("mqtt subscription references an unknown feed, its messages will be dropped")
+			}
+
+			if err := sub.Validate(); err != nil {
+				l.Warn().Err(err).Str("feed_id", sub.Feed).Msg("mqtt subscription is invalid")
+			}
+		}
+
 		feedService = NewFeedService(cache)
+		pipeline = hookfeed.NewPipeline(cfg.MiddlewareDir, feedFile.Middleware, cfg.LuaLimits(), feedKV)
+	}
+
+	store, err := blobstore.NewLocal(cfg.AttachmentDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open attachment store: %w", err)
 	}
 
 	feedMessageService := NewFeedMessageService(l, db)
-	webhookService := NewWebhookService(l, feedService, feedMessageService)
+	attachmentService := NewAttachmentService(l, db, store, feedService)
+	signatureService := NewSignatureFailureService(feedService)
+	webhookService := NewWebhookService(l, feedService, feedMessageService, attachmentService, signatureService, pipeline, registry)
 
 	return &Service{
-		Admin:        NewAdminService(l, db),
-		Users:        NewUserService(l, db),
-		Passwords:    NewPasswordService(cfg, l, db, queue),
-		Feeds:        feedService,
-		Webhooks:     webhookService,
-		FeedMessages: feedMessageService,
+		Config:        cfg,
+		Admin:         NewAdminService(l, db),
+		Users:         NewUserService(l, db),
+		Passwords:     NewPasswordService(cfg, l, db, queue),
+		Feeds:         feedService,
+		Webhooks:      webhookService,
+		FeedMessages:  feedMessageService,
+		FeedKV:        feedKV,
+		Attachments:   attachmentService,
+		RateLimits:    NewRateLimitService(feedService),
+		Signatures:    signatureService,
+		GotifyClients: NewGotifyClientService(l, db),
 		// $scaffold_inject_constructor
 	}, nil
 }
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/pkgs/utils"
+)
+
+// SignatureFailureService counts the webhook requests rejected by signature
+// verification per feed. Counters are kept in memory and reset on restart.
+type SignatureFailureService struct {
+	feeds *FeedService
+
+	mu    sync.Mutex
+	stats map[string]*dtos.SignatureStats
+}
+
+func NewSignatureFailureService(feeds *FeedService) *SignatureFailureService {
+	return &SignatureFailureService{
+		feeds: feeds,
+		stats: map[string]*dtos.SignatureStats{},
+	}
+}
+
+// Record counts a request of the feed rejected with reason
+func (s *SignatureFailureService) Record(feedID string, reason error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.stats[feedID]
+	if !ok {
+		st = &dtos.SignatureStats{FeedID: feedID}
+		s.stats[feedID] = st
+	}
+
+	st.Rejected++
+	st.LastRejectedAt = &now
+	st.LastError = reason.Error()
+}
+
+// Stats returns the rejected requests of all feeds
+func (s *SignatureFailureService) Stats() []dtos.SignatureStats {
+	if s.feeds == nil || s.feeds.GetCache() == nil {
+		return []dtos.SignatureStats{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return utils.Map(s.feeds.GetCache().GetAll(), func(f feeds.FeedParsed) dtos.SignatureStats {
+		stats := dtos.SignatureStats{FeedID: f.ID}
+		if st, ok := s.stats[f.ID]; ok {
+			stats = *st
+		}
+		stats.Verified = f.Signature != nil || len(f.AdapterSecrets) > 0
+		return stats
+	})
+}
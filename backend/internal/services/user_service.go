@@ -51,6 +51,17 @@ func (s *UserService) Register(ctx context.Context, data dtos.UserRegister) (dto
 // time comparison to prevent timing attacks. When no use is found by the provided email
 // address, the same error is returned to prevent user enumeration.
 func (s *UserService) Authenticate(ctx context.Context, data dtos.UserAuthenticate) (dtos.UserSession, error) {
+	user, err := s.VerifyCredentials(ctx, data)
+	if err != nil {
+		return dtos.UserSession{}, err
+	}
+
+	return s.createSession(ctx, user)
+}
+
+// VerifyCredentials validates a user's credentials like Authenticate, without
+// creating a session.
+func (s *UserService) VerifyCredentials(ctx context.Context, data dtos.UserAuthenticate) (dtos.User, error) {
 	dbuser, err := s.db.UserByEmail(ctx, data.Email)
 	if err != nil {
 		// This is to prevent timing attacks ensuring that when no user is found we
@@ -61,15 +72,15 @@ func (s *UserService) Authenticate(ctx context.Context, data dtos.UserAuthentica
 		hasher.CheckPasswordHash(data.Password, savedHash)
 
 		s.l.Error().Ctx(ctx).Err(err).Str("email", data.Email).Msg("failed to get user by email")
-		return dtos.UserSession{}, ErrInvalidLogin
+		return dtos.User{}, ErrInvalidLogin
 	}
 
 	if !hasher.CheckPasswordHash(data.Password, dbuser.PasswordHash) {
 		s.l.Error().Ctx(ctx).Err(err).Str("email", data.Email).Msg("password verification failed")
-		return dtos.UserSession{}, ErrInvalidLogin
+		return dtos.User{}, ErrInvalidLogin
 	}
 
-	return s.createSession(ctx, s.mapper.Map(dbuser))
+	return s.mapper.Map(dbuser), nil
 }
 
 // SessionVerify validates a user's session token and returns the user if the token is valid
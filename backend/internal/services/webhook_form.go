@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+)
+
+// ErrCaptchaFailed is returned when a form post fails captcha verification
+var ErrCaptchaFailed = errors.New("captcha verification failed")
+
+// VerifyCaptcha checks a captcha response with the siteverify endpoint of the
+// provider. Rejected and missing responses wrap ErrCaptchaFailed, errors
+// reaching the provider do not.
+func VerifyCaptcha(ctx context.Context, client *http.Client, captcha feeds.CaptchaParsed, response, remoteIP string) error {
+	if err := captcha.Validate(); err != nil {
+		return fmt.Errorf("invalid captcha configuration: %w", err)
+	}
+
+	if response == "" {
+		return fmt.Errorf("%w: missing %s field", ErrCaptchaFailed, captcha.Field)
+	}
+
+	form := url.Values{
+		"secret":   {captcha.Secret},
+		"response": {response},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, captcha.VerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create captcha request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("verify captcha: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("verify captcha: unexpected status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return fmt.Errorf("decode captcha response: %w", err)
+	}
+
+	if !result.Success {
+		return fmt.Errorf("%w: %s", ErrCaptchaFailed, strings.Join(result.ErrorCodes, ", "))
+	}
+
+	return nil
+}
+
+// checkForm applies the honeypot and captcha checks of a feed to the fields of
+// a form post and removes the checked fields from them. It reports whether the
+// submission was caught by a honeypot and should be dropped.
+func (w *WebhookService) checkForm(ctx context.Context, form feeds.FormParsed, fields map[string]any, remoteIP string) (bool, error) {
+	caught := false
+	for _, name := range form.Honeypot {
+		if v, ok := fields[name]; ok && v != "" {
+			caught = true
+		}
+		delete(fields, name)
+	}
+
+	if caught {
+		return true, nil
+	}
+
+	if form.Captcha != nil {
+		response, _ := fields[form.Captcha.Field].(string)
+		delete(fields, form.Captcha.Field)
+
+		err := VerifyCaptcha(ctx, w.httpClient, *form.Captcha, response, remoteIP)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,64 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VerifyCaptcha(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.FormValue("secret"))
+		assert.Equal(t, "203.0.113.7", r.FormValue("remoteip"))
+
+		resp := map[string]any{"success": r.FormValue("response") == "valid-token"}
+		if r.FormValue("response") != "valid-token" {
+			resp["error-codes"] = []string{"invalid-input-response"}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	captcha := feeds.Captcha{Provider: "turnstile", Secret: "secret", VerifyURL: srv.URL}.IntoParsed()
+	assert.Equal(t, "cf-turnstile-response", captcha.Field)
+
+	ctx := context.Background()
+
+	err := services.VerifyCaptcha(ctx, srv.Client(), captcha, "valid-token", "203.0.113.7")
+	require.NoError(t, err)
+
+	err = services.VerifyCaptcha(ctx, srv.Client(), captcha, "forged", "203.0.113.7")
+	require.ErrorIs(t, err, services.ErrCaptchaFailed)
+	assert.Contains(t, err.Error(), "invalid-input-response")
+
+	err = services.VerifyCaptcha(ctx, srv.Client(), captcha, "", "203.0.113.7")
+	require.ErrorIs(t, err, services.ErrCaptchaFailed)
+}
+
+func Test_VerifyCaptcha_ProviderError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	captcha := feeds.Captcha{Provider: "hcaptcha", Secret: "secret", VerifyURL: srv.URL}.IntoParsed()
+
+	err := services.VerifyCaptcha(context.Background(), srv.Client(), captcha, "token", "")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, services.ErrCaptchaFailed)
+}
+
+func Test_VerifyCaptcha_InvalidConfig(t *testing.T) {
+	captcha := feeds.Captcha{Provider: "friendlycaptcha", Secret: "secret"}.IntoParsed()
+
+	err := services.VerifyCaptcha(context.Background(), http.DefaultClient, captcha, "token", "")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, services.ErrCaptchaFailed)
+}
@@ -2,12 +2,18 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"net/http"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/hay-kot/hookfeed/backend/hookfeed"
 	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
 	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services/adapters"
 	"github.com/rs/zerolog"
 )
 
@@ -16,19 +22,41 @@ var (
 	ErrInvalidAPIKey = errors.New("invalid API key")
 	ErrInvalidJSON   = errors.New("invalid JSON body")
 	ErrFeedNotInit   = errors.New("feed service not initialized")
+
+	// ErrInvalidSignature is returned when a request fails the verification
+	// of a feed's signature or adapter secrets
+	ErrInvalidSignature = adapters.ErrInvalidSignature
 )
 
 type WebhookService struct {
 	logger             zerolog.Logger
 	feedService        *FeedService
 	feedMessageService *FeedMessageService
+	attachments        *AttachmentService
+	signatures         *SignatureFailureService
+	pipeline           *hookfeed.Pipeline
+	adapters           *adapters.Registry
+	httpClient         *http.Client
 }
 
-func NewWebhookService(logger zerolog.Logger, feedService *FeedService, feedMessageService *FeedMessageService) *WebhookService {
+func NewWebhookService(
+	logger zerolog.Logger,
+	feedService *FeedService,
+	feedMessageService *FeedMessageService,
+	attachments *AttachmentService,
+	signatures *SignatureFailureService,
+	pipeline *hookfeed.Pipeline,
+	registry *adapters.Registry,
+) *WebhookService {
 	return &WebhookService{
 		logger:             logger.With().Str("service", "webhook").Logger(),
 		feedService:        feedService,
 		feedMessageService: feedMessageService,
+		attachments:        attachments,
+		signatures:         signatures,
+		pipeline:           pipeline,
+		adapters:           registry,
+		httpClient:         &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
@@ -61,10 +89,158 @@ func (w *WebhookService) ProcessWebhook(ctx context.Context, req dtos.WebhookReq
 		Interface("query_params", req.QueryParams).
 		Msg("webhook payload")
 
+	// Verify signatures before any middleware sees the request
+	if feed.Signature != nil {
+		err = VerifySignature(*feed.Signature, req.RawBody, req.Headers, time.Now())
+		if err != nil {
+			w.logger.Warn().
+				Err(err).
+				Str("feed_id", feed.ID).
+				Msg("webhook failed signature verification")
+			w.recordSignatureFailure(feed.ID, err)
+			return nil, err
+		}
+	}
+
+	if len(feed.AdapterSecrets) > 0 && w.adapters != nil {
+		err = w.adapters.Verify(feed.AdapterSecrets, req.RawBody, firstHeaderValues(req.Headers))
+		if err != nil {
+			w.logger.Warn().
+				Err(err).
+				Str("feed_id", feed.ID).
+				Msg("webhook failed verification")
+			w.recordSignatureFailure(feed.ID, err)
+			return nil, err
+		}
+	}
+
+	// Form posts are checked before middleware and redirect the browser when
+	// the feed has a redirect URL, also when a honeypot drops the submission
+	var redirectURL string
+	if req.Form {
+		redirectURL = feed.Form.RedirectURL
+
+		caught, err := w.checkForm(ctx, feed.Form, req.Body, req.RemoteIP)
+		if err != nil {
+			w.logger.Warn().
+				Err(err).
+				Str("feed_id", feed.ID).
+				Msg("form submission failed verification")
+			return nil, err
+		}
+
+		if caught {
+			w.logger.Info().
+				Str("feed_id", feed.ID).
+				Msg("form submission caught by honeypot")
+
+			return &dtos.WebhookResponse{
+				Success:     true,
+				FeedID:      feed.ID,
+				Aborted:     true,
+				RedirectURL: redirectURL,
+			}, nil
+		}
+	}
+
+	// Uploads are checked against the attachment limits before a message is
+	// saved, so a rejected upload rejects the whole request
+	if len(req.Attachments) > 0 && w.attachments != nil {
+		sizes := make([]int64, len(req.Attachments))
+		for i, a := range req.Attachments {
+			sizes[i] = int64(len(a.Data))
+		}
+
+		if err := w.attachments.Check(ctx, feed.ID, sizes...); err != nil {
+			w.logger.Warn().
+				Err(err).
+				Str("feed_id", feed.ID).
+				Msg("webhook attachments exceed the feed limits")
+			return nil, err
+		}
+	}
+
+	if req.CloudEvents == nil {
+		messageID, err := w.processMessage(ctx, feed, req, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return &dtos.WebhookResponse{
+			Success:     true,
+			MessageID:   messageID,
+			FeedID:      feed.ID,
+			Aborted:     messageID == uuid.Nil,
+			RedirectURL: redirectURL,
+		}, nil
+	}
+
+	// Each CloudEvent of a batch is stored as its own message
+	resp := &dtos.WebhookResponse{
+		Success:    true,
+		FeedID:     feed.ID,
+		MessageIDs: []uuid.UUID{},
+	}
+
+	for i := range req.CloudEvents {
+		messageID, err := w.processMessage(ctx, feed, req, &req.CloudEvents[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if messageID != uuid.Nil {
+			resp.MessageIDs = append(resp.MessageIDs, messageID)
+		}
+	}
+
+	if len(resp.MessageIDs) > 0 {
+		resp.MessageID = resp.MessageIDs[0]
+	}
+	resp.Aborted = len(resp.MessageIDs) == 0 && len(req.CloudEvents) > 0
+
+	return resp, nil
+}
+
+// ProcessMessage runs a message that did not arrive as a webhook, such as an
+// MQTT message, through the middleware and adapters of the feed and saves it.
+// Signatures and form checks do not apply. The returned id is uuid.Nil when
+// middleware aborted the message.
+func (w *WebhookService) ProcessMessage(ctx context.Context, feedID string, req dtos.WebhookRequest) (uuid.UUID, error) {
+	if w.feedService == nil || w.feedService.GetCache() == nil {
+		return uuid.Nil, ErrFeedNotInit
+	}
+
+	ok, feed := w.feedService.GetCache().GetByID(feedID)
+	if !ok {
+		return uuid.Nil, fmt.Errorf("%w: %s", ErrFeedNotFound, feedID)
+	}
+
+	return w.processMessage(ctx, feed, req, nil)
+}
+
+// processMessage runs a request, or a CloudEvent of the request when event is
+// not nil, through the middleware and adapters and saves the message. The
+// returned id is uuid.Nil when middleware aborted the message.
+func (w *WebhookService) processMessage(
+	ctx context.Context,
+	feed feeds.FeedParsed,
+	req dtos.WebhookRequest,
+	event *dtos.CloudEvent,
+) (uuid.UUID, error) {
+	body, rawBody := req.Body, req.RawBody
+	var raw any = req.Body
+	if event != nil {
+		body = cloudEventBody(*event)
+		rawBody = event.Data
+		if event.Data != nil {
+			raw = event.Data
+		}
+	}
+
 	// Create the feed message using the constructor to ensure proper initialization
 	createMsg, err := dtos.NewFeedMessageCreateFromHTTP(
 		feed.ID,
-		req.Body,
+		raw,
 		req.Headers,
 		req.QueryParams,
 	)
@@ -72,12 +248,66 @@ func (w *WebhookService) ProcessWebhook(ctx context.Context, req dtos.WebhookReq
 		w.logger.Error().
 			Err(err).
 			Msg("failed to create feed message from HTTP request")
-		return nil, fmt.Errorf("failed to create feed message: %w", err)
+		return uuid.Nil, fmt.Errorf("failed to create feed message: %w", err)
 	}
 
 	// Set timestamp
 	createMsg.ReceivedAt = time.Now()
 
+	mctx := hookfeed.NewContext(body, req.Headers, req.QueryParams)
+	mctx.Payload.Body = string(rawBody)
+
+	mctx.Payload.Title = req.Title
+	maps.Copy(mctx.Payload.Metadata, req.Metadata)
+
+	// CloudEvents attributes are applied before middleware so they can be overridden
+	if event != nil {
+		if !event.Time.IsZero() {
+			createMsg.ReceivedAt = event.Time
+		}
+
+		mctx.Payload.Title = event.Type
+		if event.Subject != "" {
+			mctx.Payload.Title = fmt.Sprintf("%s (%s)", event.Type, event.Subject)
+		}
+		mctx.Payload.Metadata[adapters.CloudEventMetadataKey] = event.Attributes()
+	}
+
+	// Execute global and feed middleware
+	action := hookfeed.ActionContinue
+	if w.pipeline != nil {
+		action = w.pipeline.Run(ctx, feed.ID, feed.Middleware, mctx)
+		if action == hookfeed.ActionAbort {
+			w.logger.Info().
+				Str("feed_id", feed.ID).
+				Strs("logs", mctx.Payload.Logs).
+				Msg("webhook aborted by middleware")
+
+			return uuid.Nil, nil
+		}
+	}
+
+	// Apply adapters unless middleware bypassed them
+	var adapter adapters.Adapter
+	if action != hookfeed.ActionBypass {
+		adapter = w.applyAdapters(feed, &mctx.Payload)
+	}
+
+	err = applyPayload(&createMsg, mctx.Payload)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to apply middleware payload: %w", err)
+	}
+
+	// A resolving notification is stored resolved and closes its group below
+	resolver, ok := adapter.(adapters.Resolver)
+	resolves := ok && resolver.Resolved(mctx.Payload.Raw, mctx.Payload.Headers)
+	if resolves {
+		createMsg.State = string(dtos.MessageStateResolved)
+	}
+
+	processedAt := time.Now()
+	createMsg.ProcessedAt = &processedAt
+
 	// Save message to database
 	message, err := w.feedMessageService.Create(ctx, createMsg)
 	if err != nil {
@@ -85,7 +315,7 @@ func (w *WebhookService) ProcessWebhook(ctx context.Context, req dtos.WebhookReq
 			Err(err).
 			Str("feed_id", feed.ID).
 			Msg("failed to save message to database")
-		return nil, fmt.Errorf("failed to save message: %w", err)
+		return uuid.Nil, fmt.Errorf("failed to save message: %w", err)
 	}
 
 	w.logger.Info().
@@ -93,18 +323,175 @@ func (w *WebhookService) ProcessWebhook(ctx context.Context, req dtos.WebhookReq
 		Str("feed_id", feed.ID).
 		Msg("webhook processed and saved successfully")
 
+	if groupKey, _ := mctx.Payload.Metadata[adapters.GroupKeyMetadataKey].(string); resolves && groupKey != "" {
+		w.resolveGroup(ctx, feed.ID, groupKey)
+	}
+
+	if event == nil {
+		w.saveAttachments(ctx, feed.ID, message.ID, req.Attachments)
+	}
+
 	// TODO: In future iterations, we'll:
-	// - Execute global middleware
-	// - Execute feed middleware
-	// - Apply adapters
 	// - Broadcast via WebSocket
 	// - Enforce retention policies
 
-	return &dtos.WebhookResponse{
-		Success:   true,
-		MessageID: message.ID,
-		FeedID:    feed.ID,
-	}, nil
+	return message.ID, nil
+}
+
+// saveAttachments stores the files of a request with its message. The message
+// is already saved, so failures are logged and the message is kept.
+func (w *WebhookService) saveAttachments(ctx context.Context, feedID string, messageID uuid.UUID, uploads []dtos.AttachmentUpload) {
+	if w.attachments == nil {
+		return
+	}
+
+	for _, upload := range uploads {
+		a, err := w.attachments.Create(ctx, feedID, messageID, upload)
+		if err != nil {
+			w.logger.Error().
+				Err(err).
+				Str("feed_id", feedID).
+				Str("message_id", messageID.String()).
+				Str("name", upload.Name).
+				Msg("failed to save attachment")
+			continue
+		}
+
+		w.logger.Debug().
+			Str("attachment_id", a.ID.String()).
+			Str("message_id", messageID.String()).
+			Int64("size", a.Size).
+			Msg("attachment saved")
+	}
+}
+
+// firstHeaderValues returns the first value of each header
+func firstHeaderValues(headers map[string][]string) map[string]string {
+	result := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if len(v) > 0 {
+			result[k] = v[0]
+		}
+	}
+	return result
+}
+
+// cloudEventBody returns the data of an event as the body seen by middleware
+// and adapters. Data that is not a JSON object is wrapped under "data".
+func cloudEventBody(event dtos.CloudEvent) map[string]any {
+	body := map[string]any{}
+	if event.Data == nil {
+		return body
+	}
+
+	if err := json.Unmarshal(event.Data, &body); err == nil {
+		return body
+	}
+
+	var data any
+	_ = json.Unmarshal(event.Data, &data)
+	return map[string]any{"data": data}
+}
+
+// resolveGroup resolves the open messages of the group. Failures are logged as
+// the resolving message has already been saved.
+func (w *WebhookService) resolveGroup(ctx context.Context, feedID, groupKey string) {
+	resolved, err := w.feedMessageService.ResolveGroup(ctx, feedID, groupKey)
+	if err != nil {
+		w.logger.Error().
+			Err(err).
+			Str("feed_id", feedID).
+			Str("group_key", groupKey).
+			Msg("failed to resolve message group")
+		return
+	}
+
+	w.logger.Debug().
+		Str("feed_id", feedID).
+		Str("group_key", groupKey).
+		Int("resolved", len(resolved)).
+		Msg("resolved message group")
+}
+
+// applyAdapters selects an adapter for the payload and applies it, returning
+// the applied adapter or nil. The listed adapters of the feed are tried first,
+// then every adapter when auto-detection is enabled for the feed. Adapter
+// errors are added to the logs.
+func (w *WebhookService) applyAdapters(feed feeds.FeedParsed, payload *hookfeed.Payload) adapters.Adapter {
+	if w.adapters == nil || !feed.AdaptersEnabled {
+		return nil
+	}
+
+	var (
+		adapter adapters.Adapter
+		err     error
+	)
+	if len(feed.Adapters) > 0 {
+		adapter, err = w.adapters.Select(feed.Adapters, payload.Raw, payload.Headers)
+		if err != nil {
+			payload.Logs = append(payload.Logs, fmt.Sprintf("adapters: %v", err))
+		}
+	}
+
+	if adapter == nil && feed.AdaptersDetect {
+		// Detection only fails for references, which are not given here
+		adapter, _ = w.adapters.Select(nil, payload.Raw, payload.Headers)
+	}
+
+	if adapter == nil {
+		return nil
+	}
+
+	err = adapters.Apply(adapter, payload)
+	if err != nil {
+		payload.Logs = append(payload.Logs, fmt.Sprintf("adapter %s: %v", adapters.Ref(adapter), err))
+		return nil
+	}
+
+	w.logger.Debug().
+		Str("feed_id", feed.ID).
+		Str("adapter", adapters.Ref(adapter)).
+		Msg("applied adapter")
+
+	return adapter
+}
+
+// applyPayload copies the fields of a middleware payload into the message. The
+// title and message are kept when the payload leaves them empty.
+func applyPayload(msg *dtos.FeedMessageCreate, payload hookfeed.Payload) error {
+	metadata, err := json.Marshal(payload.Metadata)
+	if err != nil {
+		return fmt.Errorf("marshal metadata: %w", err)
+	}
+
+	if payload.Title != "" {
+		msg.Title = payload.Title
+	}
+	if payload.Message != "" {
+		msg.Message = payload.Message
+	}
+	msg.Priority = min(max(payload.Priority, 0), 5)
+	msg.Logs = payload.Logs
+	msg.Metadata = metadata
+
+	return nil
+}
+
+// MaxBodySize returns the largest request body accepted for a feed key, see
+// [FeedService.MaxBodySize]
+func (w *WebhookService) MaxBodySize(key string) int64 {
+	if w.feedService == nil || w.feedService.GetCache() == nil {
+		return feeds.BodyAllowance
+	}
+
+	return w.feedService.MaxBodySize(key)
+}
+
+// recordSignatureFailure counts a request of the feed rejected by verification
+func (w *WebhookService) recordSignatureFailure(feedID string, err error) {
+	if w.signatures != nil {
+		w.signatures.Record(feedID, err)
+	}
 }
 
 // findFeedBySlug looks up a feed by its key
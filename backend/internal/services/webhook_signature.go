@@ -0,0 +1,111 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+)
+
+// VerifySignature checks a request against the signature configuration of a
+// feed. The signature header may hold several signatures separated by spaces
+// or commas, as sent during secret rotation; any match is accepted. Failures
+// wrap ErrInvalidSignature.
+func VerifySignature(sig feeds.SignatureParsed, body []byte, headers http.Header, now time.Time) error {
+	if err := sig.Validate(); err != nil {
+		return fmt.Errorf("invalid signature configuration: %w", err)
+	}
+
+	signatures := headerParts(headers.Get(sig.Header), sig.Prefix)
+	if len(signatures) == 0 {
+		return fmt.Errorf("%w: missing %s header", ErrInvalidSignature, sig.Header)
+	}
+
+	var timestamp string
+	if sig.TimestampHeader != "" {
+		parts := headerParts(headers.Get(sig.TimestampHeader), sig.TimestampPrefix)
+		if len(parts) == 0 {
+			return fmt.Errorf("%w: missing %s header", ErrInvalidSignature, sig.TimestampHeader)
+		}
+		timestamp = parts[0]
+
+		secs, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: malformed timestamp %q", ErrInvalidSignature, timestamp)
+		}
+
+		if age := now.Sub(time.Unix(secs, 0)).Abs(); age > sig.Tolerance {
+			return fmt.Errorf("%w: timestamp outside of the %s tolerance", ErrInvalidSignature, sig.Tolerance)
+		}
+	}
+
+	payload := strings.NewReplacer("{timestamp}", timestamp, "{body}", string(body)).Replace(sig.Payload)
+
+	mac := hmac.New(signatureHash(sig.Algorithm), []byte(sig.Secret))
+	mac.Write([]byte(payload))
+	expected := mac.Sum(nil)
+
+	for _, s := range signatures {
+		var (
+			got []byte
+			err error
+		)
+
+		switch sig.Encoding {
+		case "base64":
+			got, err = base64.StdEncoding.DecodeString(s)
+		default:
+			got, err = hex.DecodeString(s)
+		}
+
+		if err == nil && hmac.Equal(got, expected) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// signatureHash returns the hash constructor of a validated algorithm
+func signatureHash(algorithm string) func() hash.Hash {
+	switch algorithm {
+	case "sha1":
+		return sha1.New
+	case "sha512":
+		return sha512.New
+	default:
+		return sha256.New
+	}
+}
+
+// headerParts returns the values in a header that start with prefix, with the
+// prefix removed. Values are separated by spaces, and also by commas unless the
+// prefix contains one, to support headers such as "t=1492774577,v1=5257a869...".
+func headerParts(value, prefix string) []string {
+	var parts []string
+
+	for _, field := range strings.Fields(value) {
+		candidates := []string{field}
+		if !strings.Contains(prefix, ",") {
+			candidates = strings.Split(field, ",")
+		}
+
+		for _, c := range candidates {
+			if s, ok := strings.CutPrefix(c, prefix); ok && s != "" {
+				parts = append(parts, s)
+			}
+		}
+	}
+
+	return parts
+}
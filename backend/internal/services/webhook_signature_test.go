@@ -0,0 +1,211 @@
+package services_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/testlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(h func() hash.Hash, secret, payload string) []byte {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func Test_VerifySignature(t *testing.T) {
+	const (
+		secret = "whsec_test"
+		body   = `{"id":"evt_1","type":"order.created"}`
+	)
+
+	now := time.Unix(1700000000, 0)
+	ts := "1700000000"
+
+	tests := []struct {
+		name    string
+		sig     feeds.Signature
+		headers http.Header
+		wantErr bool
+	}{
+		{
+			name: "hex sha256 with prefix",
+			sig:  feeds.Signature{Header: "X-Hub-Signature-256", Prefix: "sha256=", Secret: secret},
+			headers: http.Header{
+				"X-Hub-Signature-256": {"sha256=" + hex.EncodeToString(sign(sha256.New, secret, body))},
+			},
+		},
+		{
+			name: "base64 sha256 (Shopify)",
+			sig:  feeds.Signature{Header: "X-Shopify-Hmac-Sha256", Encoding: "base64", Secret: secret},
+			headers: http.Header{
+				"X-Shopify-Hmac-Sha256": {base64.StdEncoding.EncodeToString(sign(sha256.New, secret, body))},
+			},
+		},
+		{
+			name: "base64 sha1",
+			sig:  feeds.Signature{Header: "X-Signature", Algorithm: "SHA1", Encoding: "base64", Secret: secret},
+			headers: http.Header{
+				"X-Signature": {base64.StdEncoding.EncodeToString(sign(sha1.New, secret, body))},
+			},
+		},
+		{
+			name: "hex sha512",
+			sig:  feeds.Signature{Header: "X-Signature", Algorithm: "sha512", Secret: secret},
+			headers: http.Header{
+				"X-Signature": {hex.EncodeToString(sign(sha512.New, secret, body))},
+			},
+		},
+		{
+			name: "timestamp in the signature header (Stripe)",
+			sig: feeds.Signature{
+				Header:          "Stripe-Signature",
+				Prefix:          "v1=",
+				TimestampHeader: "Stripe-Signature",
+				TimestampPrefix: "t=",
+				Payload:         "{timestamp}.{body}",
+				Secret:          secret,
+			},
+			headers: http.Header{
+				"Stripe-Signature": {"t=" + ts + ",v1=" + hex.EncodeToString(sign(sha256.New, secret, ts+"."+body)) + ",v0=deadbeef"},
+			},
+		},
+		{
+			name: "timestamp header (Slack)",
+			sig: feeds.Signature{
+				Header:          "X-Slack-Signature",
+				Prefix:          "v0=",
+				TimestampHeader: "X-Slack-Request-Timestamp",
+				Payload:         "v0:{timestamp}:{body}",
+				Secret:          secret,
+			},
+			headers: http.Header{
+				"X-Slack-Signature":         {"v0=" + hex.EncodeToString(sign(sha256.New, secret, "v0:"+ts+":"+body))},
+				"X-Slack-Request-Timestamp": {ts},
+			},
+		},
+		{
+			name: "any of several signatures",
+			sig:  feeds.Signature{Header: "Webhook-Signature", Prefix: "v1,", Encoding: "base64", Secret: secret},
+			headers: http.Header{
+				"Webhook-Signature": {"v1,b2xk v1," + base64.StdEncoding.EncodeToString(sign(sha256.New, secret, body))},
+			},
+		},
+		{
+			name: "mismatch",
+			sig:  feeds.Signature{Header: "X-Signature", Secret: secret},
+			headers: http.Header{
+				"X-Signature": {hex.EncodeToString(sign(sha256.New, "other", body))},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "missing header",
+			sig:     feeds.Signature{Header: "X-Signature", Secret: secret},
+			headers: http.Header{},
+			wantErr: true,
+		},
+		{
+			name: "prefix not present",
+			sig:  feeds.Signature{Header: "X-Signature", Prefix: "sha256=", Secret: secret},
+			headers: http.Header{
+				"X-Signature": {hex.EncodeToString(sign(sha256.New, secret, body))},
+			},
+			wantErr: true,
+		},
+		{
+			name: "timestamp outside tolerance",
+			sig: feeds.Signature{
+				Header:          "X-Signature",
+				TimestampHeader: "X-Timestamp",
+				Payload:         "{timestamp}.{body}",
+				Secret:          secret,
+			},
+			headers: http.Header{
+				"X-Signature": {hex.EncodeToString(sign(sha256.New, secret, "1699999000."+body))},
+				"X-Timestamp": {"1699999000"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing timestamp",
+			sig: feeds.Signature{
+				Header:          "X-Signature",
+				TimestampHeader: "X-Timestamp",
+				Secret:          secret,
+			},
+			headers: http.Header{
+				"X-Signature": {hex.EncodeToString(sign(sha256.New, secret, body))},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := services.VerifySignature(tt.sig.IntoParsed(), []byte(body), tt.headers, now)
+			if tt.wantErr {
+				require.ErrorIs(t, err, services.ErrInvalidSignature)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_VerifySignature_InvalidConfig(t *testing.T) {
+	sig := feeds.Signature{Header: "X-Signature", Algorithm: "md5", Secret: "s"}.IntoParsed()
+
+	err := services.VerifySignature(sig, []byte("{}"), http.Header{"X-Signature": {"00"}}, time.Now())
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, services.ErrInvalidSignature)
+}
+
+func Test_WebhookService_SignatureFailures(t *testing.T) {
+	feedService := services.NewFeedService(feeds.NewCache(&feeds.Config{
+		Feeds: []feeds.Feed{
+			{ID: "signed", Keys: []string{"signed-key"}, Signature: &feeds.Signature{Header: "X-Signature", Secret: "secret"}},
+			{ID: "open", Keys: []string{"open-key"}},
+		},
+	}))
+
+	signatures := services.NewSignatureFailureService(feedService)
+	webhooks := services.NewWebhookService(testlib.Logger(t), feedService, nil, nil, signatures, nil, nil)
+
+	for range 2 {
+		_, err := webhooks.ProcessWebhook(context.Background(), dtos.WebhookRequest{
+			FeedKey: "signed-key",
+			Headers: http.Header{"X-Signature": {"00"}},
+			RawBody: []byte(`{}`),
+		})
+		require.ErrorIs(t, err, services.ErrInvalidSignature)
+	}
+
+	stats := signatures.Stats()
+	require.Len(t, stats, 2)
+
+	assert.Equal(t, "signed", stats[0].FeedID)
+	assert.True(t, stats[0].Verified)
+	assert.Equal(t, int64(2), stats[0].Rejected)
+	assert.NotNil(t, stats[0].LastRejectedAt)
+	assert.Equal(t, services.ErrInvalidSignature.Error(), stats[0].LastError)
+
+	assert.Equal(t, "open", stats[1].FeedID)
+	assert.False(t, stats[1].Verified)
+	assert.Zero(t, stats[1].Rejected)
+	assert.Nil(t, stats[1].LastRejectedAt)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/hay-kot/hookfeed/backend/internal/core/blobstore"
 	"github.com/hay-kot/hookfeed/backend/internal/core/validate"
 	"github.com/hay-kot/hookfeed/backend/internal/services"
 	"github.com/hay-kot/httpkit/server"
@@ -55,11 +56,24 @@ func ErrorHandler(log zerolog.Logger) ErrorAdapter {
 
 			var respInvalidRouteKeyErr *validate.InvalidRouteKeyError
 			var respFieldErrorsErr validate.FieldErrors
+			var respMaxBytesErr *http.MaxBytesError
 
 			switch {
-			case errors.Is(err, pgx.ErrNoRows):
+			case errors.Is(err, pgx.ErrNoRows), errors.Is(err, blobstore.ErrNotFound):
 				bldr.Status(http.StatusNotFound).
 					Msg("resource not found")
+			case errors.Is(err, services.ErrInvalidSignature):
+				bldr.Status(http.StatusUnauthorized).
+					Msg("invalid signature")
+			case errors.Is(err, services.ErrCaptchaFailed):
+				bldr.Status(http.StatusForbidden).
+					Msg("captcha verification failed")
+			case errors.Is(err, services.ErrAttachmentTooLarge), errors.Is(err, services.ErrAttachmentQuota):
+				bldr.Status(http.StatusRequestEntityTooLarge).
+					Msg(err.Error())
+			case errors.As(err, &respMaxBytesErr):
+				bldr.Status(http.StatusRequestEntityTooLarge).
+					Msg("request body too large")
 			case errors.Is(err, services.ErrNotAdmin):
 				bldr.Status(http.StatusForbidden).
 					Msg("forbidden")
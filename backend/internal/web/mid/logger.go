@@ -1,6 +1,9 @@
 package mid
 
 import (
+	"bufio"
+	"errors"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
@@ -30,6 +33,23 @@ func (s *spy) WriteHeader(status int) {
 	s.ResponseWriter.WriteHeader(status)
 }
 
+// Unwrap allows http.ResponseController to flush and set deadlines on the
+// underlying writer, which streaming endpoints rely on
+func (s *spy) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Hijack implements http.Hijacker for WebSocket upgrades
+func (s *spy) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not implement http.Hijacker")
+	}
+
+	s.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
 const frontendVersionHeader = "X-Frontend-Version"
 
 func extractFrontendVersionHeader(r *http.Request) string {
@@ -0,0 +1,116 @@
+package mid
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseTrustedProxies parses a list of proxy addresses and CIDR ranges, a
+// single address is a range of one address
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		if strings.Contains(v, "/") {
+			prefix, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return prefixes, nil
+}
+
+// RealIP sets the RemoteAddr of requests sent by a trusted proxy to the client
+// address in its True-Client-IP, X-Real-IP or X-Forwarded-For header. Headers of
+// requests from any other peer are ignored, they are chosen by the client and
+// would let it pick the address that rate limits and captchas see.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(trusted) > 0 && isTrusted(trusted, peerAddr(r.RemoteAddr)) {
+				if ip := forwardedIP(trusted, r.Header); ip != "" {
+					r.RemoteAddr = ip
+				}
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedIP returns the client address set by trusted proxies. In
+// X-Forwarded-For every proxy appends the address it received the request from,
+// so the client is the last address that is not a trusted proxy.
+func forwardedIP(trusted []netip.Prefix, header http.Header) string {
+	for _, name := range []string{"True-Client-IP", "X-Real-IP"} {
+		if addr, err := netip.ParseAddr(strings.TrimSpace(header.Get(name))); err == nil {
+			return addr.Unmap().String()
+		}
+	}
+
+	hops := strings.Split(strings.Join(header.Values("X-Forwarded-For"), ","), ",")
+
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// Addresses before an invalid hop cannot be attributed to a proxy
+			break
+		}
+
+		client = addr.Unmap()
+		if !isTrusted(trusted, client) {
+			break
+		}
+	}
+
+	if !client.IsValid() {
+		return ""
+	}
+	return client.String()
+}
+
+// peerAddr returns the address of the connection of a request
+func peerAddr(remoteAddr string) netip.Addr {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+func isTrusted(trusted []netip.Prefix, addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
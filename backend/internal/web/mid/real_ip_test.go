@@ -0,0 +1,79 @@
+package mid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RealIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.0.2.1 ", ""})
+	require.NoError(t, err)
+	require.Len(t, trusted, 2)
+
+	tests := []struct {
+		name    string
+		peer    string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:    "untrusted peer",
+			peer:    "203.0.113.9:4000",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.1"},
+			want:    "203.0.113.9:4000",
+		},
+		{
+			name:    "trusted real ip",
+			peer:    "192.0.2.1:4000",
+			headers: map[string]string{"X-Real-IP": "198.51.100.1"},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "forwarded for skips trusted hops",
+			peer:    "10.0.0.2:4000",
+			headers: map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.1, 10.0.0.3"},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "forwarded for stops at invalid hop",
+			peer:    "10.0.0.2:4000",
+			headers: map[string]string{"X-Forwarded-For": "1.1.1.1, garbage, 10.0.0.3"},
+			want:    "10.0.0.3",
+		},
+		{
+			name: "trusted peer without headers",
+			peer: "10.0.0.2:4000",
+			want: "10.0.0.2:4000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/hooks/key", nil)
+			r.RemoteAddr = tt.peer
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ParseTrustedProxies_Invalid(t *testing.T) {
+	_, err := ParseTrustedProxies([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+
+	_, err = ParseTrustedProxies([]string{"proxy.local"})
+	require.Error(t, err)
+}
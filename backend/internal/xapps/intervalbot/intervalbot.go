@@ -2,24 +2,72 @@ package intervalbot
 
 import (
 	"context"
+	"time"
 
+	"github.com/hay-kot/hookfeed/backend/internal/services"
 	"github.com/rs/zerolog"
 )
 
+const (
+	// KVPurgeInterval is how often expired middleware key/value entries are deleted
+	KVPurgeInterval = 15 * time.Minute
+	// AttachmentPurgeInterval is how often the files of deleted messages are removed
+	AttachmentPurgeInterval = 5 * time.Minute
+)
+
 type IntervalBot struct {
-	l zerolog.Logger
+	l    zerolog.Logger
+	svcs *services.Service
 }
 
-func New(l zerolog.Logger) *IntervalBot {
+func New(l zerolog.Logger, svcs *services.Service) *IntervalBot {
 	return &IntervalBot{
-		l: l.With().Str("service", "interval_bot").Logger(),
+		l:    l.With().Str("service", "interval_bot").Logger(),
+		svcs: svcs,
 	}
 }
 
 func (ib *IntervalBot) Start(ctx context.Context) error {
 	ib.l.Info().Msg("starting service")
-	<-ctx.Done()
-	ib.l.Info().Msg("stopping service")
 
-	return nil
+	ticker := time.NewTicker(KVPurgeInterval)
+	defer ticker.Stop()
+
+	attachmentTicker := time.NewTicker(AttachmentPurgeInterval)
+	defer attachmentTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			ib.l.Info().Msg("stopping service")
+			return nil
+		case <-ticker.C:
+			ib.purgeKV(ctx)
+		case <-attachmentTicker.C:
+			ib.purgeAttachments(ctx)
+		}
+	}
+}
+
+func (ib *IntervalBot) purgeKV(ctx context.Context) {
+	n, err := ib.svcs.FeedKV.DeleteExpired(ctx)
+	if err != nil {
+		ib.l.Error().Err(err).Msg("failed to delete expired kv entries")
+		return
+	}
+
+	if n > 0 {
+		ib.l.Debug().Int64("deleted", n).Msg("deleted expired kv entries")
+	}
+}
+
+func (ib *IntervalBot) purgeAttachments(ctx context.Context) {
+	n, err := ib.svcs.Attachments.DeleteUnlinked(ctx)
+	if err != nil {
+		ib.l.Error().Err(err).Msg("failed to delete attachments of deleted messages")
+	}
+
+	if n > 0 {
+		ib.l.Debug().Int64("deleted", n).Msg("deleted attachments of deleted messages")
+	}
 }
@@ -0,0 +1,152 @@
+// Package mqttsub provides an MQTT client that subscribes to the topics in the
+// feed file and saves published payloads as feed messages.
+package mqttsub
+
+import (
+	"context"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services/adapters"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultClientID  = "hookfeed"
+	subscribeTimeout = 10 * time.Second
+)
+
+// ProcessFunc saves a message for a feed, see [services.WebhookService.ProcessMessage]
+type ProcessFunc func(ctx context.Context, feedID string, req dtos.WebhookRequest) (uuid.UUID, error)
+
+type Subscriber struct {
+	l       zerolog.Logger
+	cfg     feeds.MQTT
+	process ProcessFunc
+}
+
+func New(l zerolog.Logger, cfg feeds.MQTT, process ProcessFunc) *Subscriber {
+	return &Subscriber{
+		l:       l.With().Str("service", "mqtt_subscriber").Logger(),
+		cfg:     cfg,
+		process: process,
+	}
+}
+
+// Start connects to the broker and subscribes to the topics until ctx is done.
+// The client reconnects and resubscribes when the connection is lost.
+func (s *Subscriber) Start(ctx context.Context) error {
+	clientID := s.cfg.ClientID
+	if clientID == "" {
+		clientID = defaultClientID
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(s.cfg.Broker).
+		SetClientID(clientID).
+		SetUsername(s.cfg.Username).
+		SetPassword(s.cfg.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetDefaultPublishHandler(s.handler(ctx)).
+		SetOnConnectHandler(func(c mqtt.Client) {
+			s.l.Info().Str("broker", s.cfg.Broker).Msg("connected to broker")
+			s.subscribe(ctx, c)
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			s.l.Warn().Err(err).Str("broker", s.cfg.Broker).Msg("lost connection to broker")
+		})
+
+	s.l.Info().Str("broker", s.cfg.Broker).Int("subscriptions", len(s.cfg.Subscriptions)).Msg("starting service")
+
+	client := mqtt.NewClient(opts)
+	client.Connect()
+
+	<-ctx.Done()
+	s.l.Info().Msg("stopping service")
+	client.Disconnect(250)
+
+	return nil
+}
+
+// subscribe subscribes to all topic filters, it runs on every (re)connect
+func (s *Subscriber) subscribe(ctx context.Context, c mqtt.Client) {
+	filters := make(map[string]byte, len(s.cfg.Subscriptions))
+	for _, sub := range s.cfg.Subscriptions {
+		if err := sub.Validate(); err != nil {
+			s.l.Error().Err(err).Str("feed_id", sub.Feed).Msg("skipping invalid subscription")
+			continue
+		}
+
+		// Brokers keep one subscription per filter, the highest QoS wins
+		filters[sub.Topic] = max(filters[sub.Topic], sub.QoS)
+	}
+
+	if len(filters) == 0 {
+		s.l.Warn().Msg("no valid subscriptions")
+		return
+	}
+
+	// Without a callback every message goes to the default handler once, instead
+	// of once for each matching filter
+	token := c.SubscribeMultiple(filters, nil)
+	if !token.WaitTimeout(subscribeTimeout) {
+		s.l.Error().Msg("timed out subscribing to topics")
+		return
+	}
+
+	if err := token.Error(); err != nil {
+		s.l.Error().Err(err).Msg("failed to subscribe to topics")
+		return
+	}
+
+	s.l.Info().Int("topics", len(filters)).Msg("subscribed to topics")
+}
+
+// handler saves a published message to the feed of the first matching
+// subscription. Retained messages are skipped, they were already saved when
+// published and are resent by the broker on every subscribe.
+func (s *Subscriber) handler(ctx context.Context) mqtt.MessageHandler {
+	return func(_ mqtt.Client, m mqtt.Message) {
+		if m.Retained() {
+			s.l.Debug().Str("topic", m.Topic()).Msg("skipping retained message")
+			return
+		}
+
+		feedID, ok := s.cfg.Match(m.Topic())
+		if !ok {
+			s.l.Debug().Str("topic", m.Topic()).Msg("no feed for topic")
+			return
+		}
+
+		body, raw, err := adapters.DecodeBody(m.Payload())
+		if err != nil {
+			s.l.Error().Err(err).Str("topic", m.Topic()).Msg("failed to decode payload")
+			return
+		}
+
+		messageID, err := s.process(ctx, feedID, dtos.WebhookRequest{
+			Body:    body,
+			RawBody: raw,
+			Title:   m.Topic(),
+			Metadata: map[string]any{
+				"mqttTopic": m.Topic(),
+				"mqttQos":   m.Qos(),
+			},
+		})
+		if err != nil {
+			s.l.Error().Err(err).Str("topic", m.Topic()).Str("feed_id", feedID).Msg("failed to save mqtt message")
+			return
+		}
+
+		s.l.Debug().
+			Str("topic", m.Topic()).
+			Str("feed_id", feedID).
+			Str("message_id", messageID.String()).
+			Msg("saved mqtt message")
+	}
+}
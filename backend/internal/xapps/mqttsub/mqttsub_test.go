@@ -0,0 +1,191 @@
+package mqttsub
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type processed struct {
+	feedID string
+	req    dtos.WebhookRequest
+}
+
+// recorder collects the messages passed to the process func
+type recorder struct {
+	mu   sync.Mutex
+	msgs []processed
+}
+
+func (r *recorder) process(_ context.Context, feedID string, req dtos.WebhookRequest) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, processed{feedID: feedID, req: req})
+	return uuid.New(), nil
+}
+
+func (r *recorder) messages() []processed {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]processed(nil), r.msgs...)
+}
+
+// startBroker starts an in-process broker on a free port that only accepts the
+// given credentials
+func startBroker(t *testing.T, username, password string) (*mochi.Server, string) {
+	t.Helper()
+
+	server := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.DiscardHandler),
+	})
+	err := server.AddHook(new(auth.Hook), &auth.Options{
+		Ledger: &auth.Ledger{
+			Auth: auth.AuthRules{
+				{Username: auth.RString(username), Password: auth.RString(password), Allow: true},
+				{Client: "inline", Allow: true},
+			},
+			ACL: auth.ACLRules{{}},
+		},
+	})
+	require.NoError(t, err)
+
+	tcp := listeners.NewTCP(listeners.Config{ID: "test", Address: "127.0.0.1:0"})
+	require.NoError(t, server.AddListener(tcp))
+	require.NoError(t, server.Serve())
+
+	t.Cleanup(func() { _ = server.Close() })
+
+	return server, "tcp://" + tcp.Address()
+}
+
+func Test_Subscriber(t *testing.T) {
+	broker, url := startBroker(t, "hookfeed", "secret")
+
+	// Retained messages are resent on subscribe and skipped
+	require.NoError(t, broker.Publish("home/garage/alerts", []byte("retained"), true, 0))
+
+	rec := &recorder{}
+	sub := New(zerolog.Nop(), feeds.MQTT{
+		Broker:   url,
+		Username: "hookfeed",
+		Password: "secret",
+		Subscriptions: []feeds.MQTTSubscription{
+			{Topic: "home/garage/#", Feed: "garage", QoS: 1},
+			{Topic: "home/+/alerts", Feed: "alerts"},
+		},
+	}, rec.process)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- sub.Start(ctx) }()
+
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	require.Eventually(t, func() bool {
+		subs := broker.Topics.Subscribers("home/kitchen/alerts")
+		return len(subs.Subscriptions) > 0
+	}, 5*time.Second, 10*time.Millisecond, "subscriber did not subscribe")
+
+	require.NoError(t, broker.Publish("home/kitchen/alerts", []byte(`{"title":"Smoke detected","priority":5}`), false, 0))
+	require.NoError(t, broker.Publish("home/garage/door", []byte("open"), false, 1))
+	require.NoError(t, broker.Publish("office/printer", []byte("jammed"), false, 0))
+
+	require.Eventually(t, func() bool {
+		return len(rec.messages()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	msgs := rec.messages()
+
+	assert.Equal(t, "alerts", msgs[0].feedID)
+	assert.Equal(t, map[string]any{"title": "Smoke detected", "priority": float64(5)}, msgs[0].req.Body)
+	assert.Equal(t, "home/kitchen/alerts", msgs[0].req.Title)
+	assert.Equal(t, "home/kitchen/alerts", msgs[0].req.Metadata["mqttTopic"])
+
+	assert.Equal(t, "garage", msgs[1].feedID)
+	assert.Equal(t, map[string]any{"$body": "open"}, msgs[1].req.Body)
+	assert.JSONEq(t, `{"$body":"open"}`, string(msgs[1].req.RawBody))
+
+	// Overlapping filters deliver a message once, to the first listed feed
+	require.NoError(t, broker.Publish("home/garage/alerts", []byte("carbon monoxide"), false, 0))
+
+	require.Eventually(t, func() bool {
+		return len(rec.messages()) >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	msgs = rec.messages()
+	require.Len(t, msgs, 3)
+	assert.Equal(t, "garage", msgs[2].feedID)
+	assert.Equal(t, map[string]any{"$body": "carbon monoxide"}, msgs[2].req.Body)
+}
+
+func Test_Subscriber_RejectedCredentials(t *testing.T) {
+	broker, url := startBroker(t, "hookfeed", "secret")
+
+	sub := New(zerolog.Nop(), feeds.MQTT{
+		Broker:        url,
+		Username:      "hookfeed",
+		Password:      "wrong",
+		Subscriptions: []feeds.MQTTSubscription{{Topic: "#", Feed: "all"}},
+	}, (&recorder{}).process)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, sub.Start(ctx))
+	assert.Empty(t, broker.Topics.Subscribers("home").Subscriptions)
+}
+
+func Test_TopicMatch(t *testing.T) {
+	tests := []struct {
+		filter string
+		topic  string
+		want   bool
+	}{
+		{"home/kitchen/alerts", "home/kitchen/alerts", true},
+		{"home/kitchen/alerts", "home/kitchen", false},
+		{"home/+/alerts", "home/garage/alerts", true},
+		{"home/+/alerts", "home/garage/door/alerts", false},
+		{"home/#", "home", true},
+		{"home/#", "home/garage/door", true},
+		{"home/#", "office/printer", false},
+		{"#", "home/garage", true},
+		{"#", "$SYS/broker/uptime", false},
+		{"+/broker/uptime", "$SYS/broker/uptime", false},
+		{"$SYS/#", "$SYS/broker/uptime", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, feeds.TopicMatch(tt.filter, tt.topic), "%s ~ %s", tt.filter, tt.topic)
+	}
+}
+
+func Test_MQTTSubscription_Validate(t *testing.T) {
+	valid := []string{"#", "home/+/alerts", "home/#", "+"}
+	for _, topic := range valid {
+		assert.NoError(t, feeds.MQTTSubscription{Topic: topic}.Validate(), topic)
+	}
+
+	invalid := []string{"", "home/#/alerts", "home/gar+age", "home#"}
+	for _, topic := range invalid {
+		assert.Error(t, feeds.MQTTSubscription{Topic: topic}.Validate(), topic)
+	}
+
+	assert.Error(t, feeds.MQTTSubscription{Topic: "home", QoS: 3}.Validate())
+}
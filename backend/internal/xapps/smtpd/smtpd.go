@@ -0,0 +1,219 @@
+// Package smtpd provides an SMTP server that turns inbound email into feed
+// messages, for devices that can only send alerts by email.
+package smtpd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-smtp"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/services/adapters"
+	"github.com/rs/zerolog"
+)
+
+type Config struct {
+	Enabled         bool          `toml:"enabled"           env:"SMTP_ENABLED"           envDefault:"false"`
+	Host            string        `toml:"host"              env:"SMTP_HOST"              envDefault:"0.0.0.0"`
+	Port            string        `toml:"port"              env:"SMTP_PORT"              envDefault:"2525"`
+	Domain          string        `toml:"domain"            env:"SMTP_DOMAIN"            envDefault:"hookfeed.local"` // mail is accepted for <feed-key>@<domain>
+	MaxMessageBytes int64         `toml:"max_message_bytes" env:"SMTP_MAX_MESSAGE_BYTES" envDefault:"10485760"`
+	MaxRecipients   int           `toml:"max_recipients"    env:"SMTP_MAX_RECIPIENTS"    envDefault:"50"`
+	ReadTimeout     time.Duration `toml:"read_timeout"      env:"SMTP_READ_TIMEOUT"      envDefault:"30s"`
+	WriteTimeout    time.Duration `toml:"write_timeout"     env:"SMTP_WRITE_TIMEOUT"     envDefault:"30s"`
+}
+
+func (cfg Config) Addr() string {
+	return cfg.Host + ":" + cfg.Port
+}
+
+var (
+	errUnknownRecipient = &smtp.SMTPError{
+		Code:         550,
+		EnhancedCode: smtp.EnhancedCode{5, 1, 1},
+		Message:      "no feed for this address",
+	}
+	errRelayDenied = &smtp.SMTPError{
+		Code:         550,
+		EnhancedCode: smtp.EnhancedCode{5, 7, 1},
+		Message:      "relaying denied",
+	}
+	errInvalidMessage = &smtp.SMTPError{
+		Code:         554,
+		EnhancedCode: smtp.EnhancedCode{5, 6, 0},
+		Message:      "message could not be parsed",
+	}
+	errNotSaved = &smtp.SMTPError{
+		Code:         451,
+		EnhancedCode: smtp.EnhancedCode{4, 3, 0},
+		Message:      "message could not be saved, try again later",
+	}
+)
+
+type SMTPServer struct {
+	l    zerolog.Logger
+	cfg  Config
+	svcs *services.Service
+}
+
+func New(l zerolog.Logger, cfg Config, svcs *services.Service) *SMTPServer {
+	return &SMTPServer{
+		l:    l.With().Str("service", "smtp_server").Logger(),
+		cfg:  cfg,
+		svcs: svcs,
+	}
+}
+
+func (s *SMTPServer) Start(ctx context.Context) error {
+	if s.svcs.Feeds == nil {
+		return errors.New("smtp server requires a feed file")
+	}
+
+	server := s.newServer(ctx)
+
+	go func() {
+		<-ctx.Done()
+		s.l.Info().Msg("stopping service")
+		_ = server.Shutdown(context.Background())
+	}()
+
+	s.l.Info().Str("addr", s.cfg.Addr()).Str("domain", s.cfg.Domain).Msg("starting service")
+	err := server.ListenAndServe()
+	if errors.Is(err, smtp.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// newServer returns the SMTP server of the config, sessions are bound to ctx
+func (s *SMTPServer) newServer(ctx context.Context) *smtp.Server {
+	server := smtp.NewServer(&backend{ctx: ctx, s: s})
+	server.Addr = s.cfg.Addr()
+	server.Domain = s.cfg.Domain
+	server.MaxMessageBytes = s.cfg.MaxMessageBytes
+	server.MaxRecipients = s.cfg.MaxRecipients
+	server.ReadTimeout = s.cfg.ReadTimeout
+	server.WriteTimeout = s.cfg.WriteTimeout
+	server.ErrorLog = logger{s.l}
+	return server
+}
+
+// feedForAddress resolves a recipient address of the form <feed-key>@<domain>
+// to its feed
+func (s *SMTPServer) feedForAddress(address string) (dtos.Feed, error) {
+	local, domain, ok := strings.Cut(strings.Trim(address, "<>"), "@")
+	if !ok || !strings.EqualFold(domain, s.cfg.Domain) {
+		return dtos.Feed{}, errRelayDenied
+	}
+
+	feed, ok := s.svcs.Feeds.GetByKey(local)
+	if !ok {
+		return dtos.Feed{}, errUnknownRecipient
+	}
+
+	return feed, nil
+}
+
+// deliver saves an email as a message in each of the feeds. The messages are
+// saved in one transaction so a retry after a failure does not duplicate the
+// messages of feeds that were already saved.
+func (s *SMTPServer) deliver(ctx context.Context, from string, feeds []dtos.Feed, data []byte) error {
+	createDTOs := make([]dtos.FeedMessageCreate, 0, len(feeds))
+	for _, feed := range feeds {
+		createDTO, err := adapters.ParseEmail(bytes.NewReader(data), feed.ID)
+		if err != nil {
+			s.l.Warn().Err(err).Str("feed_id", feed.ID).Str("from", from).Msg("failed to parse email")
+			return errInvalidMessage
+		}
+
+		createDTOs = append(createDTOs, createDTO)
+	}
+
+	feedMessages, err := s.svcs.FeedMessages.CreateAll(ctx, createDTOs)
+	if err != nil {
+		s.l.Error().Err(err).Int("feeds", len(feeds)).Msg("failed to create feed messages from email")
+		return errNotSaved
+	}
+
+	for _, feedMessage := range feedMessages {
+		s.l.Info().
+			Str("message_id", feedMessage.ID.String()).
+			Str("feed_slug", feedMessage.FeedSlug).
+			Str("from", from).
+			Msg("email saved successfully")
+	}
+
+	return nil
+}
+
+type backend struct {
+	ctx context.Context
+	s   *SMTPServer
+}
+
+func (b *backend) NewSession(_ *smtp.Conn) (smtp.Session, error) {
+	return &session{ctx: b.ctx, s: b.s}, nil
+}
+
+// session is a single SMTP transaction. Recipients are resolved to feeds as
+// they are added so unknown addresses are rejected before the data is sent.
+type session struct {
+	ctx   context.Context
+	s     *SMTPServer
+	from  string
+	feeds []dtos.Feed
+}
+
+func (se *session) Mail(from string, _ *smtp.MailOptions) error {
+	se.from = from
+	return nil
+}
+
+func (se *session) Rcpt(to string, _ *smtp.RcptOptions) error {
+	feed, err := se.s.feedForAddress(to)
+	if err != nil {
+		se.s.l.Debug().Str("to", to).Str("from", se.from).Msg("rejected recipient")
+		return err
+	}
+
+	// Addresses may be repeated or map several keys to the same feed
+	for _, f := range se.feeds {
+		if f.ID == feed.ID {
+			return nil
+		}
+	}
+
+	se.feeds = append(se.feeds, feed)
+	return nil
+}
+
+func (se *session) Data(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read message: %w", err)
+	}
+
+	return se.s.deliver(se.ctx, se.from, se.feeds, data)
+}
+
+func (se *session) Reset() {
+	se.from = ""
+	se.feeds = nil
+}
+
+func (se *session) Logout() error {
+	return nil
+}
+
+// logger writes the errors of the SMTP server to zerolog
+type logger struct{ l zerolog.Logger }
+
+func (l logger) Printf(format string, v ...any) { l.l.Error().Msgf(format, v...) }
+func (l logger) Println(v ...any)               { l.l.Error().Msg(fmt.Sprint(v...)) }
@@ -0,0 +1,121 @@
+package smtpd
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-smtp"
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/testlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEmail = "From: device@example.com\r\n" +
+	"To: alerts-key@hookfeed.local\r\n" +
+	"Subject: Disk full\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"The disk is full.\r\n"
+
+// startServer serves SMTP for svcs on a loopback address and returns a client
+// connected to it
+func startServer(t *testing.T, svcs *services.Service) *smtp.Client {
+	t.Helper()
+
+	s := New(testlib.Logger(t), Config{
+		Domain:          "hookfeed.local",
+		MaxMessageBytes: 1 << 20,
+		MaxRecipients:   10,
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    5 * time.Second,
+	}, svcs)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := s.newServer(context.Background())
+	go func() { _ = server.Serve(ln) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	c, err := smtp.Dial(ln.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c
+}
+
+func testFeeds() *services.FeedService {
+	return services.NewFeedService(feeds.NewCache(&feeds.Config{
+		Feeds: []feeds.Feed{
+			{ID: "alerts", Keys: []string{"alerts-key", "alerts-alt"}},
+			{ID: "backups", Keys: []string{"backups-key"}},
+		},
+	}))
+}
+
+func Test_Session_Rcpt(t *testing.T) {
+	c := startServer(t, &services.Service{Feeds: testFeeds()})
+
+	require.NoError(t, c.Mail("device@example.com", nil))
+
+	tests := []struct {
+		name string
+		to   string
+		want *smtp.SMTPError
+	}{
+		{"feed key", "alerts-key@hookfeed.local", nil},
+		{"domain is case insensitive", "backups-key@HookFeed.Local", nil},
+		{"other domain", "alerts-key@example.com", errRelayDenied},
+		{"unknown key", "unknown@hookfeed.local", errUnknownRecipient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.Rcpt(tt.to, nil)
+			if tt.want == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			var serr *smtp.SMTPError
+			require.True(t, errors.As(err, &serr))
+			assert.Equal(t, tt.want.Code, serr.Code)
+			assert.Equal(t, tt.want.EnhancedCode, serr.EnhancedCode)
+		})
+	}
+}
+
+func Test_Session_Deliver(t *testing.T) {
+	testlib.IntegrationGuard(t)
+
+	var (
+		logger = testlib.Logger(t)
+		fms    = services.NewFeedMessageService(logger, testlib.NewDatabase(t, logger))
+		c      = startServer(t, &services.Service{Feeds: testFeeds(), FeedMessages: fms})
+	)
+
+	require.NoError(t, c.Mail("device@example.com", nil))
+	require.NoError(t, c.Rcpt("alerts-key@hookfeed.local", nil))
+	require.NoError(t, c.Rcpt("alerts-alt@hookfeed.local", nil))
+	require.NoError(t, c.Rcpt("backups-key@hookfeed.local", nil))
+
+	w, err := c.Data()
+	require.NoError(t, err)
+	_, err = w.Write([]byte(testEmail))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	for _, feedID := range []string{"alerts", "backups"} {
+		msgs, err := fms.GetBefore(context.Background(), &feedID, math.MaxInt64, 10)
+		require.NoError(t, err)
+		require.Len(t, msgs, 1, "two keys of one feed save one message")
+		require.NotNil(t, msgs[0].Title)
+		assert.Equal(t, "Disk full", *msgs[0].Title)
+	}
+}
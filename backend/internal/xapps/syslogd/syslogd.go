@@ -0,0 +1,324 @@
+// Package syslogd provides a UDP and TCP syslog listener that routes messages
+// to feeds and saves them in batches.
+package syslogd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/services/adapters"
+	"github.com/rs/zerolog"
+)
+
+// maxMessageSize is the largest syslog message read from a connection
+const maxMessageSize = 64 * 1024
+
+// maxLengthDigits is the longest octet count prefix accepted, enough for any
+// length up to maxMessageSize
+const maxLengthDigits = 10
+
+type Config struct {
+	Enabled       bool          `toml:"enabled"        env:"SYSLOG_ENABLED"        envDefault:"false"`
+	UDPAddr       string        `toml:"udp_addr"       env:"SYSLOG_UDP_ADDR"       envDefault:"0.0.0.0:5514"` // empty disables the UDP listener
+	TCPAddr       string        `toml:"tcp_addr"       env:"SYSLOG_TCP_ADDR"       envDefault:"0.0.0.0:5514"` // empty disables the TCP listener
+	BatchSize     int           `toml:"batch_size"     env:"SYSLOG_BATCH_SIZE"     envDefault:"100"`
+	FlushInterval time.Duration `toml:"flush_interval" env:"SYSLOG_FLUSH_INTERVAL" envDefault:"1s"`
+}
+
+// Validate reports whether the batching settings can be used
+func (cfg Config) Validate() error {
+	if cfg.BatchSize <= 0 {
+		return fmt.Errorf("syslog batch size must be greater than 0, got %d", cfg.BatchSize)
+	}
+
+	if cfg.FlushInterval <= 0 {
+		return fmt.Errorf("syslog flush interval must be greater than 0, got %s", cfg.FlushInterval)
+	}
+
+	return nil
+}
+
+type SyslogServer struct {
+	l    zerolog.Logger
+	cfg  Config
+	svcs *services.Service
+}
+
+func New(l zerolog.Logger, cfg Config, svcs *services.Service) *SyslogServer {
+	return &SyslogServer{
+		l:    l.With().Str("service", "syslog_server").Logger(),
+		cfg:  cfg,
+		svcs: svcs,
+	}
+}
+
+func (s *SyslogServer) Start(ctx context.Context) error {
+	if s.svcs.Feeds == nil {
+		return errors.New("syslog server requires a feed file")
+	}
+
+	if err := s.cfg.Validate(); err != nil {
+		return err
+	}
+
+	var (
+		udp net.PacketConn
+		tcp net.Listener
+		err error
+	)
+
+	if s.cfg.UDPAddr != "" {
+		udp, err = net.ListenPacket("udp", s.cfg.UDPAddr)
+		if err != nil {
+			return fmt.Errorf("listen udp: %w", err)
+		}
+	}
+
+	if s.cfg.TCPAddr != "" {
+		tcp, err = net.Listen("tcp", s.cfg.TCPAddr)
+		if err != nil {
+			if udp != nil {
+				_ = udp.Close()
+			}
+			return fmt.Errorf("listen tcp: %w", err)
+		}
+	}
+
+	s.l.Info().Str("udp", s.cfg.UDPAddr).Str("tcp", s.cfg.TCPAddr).Msg("starting service")
+
+	messages := make(chan dtos.FeedMessageCreate, s.cfg.BatchSize)
+
+	var wg sync.WaitGroup
+	if udp != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveUDP(udp, messages)
+		}()
+	}
+	if tcp != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveTCP(ctx, tcp, messages)
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		s.l.Info().Msg("stopping service")
+		if udp != nil {
+			_ = udp.Close()
+		}
+		if tcp != nil {
+			_ = tcp.Close()
+		}
+	}()
+
+	// The batcher drains the remaining messages once the listeners have stopped
+	go func() {
+		wg.Wait()
+		close(messages)
+	}()
+
+	s.batch(messages)
+	return nil
+}
+
+// serveUDP handles one message per datagram until the connection is closed
+func (s *SyslogServer) serveUDP(conn net.PacketConn, messages chan<- dtos.FeedMessageCreate) {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.l.Error().Err(err).Msg("failed to read udp message")
+			}
+			return
+		}
+
+		s.handle(buf[:n], addr.String(), messages)
+	}
+}
+
+// serveTCP accepts connections until the listener is closed, connections are
+// closed when ctx is done
+func (s *SyslogServer) serveTCP(ctx context.Context, ln net.Listener, messages chan<- dtos.FeedMessageCreate) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.l.Error().Err(err).Msg("failed to accept tcp connection")
+			}
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn, messages)
+		}()
+	}
+}
+
+func (s *SyslogServer) serveConn(ctx context.Context, conn net.Conn, messages chan<- dtos.FeedMessageCreate) {
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	defer func() { _ = conn.Close() }()
+
+	remote := conn.RemoteAddr().String()
+	r := bufio.NewReaderSize(conn, maxMessageSize)
+
+	for {
+		frame, err := readFrame(r)
+		if len(frame) > 0 {
+			s.handle(frame, remote, messages)
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.l.Debug().Err(err).Str("remote", remote).Msg("closing syslog connection")
+			}
+			return
+		}
+	}
+}
+
+// readFrame reads a message framed by octet counting ("<length> <message>") or
+// terminated by a newline, as described in RFC 6587
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if first[0] < '0' || first[0] > '9' {
+		line, err := r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return nil, fmt.Errorf("message exceeds %d bytes", maxMessageSize)
+		}
+		return line, err
+	}
+
+	// ReadSlice is bounded by the reader buffer, the prefix is checked before
+	// the rest of the buffer is filled with digits
+	prefix, err := r.ReadSlice(' ')
+	if errors.Is(err, bufio.ErrBufferFull) || len(prefix) > maxLengthDigits+1 {
+		return nil, fmt.Errorf("message length exceeds %d digits", maxLengthDigits)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(string(prefix[:len(prefix)-1]))
+	if err != nil || n <= 0 || n > maxMessageSize {
+		return nil, fmt.Errorf("invalid message length %q", prefix)
+	}
+
+	frame := make([]byte, n)
+	_, err = io.ReadFull(r, frame)
+	return frame, err
+}
+
+// handle parses and routes a message, messages that cannot be parsed or match
+// no route are dropped
+func (s *SyslogServer) handle(data []byte, remote string, messages chan<- dtos.FeedMessageCreate) {
+	msg, err := adapters.ParseSyslog(data, time.Now())
+	if err != nil {
+		s.l.Debug().Err(err).Str("remote", remote).Msg("dropping syslog message")
+		return
+	}
+
+	feedID, ok := s.svcs.Feeds.SyslogFeed(msg.FacilityName(), msg.AppName, msg.Hostname)
+	if !ok {
+		s.l.Debug().
+			Str("remote", remote).
+			Str("facility", msg.FacilityName()).
+			Str("app_name", msg.AppName).
+			Str("hostname", msg.Hostname).
+			Msg("no feed for syslog message")
+		return
+	}
+
+	createDTO, err := adapters.NewSyslogFeedMessage(msg, feedID, remote)
+	if err != nil {
+		s.l.Error().Err(err).Str("feed_id", feedID).Msg("failed to create feed message from syslog")
+		return
+	}
+
+	messages <- createDTO
+}
+
+// batch saves messages once the batch is full or the flush interval passed,
+// until messages is closed
+func (s *SyslogServer) batch(messages <-chan dtos.FeedMessageCreate) {
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	pending := make([]dtos.FeedMessageCreate, 0, s.cfg.BatchSize)
+
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				s.flush(pending)
+				return
+			}
+
+			pending = append(pending, msg)
+			if len(pending) >= s.cfg.BatchSize {
+				s.flush(pending)
+				pending = pending[:0]
+			}
+		case <-ticker.C:
+			s.flush(pending)
+			pending = pending[:0]
+		}
+	}
+}
+
+func (s *SyslogServer) flush(pending []dtos.FeedMessageCreate) {
+	if len(pending) == 0 {
+		return
+	}
+
+	// Flushes also run during shutdown, after the service context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	n, err := s.svcs.FeedMessages.CreateBatch(ctx, pending)
+	if err != nil {
+		// A single bad row fails the whole COPY, save the messages one at a
+		// time so only the rejected messages are lost
+		s.l.Warn().Err(err).Int("messages", len(pending)).Msg("failed to save syslog batch, saving messages one at a time")
+		n = s.flushEach(ctx, pending)
+	}
+
+	s.l.Debug().Int64("messages", n).Msg("saved syslog messages")
+}
+
+// flushEach saves the messages one at a time and returns the number saved
+func (s *SyslogServer) flushEach(ctx context.Context, pending []dtos.FeedMessageCreate) int64 {
+	var saved int64
+	for i := range pending {
+		n, err := s.svcs.FeedMessages.CreateBatch(ctx, pending[i:i+1])
+		if err != nil {
+			s.l.Error().Err(err).Str("feed_id", pending[i].FeedID).Msg("failed to save syslog message")
+			continue
+		}
+		saved += n
+	}
+
+	return saved
+}
@@ -0,0 +1,62 @@
+package syslogd
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReadFrame(t *testing.T) {
+	r := bufio.NewReaderSize(strings.NewReader("11 <34>1 hello<34>1 line\n"), maxMessageSize)
+
+	frame, err := readFrame(r)
+	require.NoError(t, err)
+	assert.Equal(t, "<34>1 hello", string(frame))
+
+	frame, err = readFrame(r)
+	require.NoError(t, err)
+	assert.Equal(t, "<34>1 line\n", string(frame))
+
+	_, err = readFrame(r)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func Test_ReadFrame_LengthPrefix(t *testing.T) {
+	tests := []struct {
+		name  string
+		input io.Reader
+	}{
+		{"too long", strings.NewReader("12345678901 <34>1 hello")},
+		{"too large", strings.NewReader("99999 <34>1 hello")},
+		{"endless digits", io.MultiReader(strings.NewReader("1"), endless('9'))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readFrame(bufio.NewReaderSize(tt.input, maxMessageSize))
+			assert.Error(t, err)
+		})
+	}
+}
+
+// endless is a reader that never ends, returning the same byte
+type endless byte
+
+func (e endless) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(e)
+	}
+	return len(p), nil
+}
+
+func Test_Config_Validate(t *testing.T) {
+	require.NoError(t, Config{BatchSize: 100, FlushInterval: time.Second}.Validate())
+	assert.Error(t, Config{BatchSize: 0, FlushInterval: time.Second}.Validate())
+	assert.Error(t, Config{BatchSize: 100, FlushInterval: 0}.Validate())
+	assert.Error(t, Config{BatchSize: 100, FlushInterval: -time.Second}.Validate())
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/web/extractors"
+	"github.com/hay-kot/httpkit/server"
+)
+
+type AttachmentController struct {
+	service *services.AttachmentService
+}
+
+func NewAttachmentController(service *services.AttachmentService) *AttachmentController {
+	return &AttachmentController{
+		service: service,
+	}
+}
+
+// GetByMessage godoc
+//
+//	@Tags			Attachments
+//	@Summary		List the attachments of a message
+//	@Produce		json
+//	@Param			id	path		string	true	"The FeedMessage ID"
+//	@Success		200	{array}		dtos.Attachment
+//	@Router			/v1/feed-messages/{id}/attachments [GET]
+//	@Security		Bearer
+func (ac *AttachmentController) GetByMessage(w http.ResponseWriter, r *http.Request) error {
+	id, err := extractors.ID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	attachments, err := ac.service.GetByMessage(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return server.JSON(w, http.StatusOK, attachments)
+}
+
+// Download godoc
+//
+//	@Tags			Attachments
+//	@Summary		Download an attachment
+//	@Description	Returns the content of the file with its content type. The file is always sent as a download.
+//	@Produce		octet-stream
+//	@Param			id	path		string	true	"The Attachment ID"
+//	@Success		200	{file}		file
+//	@Failure		404	{object}	server.ErrorResp
+//	@Router			/v1/attachments/{id} [GET]
+//	@Security		Bearer
+func (ac *AttachmentController) Download(w http.ResponseWriter, r *http.Request) error {
+	id, err := extractors.ID(r, "id")
+	if err != nil {
+		return err
+	}
+
+	attachment, err := ac.service.Get(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	content, err := ac.service.Open(r.Context(), attachment)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = content.Close() }()
+
+	// Uploaded files are untrusted, browsers must not render them in the app
+	h := w.Header()
+	h.Set("Content-Type", attachment.ContentType)
+	h.Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Content-Security-Policy", "sandbox")
+
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, content)
+	return err
+}
@@ -9,11 +9,15 @@ import (
 
 type FeedController struct {
 	feedService *services.FeedService
+	rateLimits  *services.RateLimitService
+	signatures  *services.SignatureFailureService
 }
 
-func NewFeedController(feedService *services.FeedService) *FeedController {
+func NewFeedController(feedService *services.FeedService, rateLimits *services.RateLimitService, signatures *services.SignatureFailureService) *FeedController {
 	return &FeedController{
 		feedService: feedService,
+		rateLimits:  rateLimits,
+		signatures:  signatures,
 	}
 }
 
@@ -34,3 +38,29 @@ func (fc *FeedController) GetAll(w http.ResponseWriter, r *http.Request) error {
 	feeds := fc.feedService.GetAllFeeds()
 	return server.JSON(w, http.StatusOK, feeds)
 }
+
+// GetRateLimits godoc
+//
+//	@Tags			Feeds
+//	@Summary		Get rate limit counters
+//	@Description	Get the webhook requests of each feed rejected by rate limits since the server started
+//	@Produce		json
+//	@Success		200	{array}	dtos.RateLimitStats
+//	@Router			/v1/feeds/rate-limits [GET]
+//	@Security		Bearer
+func (fc *FeedController) GetRateLimits(w http.ResponseWriter, r *http.Request) error {
+	return server.JSON(w, http.StatusOK, fc.rateLimits.Stats())
+}
+
+// GetSignatureFailures godoc
+//
+//	@Tags			Feeds
+//	@Summary		Get signature failure counters
+//	@Description	Get the webhook requests of each feed rejected by signature or adapter secret verification since the server started
+//	@Produce		json
+//	@Success		200	{array}	dtos.SignatureStats
+//	@Router			/v1/feeds/signature-failures [GET]
+//	@Security		Bearer
+func (fc *FeedController) GetSignatureFailures(w http.ResponseWriter, r *http.Request) error {
+	return server.JSON(w, http.StatusOK, fc.signatures.Stats())
+}
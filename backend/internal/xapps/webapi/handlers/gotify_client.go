@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services/adapters"
+	"github.com/hay-kot/hookfeed/backend/internal/web/extractors"
+	"github.com/hay-kot/httpkit/server"
+)
+
+const (
+	gotifyDefaultLimit = 100
+	gotifyMaxLimit     = 200
+	gotifyPingInterval = 45 * time.Second
+	gotifyWriteTimeout = 10 * time.Second
+)
+
+var gotifyUpgrader = websocket.Upgrader{
+	// Clients authenticate with the client token, not with cookies
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// GotifyVersion godoc
+//
+//	@Tags			Gotify
+//	@Summary		Get the Gotify-compatible server version
+//	@Produce		json
+//	@Success		200	{object}	dtos.GotifyVersion
+//	@Router			/version [GET]
+func GotifyVersion(resp dtos.GotifyVersion) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		return server.JSON(w, http.StatusOK, resp)
+	}
+}
+
+// CreateClient godoc
+//
+//	@Tags			Gotify
+//	@Summary		Create a Gotify client
+//	@Description	Logs in with basic auth (email and password) and returns a client token for the other Gotify client endpoints. The token is only returned here, it is revoked by deleting the client.
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		dtos.GotifyClientCreate	true	"Client name"
+//	@Success		200		{object}	dtos.GotifyClient
+//	@Failure		401		{object}	server.ErrorResp
+//	@Router			/client [POST]
+func (gc *GotifyController) CreateClient(w http.ResponseWriter, r *http.Request) error {
+	email, password, ok := r.BasicAuth()
+	if !ok {
+		return gc.unauthorized(w, r)
+	}
+
+	user, err := gc.userService.VerifyCredentials(r.Context(), dtos.UserAuthenticate{Email: email, Password: password})
+	if err != nil {
+		return gc.unauthorized(w, r)
+	}
+
+	body, err := extractors.Body[dtos.GotifyClientCreate](r)
+	if err != nil {
+		return err
+	}
+
+	client, err := gc.clientService.Create(r.Context(), user.ID, body.Name)
+	if err != nil {
+		return err
+	}
+
+	return server.JSON(w, http.StatusOK, client)
+}
+
+// GetClients godoc
+//
+//	@Tags			Gotify
+//	@Summary		List the Gotify clients of the user
+//	@Description	Returns the clients of the user of the client token. Tokens are not returned, only their hashes are stored.
+//	@Produce		json
+//	@Param			X-Gotify-Key	header		string	false	"Client token"
+//	@Success		200				{array}		dtos.GotifyClient
+//	@Failure		401				{object}	server.ErrorResp
+//	@Router			/client [GET]
+func (gc *GotifyController) GetClients(w http.ResponseWriter, r *http.Request) error {
+	user, ok := gc.client(r)
+	if !ok {
+		return gc.unauthorized(w, r)
+	}
+
+	clients, err := gc.clientService.GetAll(r.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	return server.JSON(w, http.StatusOK, clients)
+}
+
+// DeleteClient godoc
+//
+//	@Tags			Gotify
+//	@Summary		Delete a Gotify client
+//	@Description	Revokes the token of a client of the user of the client token
+//	@Param			X-Gotify-Key	header	string	false	"Client token"
+//	@Param			id				path	int		true	"Client id"
+//	@Success		200
+//	@Failure		401	{object}	server.ErrorResp
+//	@Failure		404	{object}	server.ErrorResp
+//	@Router			/client/{id} [DELETE]
+func (gc *GotifyController) DeleteClient(w http.ResponseWriter, r *http.Request) error {
+	user, ok := gc.client(r)
+	if !ok {
+		return gc.unauthorized(w, r)
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return server.Error().
+			Status(http.StatusBadRequest).
+			Msg("invalid client id").
+			Write(r.Context(), w)
+	}
+
+	if err := gc.clientService.Delete(r.Context(), user.ID, id); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// CurrentUser godoc
+//
+//	@Tags			Gotify
+//	@Summary		Get the user of a Gotify client token
+//	@Produce		json
+//	@Param			X-Gotify-Key	header		string	false	"Client token"
+//	@Success		200				{object}	dtos.GotifyUser
+//	@Failure		401				{object}	server.ErrorResp
+//	@Router			/current/user [GET]
+func (gc *GotifyController) CurrentUser(w http.ResponseWriter, r *http.Request) error {
+	user, ok := gc.client(r)
+	if !ok {
+		return gc.unauthorized(w, r)
+	}
+
+	return server.JSON(w, http.StatusOK, dtos.MapGotifyUser(user))
+}
+
+// GetApplications godoc
+//
+//	@Tags			Gotify
+//	@Summary		List feeds as Gotify applications
+//	@Produce		json
+//	@Param			X-Gotify-Key	header		string	false	"Client token"
+//	@Success		200				{array}		dtos.GotifyApplication
+//	@Failure		401				{object}	server.ErrorResp
+//	@Router			/application [GET]
+func (gc *GotifyController) GetApplications(w http.ResponseWriter, r *http.Request) error {
+	if _, ok := gc.client(r); !ok {
+		return gc.unauthorized(w, r)
+	}
+
+	feeds := gc.feedService.GetAllFeeds()
+
+	apps := make([]dtos.GotifyApplication, len(feeds))
+	for i, feed := range feeds {
+		apps[i] = dtos.MapGotifyApplication(feed)
+	}
+
+	return server.JSON(w, http.StatusOK, apps)
+}
+
+// GetMessages godoc
+//
+//	@Tags			Gotify
+//	@Summary		List messages of all feeds
+//	@Description	Returns messages newest first. Pass the paging.since of a response as since to get the next page.
+//	@Produce		json
+//	@Param			X-Gotify-Key	header		string	false	"Client token"
+//	@Param			limit			query		int		false	"The number of messages to return (1-200)"	default(100)
+//	@Param			since			query		int		false	"Return messages with an id below since"
+//	@Success		200				{object}	dtos.GotifyPagedMessages
+//	@Failure		401				{object}	server.ErrorResp
+//	@Router			/message [GET]
+func (gc *GotifyController) GetMessages(w http.ResponseWriter, r *http.Request) error {
+	if _, ok := gc.client(r); !ok {
+		return gc.unauthorized(w, r)
+	}
+
+	return gc.pagedMessages(w, r, nil)
+}
+
+// GetApplicationMessages godoc
+//
+//	@Tags			Gotify
+//	@Summary		List messages of a feed
+//	@Description	Returns messages of the feed with the Gotify application id, newest first
+//	@Produce		json
+//	@Param			X-Gotify-Key	header		string	false	"Client token"
+//	@Param			id				path		int		true	"Application id"
+//	@Param			limit			query		int		false	"The number of messages to return (1-200)"	default(100)
+//	@Param			since			query		int		false	"Return messages with an id below since"
+//	@Success		200				{object}	dtos.GotifyPagedMessages
+//	@Failure		401				{object}	server.ErrorResp
+//	@Failure		404				{object}	server.ErrorResp
+//	@Router			/application/{id}/message [GET]
+func (gc *GotifyController) GetApplicationMessages(w http.ResponseWriter, r *http.Request) error {
+	if _, ok := gc.client(r); !ok {
+		return gc.unauthorized(w, r)
+	}
+
+	appID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return server.Error().
+			Status(http.StatusBadRequest).
+			Msg("invalid application id").
+			Write(r.Context(), w)
+	}
+
+	for _, feed := range gc.feedService.GetAllFeeds() {
+		if dtos.GotifyAppID(feed.ID) == appID {
+			return gc.pagedMessages(w, r, &feed.ID)
+		}
+	}
+
+	return server.Error().
+		Status(http.StatusNotFound).
+		Msg("application does not exist").
+		Write(r.Context(), w)
+}
+
+// DeleteMessage godoc
+//
+//	@Tags			Gotify
+//	@Summary		Delete a message
+//	@Param			X-Gotify-Key	header	string	false	"Client token"
+//	@Param			id				path	int		true	"Message id"
+//	@Success		200
+//	@Failure		401	{object}	server.ErrorResp
+//	@Failure		404	{object}	server.ErrorResp
+//	@Router			/message/{id} [DELETE]
+func (gc *GotifyController) DeleteMessage(w http.ResponseWriter, r *http.Request) error {
+	if _, ok := gc.client(r); !ok {
+		return gc.unauthorized(w, r)
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return server.Error().
+			Status(http.StatusBadRequest).
+			Msg("invalid message id").
+			Write(r.Context(), w)
+	}
+
+	// Gotify ids are the unique sequential numbers of messages, the newest
+	// message numbered below id+1 is the message when it still exists
+	msgs, err := gc.feedMessageService.GetBefore(r.Context(), nil, id+1, 1)
+	if err != nil {
+		return err
+	}
+
+	if len(msgs) == 0 || msgs[0].Seq != id {
+		return server.Error().
+			Status(http.StatusNotFound).
+			Msg("message does not exist").
+			Write(r.Context(), w)
+	}
+
+	if err := gc.feedMessageService.Delete(r.Context(), msgs[0].ID); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// Stream godoc
+//
+//	@Tags			Gotify
+//	@Summary		Stream new messages over a WebSocket
+//	@Description	Sends every new message of all feeds as a Gotify message. The client token may be passed as the token query parameter.
+//	@Param			token	query	string	false	"Client token"
+//	@Success		101
+//	@Failure		401	{object}	server.ErrorResp
+//	@Router			/stream [GET]
+func (gc *GotifyController) Stream(w http.ResponseWriter, r *http.Request) error {
+	if _, ok := gc.client(r); !ok {
+		return gc.unauthorized(w, r)
+	}
+
+	conn, err := gotifyUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		gc.logger.Debug().Err(err).Msg("failed to upgrade gotify stream")
+		return nil
+	}
+	defer func() { _ = conn.Close() }()
+
+	// The read deadline set by the http server no longer applies, a closed
+	// connection is detected by the reader below or a failed ping
+	_ = conn.SetReadDeadline(time.Time{})
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	msgs := gc.feedMessageService.Subscribe(ctx)
+
+	ticker := time.NewTicker(gotifyPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-msgs:
+			if !ok {
+				return nil
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(gotifyWriteTimeout))
+			if err := conn.WriteJSON(dtos.MapGotifyMessage(msg)); err != nil {
+				gc.logger.Debug().Err(err).Msg("gotify stream client disconnected")
+				return nil
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(gotifyWriteTimeout)); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// pagedMessages writes a page of messages, of a single feed when feedID is set
+func (gc *GotifyController) pagedMessages(w http.ResponseWriter, r *http.Request, feedID *string) error {
+	query := r.URL.Query()
+
+	limit := gotifyDefaultLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > gotifyMaxLimit {
+			return server.Error().
+				Status(http.StatusBadRequest).
+				Msg("limit must be between 1 and 200").
+				Write(r.Context(), w)
+		}
+		limit = n
+	}
+
+	before := int64(math.MaxInt64)
+	if v := query.Get("since"); v != "" && v != "0" {
+		since, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || since < 0 {
+			return server.Error().
+				Status(http.StatusBadRequest).
+				Msg("invalid since").
+				Write(r.Context(), w)
+		}
+		before = since
+	}
+
+	// Fetch one extra message to know whether there is a next page
+	msgs, err := gc.feedMessageService.GetBefore(r.Context(), feedID, before, limit+1)
+	if err != nil {
+		return err
+	}
+
+	more := len(msgs) > limit
+	if more {
+		msgs = msgs[:limit]
+	}
+
+	page := dtos.GotifyPagedMessages{
+		Paging: dtos.GotifyPaging{
+			Size:  len(msgs),
+			Limit: limit,
+		},
+		Messages: make([]dtos.GotifyMessage, len(msgs)),
+	}
+
+	for i, msg := range msgs {
+		page.Messages[i] = dtos.MapGotifyMessage(msg)
+	}
+
+	if len(msgs) > 0 {
+		page.Paging.Since = page.Messages[len(msgs)-1].ID
+	}
+
+	if more {
+		next := url.Values{}
+		next.Set("limit", strconv.Itoa(limit))
+		next.Set("since", strconv.FormatInt(page.Paging.Since, 10))
+		page.Paging.Next = (&url.URL{Path: r.URL.Path, RawQuery: next.Encode()}).String()
+	}
+
+	return server.JSON(w, http.StatusOK, page)
+}
+
+// client returns the user of the client token of a request
+func (gc *GotifyController) client(r *http.Request) (dtos.User, bool) {
+	token := adapters.GotifyToken(r)
+	if token == "" {
+		return dtos.User{}, false
+	}
+
+	user, err := gc.clientService.Verify(r.Context(), token)
+	if err != nil {
+		return dtos.User{}, false
+	}
+
+	return user, true
+}
+
+func (gc *GotifyController) unauthorized(w http.ResponseWriter, r *http.Request) error {
+	return server.Error().
+		Status(http.StatusUnauthorized).
+		Msg(gotifyUnauthorized).
+		Write(r.Context(), w)
+}
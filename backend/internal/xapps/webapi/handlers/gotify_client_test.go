@@ -0,0 +1,187 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/testlib"
+	"github.com/hay-kot/hookfeed/backend/internal/web/mid"
+	"github.com/hay-kot/hookfeed/backend/internal/xapps/webapi/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gotifyRouter registers the Gotify client routes of ctrl like the web api
+func gotifyRouter(t *testing.T, ctrl *handlers.GotifyController) http.Handler {
+	adapter := mid.ErrorHandler(testlib.Logger(t))
+
+	mux := chi.NewRouter()
+	mux.Post("/client", adapter.Adapt(ctrl.CreateClient))
+	mux.Get("/client", adapter.Adapt(ctrl.GetClients))
+	mux.Delete("/client/{id}", adapter.Adapt(ctrl.DeleteClient))
+	mux.Get("/current/user", adapter.Adapt(ctrl.CurrentUser))
+	mux.Get("/application", adapter.Adapt(ctrl.GetApplications))
+	mux.Get("/application/{id}/message", adapter.Adapt(ctrl.GetApplicationMessages))
+	mux.Get("/message", adapter.Adapt(ctrl.GetMessages))
+	mux.Delete("/message/{id}", adapter.Adapt(ctrl.DeleteMessage))
+	mux.Get("/stream", adapter.Adapt(ctrl.Stream))
+	return mux
+}
+
+func Test_GotifyController_Unauthorized(t *testing.T) {
+	// Requests without a token are rejected before any service is used
+	ctrl := handlers.NewGotifyController(testlib.Logger(t), nil, nil, nil, nil, nil)
+	mux := gotifyRouter(t, ctrl)
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/client"},
+		{http.MethodGet, "/client"},
+		{http.MethodDelete, "/client/1"},
+		{http.MethodGet, "/current/user"},
+		{http.MethodGet, "/application"},
+		{http.MethodGet, "/application/1/message"},
+		{http.MethodGet, "/message"},
+		{http.MethodDelete, "/message/1"},
+		{http.MethodGet, "/stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
+
+func Test_GotifyController_Client(t *testing.T) {
+	testlib.IntegrationGuard(t)
+
+	var (
+		ctx      = context.Background()
+		logger   = testlib.Logger(t)
+		queries  = testlib.NewDatabase(t, logger)
+		users    = services.NewUserService(logger, queries)
+		messages = services.NewFeedMessageService(logger, queries)
+		feedSvc  = services.NewFeedService(feeds.NewCache(&feeds.Config{
+			Feeds: []feeds.Feed{{ID: "alerts", Keys: []string{"alerts-key"}}},
+		}))
+		ctrl = handlers.NewGotifyController(logger, messages, feedSvc, users, services.NewGotifyClientService(logger, queries), nil)
+		mux  = gotifyRouter(t, ctrl)
+	)
+
+	register := dtos.UserRegister{Email: "gotify@example.com", Username: "gotify", Password: "correct-horse-battery"}
+	_, err := users.Register(ctx, register)
+	require.NoError(t, err)
+
+	do := func(method, path, token string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("X-Gotify-Key", token)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	createClient := func() dtos.GotifyClient {
+		r := httptest.NewRequest(http.MethodPost, "/client", strings.NewReader(`{"name":"phone"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.SetBasicAuth(register.Email, register.Password)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var client dtos.GotifyClient
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&client))
+		require.NotEmpty(t, client.Token)
+		return client
+	}
+
+	seqs := make([]int64, 3)
+	for i := range seqs {
+		data := dtos.FeedMessageCreateNew()
+		data.FeedID = "alerts"
+		msg, err := messages.Create(ctx, data)
+		require.NoError(t, err)
+		seqs[i] = msg.Seq
+	}
+
+	client := createClient()
+
+	t.Run("wrong password", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/client", strings.NewReader(`{"name":"phone"}`))
+		r.SetBasicAuth(register.Email, "wrong")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/message", "unknown", "").Code)
+	})
+
+	t.Run("session token", func(t *testing.T) {
+		session, err := users.Authenticate(ctx, dtos.UserAuthenticate{Email: register.Email, Password: register.Password})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/message", session.Token, "").Code)
+	})
+
+	t.Run("paging with since", func(t *testing.T) {
+		w := do(http.MethodGet, "/message?limit=2", client.Token, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var page dtos.GotifyPagedMessages
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&page))
+		require.Len(t, page.Messages, 2)
+		assert.Equal(t, seqs[2], page.Messages[0].ID)
+		assert.Equal(t, seqs[1], page.Paging.Since)
+		assert.Equal(t, "/message?limit=2&since="+strconv.FormatInt(seqs[1], 10), page.Paging.Next)
+
+		w = do(http.MethodGet, page.Paging.Next, client.Token, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		page = dtos.GotifyPagedMessages{}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&page))
+		require.Len(t, page.Messages, 1)
+		assert.Equal(t, seqs[0], page.Messages[0].ID)
+		assert.Empty(t, page.Paging.Next)
+	})
+
+	t.Run("delete message by seq", func(t *testing.T) {
+		path := "/message/" + strconv.FormatInt(seqs[1], 10)
+
+		assert.Equal(t, http.StatusOK, do(http.MethodDelete, path, client.Token, "").Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, path, client.Token, "").Code)
+
+		// The message numbered below the deleted one is not deleted in its place
+		w := do(http.MethodGet, "/message", client.Token, "")
+		var page dtos.GotifyPagedMessages
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&page))
+		require.Len(t, page.Messages, 2)
+		assert.Equal(t, seqs[0], page.Messages[1].ID)
+	})
+
+	t.Run("revoked client", func(t *testing.T) {
+		other := createClient()
+
+		path := "/client/" + strconv.FormatInt(other.ID, 10)
+		assert.Equal(t, http.StatusOK, do(http.MethodDelete, path, client.Token, "").Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, path, client.Token, "").Code)
+
+		assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/message", other.Token, "").Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/message", client.Token, "").Code)
+	})
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/services/adapters"
+	"github.com/hay-kot/httpkit/server"
+	"github.com/rs/zerolog"
+)
+
+// gotifyUnauthorized is the error message Gotify returns for a missing or
+// invalid token
+const gotifyUnauthorized = "you need to provide a valid access token or user credentials to access this api"
+
+type GotifyController struct {
+	logger             zerolog.Logger
+	feedMessageService *services.FeedMessageService
+	feedService        *services.FeedService
+	userService        *services.UserService
+	clientService      *services.GotifyClientService
+	rateLimits         *services.RateLimitService
+}
+
+func NewGotifyController(logger zerolog.Logger, feedMessageService *services.FeedMessageService, feedService *services.FeedService, userService *services.UserService, clientService *services.GotifyClientService, rateLimits *services.RateLimitService) *GotifyController {
+	return &GotifyController{
+		logger:             logger.With().Str("controller", "gotify").Logger(),
+		feedMessageService: feedMessageService,
+		feedService:        feedService,
+		userService:        userService,
+		clientService:      clientService,
+		rateLimits:         rateLimits,
+	}
+}
+
+// CreateMessage godoc
+//
+//	@Tags			Gotify
+//	@Summary		Publish Gotify-compatible message
+//	@Description	Accepts Gotify-style message requests. The application token is resolved to a feed key and may be passed as the X-Gotify-Key header, the token query parameter, or a Bearer Authorization header. Priority uses the Gotify 0-10 scale.
+//	@Description	Requests are subject to the rate limits of the feed and of the source IP, and the body to the attachment limit of the feed.
+//	@Accept			json,x-www-form-urlencoded,mpfd
+//	@Produce		json
+//	@Param			token			query		string	false	"Application token (feed key)"
+//	@Param			X-Gotify-Key	header		string	false	"Application token (feed key)"
+//	@Param			body			body		object	true	"Gotify message with title, message, priority (0-10) and extras"
+//	@Success		200				{object}	dtos.GotifyMessage
+//	@Failure		400				{object}	server.ErrorResp
+//	@Failure		401				{object}	server.ErrorResp
+//	@Failure		413				{object}	server.ErrorResp
+//	@Failure		429				{object}	server.ErrorResp
+//	@Router			/message [POST]
+func (gc *GotifyController) CreateMessage(w http.ResponseWriter, r *http.Request) error {
+	token := adapters.GotifyToken(r)
+	if token == "" {
+		return server.Error().
+			Status(http.StatusUnauthorized).
+			Msg(gotifyUnauthorized).
+			Write(r.Context(), w)
+	}
+
+	if retry, ok := gc.rateLimits.Allow(token, remoteIP(r)); !ok {
+		return rateLimited(w, r, retry)
+	}
+
+	// Resolve the application token to a feed
+	feed, ok := gc.feedService.GetByKey(token)
+	if !ok {
+		gc.logger.Warn().Msg("feed not found for gotify token")
+		return server.Error().
+			Status(http.StatusUnauthorized).
+			Msg(gotifyUnauthorized).
+			Write(r.Context(), w)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, gc.feedService.MaxBodySize(token))
+	createDTO, err := adapters.ParseGotifyMessage(r, feed.ID)
+	if err != nil {
+		gc.logger.Error().Err(err).Str("feed_id", feed.ID).Msg("failed to parse gotify message")
+		return server.Error().
+			Status(bodyErrorStatus(err)).
+			Msg(err.Error()).
+			Write(r.Context(), w)
+	}
+
+	feedMessage, err := gc.feedMessageService.Create(r.Context(), createDTO)
+	if err != nil {
+		gc.logger.Error().Err(err).Str("feed_id", feed.ID).Msg("failed to create feed message from gotify")
+		return err
+	}
+
+	gc.logger.Info().
+		Str("message_id", feedMessage.ID.String()).
+		Str("feed_slug", feedMessage.FeedSlug).
+		Msg("gotify message saved successfully")
+
+	return server.JSON(w, http.StatusOK, dtos.MapGotifyMessage(feedMessage))
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
 	"github.com/hay-kot/hookfeed/backend/internal/services"
 	"github.com/hay-kot/hookfeed/backend/internal/services/adapters"
 	"github.com/hay-kot/httpkit/server"
@@ -14,13 +16,15 @@ type NtfyController struct {
 	logger             zerolog.Logger
 	feedMessageService *services.FeedMessageService
 	feedService        *services.FeedService
+	attachments        *services.AttachmentService
 }
 
-func NewNtfyController(logger zerolog.Logger, feedMessageService *services.FeedMessageService, feedService *services.FeedService) *NtfyController {
+func NewNtfyController(logger zerolog.Logger, feedMessageService *services.FeedMessageService, feedService *services.FeedService, attachments *services.AttachmentService) *NtfyController {
 	return &NtfyController{
 		logger:             logger.With().Str("controller", "ntfy").Logger(),
 		feedMessageService: feedMessageService,
 		feedService:        feedService,
+		attachments:        attachments,
 	}
 }
 
@@ -29,7 +33,7 @@ func NewNtfyController(logger zerolog.Logger, feedMessageService *services.FeedM
 //	@Tags			Ntfy
 //	@Summary		Publish ntfy-compatible notification
 //	@Description	Accepts ntfy-style POST/PUT requests for publishing notifications. Supports ntfy headers (X-Title, X-Message, etc.), query parameters (title, message, priority, etc.), JSON body, and plain text body.
-//	@Accept			json,text/plain
+//	@Accept			json,text/plain,octet-stream
 //	@Produce		json
 //	@Param			topic		path		string	true	"Topic/Feed name"
 //	@Param			title		query		string	false	"Notification title (alias: t)"
@@ -39,6 +43,11 @@ func NewNtfyController(logger zerolog.Logger, feedMessageService *services.FeedM
 //	@Param			click		query		string	false	"URL opened when notification is clicked"
 //	@Param			icon		query		string	false	"URL for custom notification icon"
 //	@Param			markdown	query		bool	false	"Enable Markdown rendering (alias: md)"
+//	@Param			actions		query		string	false	"Action buttons, JSON or ntfy short format"
+//	@Param			attach		query		string	false	"URL of an external attachment (alias: a)"
+//	@Param			filename	query		string	false	"Attachment file name (alias: f)"
+//	@Param			delay		query		string	false	"Delivery delay, unix timestamp or duration (aliases: at, in)"
+//	@Param			email		query		string	false	"E-mail address to forward the notification to (alias: e)"
 //	@Param			X-Title		header		string	false	"Notification title (overrides query param)"
 //	@Param			X-Priority	header		int		false	"Priority (1-5, default 3, overrides query param)"
 //	@Param			X-Tags		header		string	false	"Comma-separated tags (overrides query param)"
@@ -46,8 +55,14 @@ func NewNtfyController(logger zerolog.Logger, feedMessageService *services.FeedM
 //	@Param			X-Click		header		string	false	"Click URL (overrides query param)"
 //	@Param			X-Icon		header		string	false	"Icon URL (overrides query param)"
 //	@Param			X-Markdown	header		bool	false	"Enable Markdown (overrides query param)"
-//	@Param			body		body		string	false	"Message body (plain text or JSON)"
+//	@Param			X-Actions	header		string	false	"Action buttons (overrides query param)"
+//	@Param			X-Attach	header		string	false	"Attachment URL (overrides query param)"
+//	@Param			X-Filename	header		string	false	"Attachment file name (overrides query param)"
+//	@Param			X-Delay		header		string	false	"Delivery delay (overrides query param)"
+//	@Param			X-Email		header		string	false	"E-mail address (overrides query param)"
+//	@Param			body		body		string	false	"Message body (plain text or JSON), a PUT of a binary body is stored as an attachment"
 //	@Success		200			{object}	dtos.FeedMessage
+//	@Failure		413			{object}	server.ErrorResp
 //	@Router			/{topic} [POST]
 //	@Router			/{topic} [PUT]
 func (nc *NtfyController) Publish(w http.ResponseWriter, r *http.Request) error {
@@ -71,12 +86,33 @@ func (nc *NtfyController) Publish(w http.ResponseWriter, r *http.Request) error
 			Write(r.Context(), w)
 	}
 
+	// A file upload is checked against the attachment limits of the feed
+	// before the message is saved
+	r.Body = http.MaxBytesReader(w, r.Body, nc.feedService.MaxBodySize(topic))
+	upload, data, err := adapters.ReadNtfyUpload(r)
+	if err != nil {
+		return server.Error().
+			Status(bodyErrorStatus(err)).
+			Msg(err.Error()).
+			Write(r.Context(), w)
+	}
+
+	if upload != nil {
+		if err := nc.attachments.Check(r.Context(), feed.ID, upload.Size); err != nil {
+			nc.logger.Warn().Err(err).Str("topic", topic).Msg("ntfy attachment exceeds the feed limits")
+			return server.Error().
+				Status(http.StatusRequestEntityTooLarge).
+				Msg(err.Error()).
+				Write(r.Context(), w)
+		}
+	}
+
 	// Parse the ntfy request using the feed ID (not the key)
 	createDTO, err := adapters.ParseNtfyMessage(r, feed.ID)
 	if err != nil {
 		nc.logger.Error().Err(err).Str("topic", topic).Msg("failed to parse ntfy message")
 		return server.Error().
-			Status(http.StatusBadRequest).
+			Status(bodyErrorStatus(err)).
 			Msg(err.Error()).
 			Write(r.Context(), w)
 	}
@@ -95,6 +131,17 @@ func (nc *NtfyController) Publish(w http.ResponseWriter, r *http.Request) error
 		return err
 	}
 
+	if upload != nil {
+		_, err := nc.attachments.Create(r.Context(), feed.ID, feedMessage.ID, dtos.AttachmentUpload{
+			Name:        upload.Name,
+			ContentType: upload.Type,
+			Data:        data,
+		})
+		if err != nil {
+			nc.logger.Error().Err(err).Str("message_id", feedMessage.ID.String()).Msg("failed to save ntfy attachment")
+		}
+	}
+
 	nc.logger.Info().
 		Str("message_id", feedMessage.ID.String()).
 		Str("topic", topic).
@@ -103,3 +150,13 @@ func (nc *NtfyController) Publish(w http.ResponseWriter, r *http.Request) error
 
 	return server.JSON(w, http.StatusOK, feedMessage)
 }
+
+// bodyErrorStatus returns the status of a request that failed to parse, 413 when
+// the body is over its size limit
+func bodyErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
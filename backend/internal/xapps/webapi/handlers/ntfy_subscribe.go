@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services/adapters"
+	"github.com/hay-kot/httpkit/server"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	ntfyKeepaliveInterval = 45 * time.Second
+	ntfyWriteTimeout      = 10 * time.Second
+	ntfyCacheLimit        = 1000 // maximum number of cached messages returned for since=
+)
+
+var ntfyUpgrader = websocket.Upgrader{
+	// ntfy clients connect from any origin, the topic is the credential
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// ntfySubscription is a parsed subscribe request
+type ntfySubscription struct {
+	topic  string
+	feedID string
+	poll   bool
+	since  adapters.NtfySince
+	filter adapters.NtfyFilter
+}
+
+// SubscribeJSON godoc
+//
+//	@Tags			Ntfy
+//	@Summary		Subscribe to a topic as a JSON stream
+//	@Description	Streams the messages of a feed as newline delimited JSON in the ntfy wire format. With poll=1 the cached messages are returned and the connection is closed.
+//	@Produce		json
+//	@Param			topic		path		string	true	"Topic/Feed name"
+//	@Param			since		query		string	false	"Return cached messages since a duration (10m), unix timestamp, message ID, all or latest"
+//	@Param			poll		query		bool	false	"Return cached messages and close the connection"
+//	@Param			id			query		string	false	"Only return the message with this ID"
+//	@Param			title		query		string	false	"Only return messages with this title"
+//	@Param			message		query		string	false	"Only return messages with this message"
+//	@Param			priority	query		string	false	"Only return messages with any of these comma-separated priorities"
+//	@Param			tags		query		string	false	"Only return messages with all of these comma-separated tags"
+//	@Success		200			{object}	adapters.NtfyEvent
+//	@Failure		403			{object}	server.ErrorResp
+//	@Router			/{topic}/json [GET]
+func (nc *NtfyController) SubscribeJSON(w http.ResponseWriter, r *http.Request) error {
+	return nc.stream(w, r, "application/x-ndjson; charset=utf-8", func(w io.Writer, e adapters.NtfyEvent) error {
+		return json.NewEncoder(w).Encode(e)
+	})
+}
+
+// SubscribeSSE godoc
+//
+//	@Tags			Ntfy
+//	@Summary		Subscribe to a topic as server-sent events
+//	@Description	Streams the messages of a feed as server-sent events in the ntfy wire format. Supports the same parameters as the JSON stream.
+//	@Produce		text/event-stream
+//	@Param			topic	path	string	true	"Topic/Feed name"
+//	@Param			since	query	string	false	"Return cached messages since a duration (10m), unix timestamp, message ID, all or latest"
+//	@Param			poll	query	bool	false	"Return cached messages and close the connection"
+//	@Success		200
+//	@Failure		403	{object}	server.ErrorResp
+//	@Router			/{topic}/sse [GET]
+func (nc *NtfyController) SubscribeSSE(w http.ResponseWriter, r *http.Request) error {
+	return nc.stream(w, r, "text/event-stream; charset=utf-8", func(w io.Writer, e adapters.NtfyEvent) error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+
+		if e.Event == adapters.NtfyEventMessage {
+			_, err = fmt.Fprintf(w, "id: %s\ndata: %s\n\n", e.ID, data)
+		} else {
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Event, data)
+		}
+		return err
+	})
+}
+
+// SubscribeRaw godoc
+//
+//	@Tags			Ntfy
+//	@Summary		Subscribe to a topic as plain text
+//	@Description	Streams the message text of a feed, one message per line. Keepalives are sent as empty lines. Supports the same parameters as the JSON stream.
+//	@Produce		plain
+//	@Param			topic	path	string	true	"Topic/Feed name"
+//	@Param			since	query	string	false	"Return cached messages since a duration (10m), unix timestamp, message ID, all or latest"
+//	@Param			poll	query	bool	false	"Return cached messages and close the connection"
+//	@Success		200
+//	@Failure		403	{object}	server.ErrorResp
+//	@Router			/{topic}/raw [GET]
+func (nc *NtfyController) SubscribeRaw(w http.ResponseWriter, r *http.Request) error {
+	return nc.stream(w, r, "text/plain; charset=utf-8", func(w io.Writer, e adapters.NtfyEvent) error {
+		var err error
+		switch e.Event {
+		case adapters.NtfyEventMessage:
+			_, err = io.WriteString(w, strings.ReplaceAll(e.Message, "\n", " ")+"\n")
+		case adapters.NtfyEventKeepalive:
+			_, err = io.WriteString(w, "\n")
+		}
+		return err
+	})
+}
+
+// SubscribeWS godoc
+//
+//	@Tags			Ntfy
+//	@Summary		Subscribe to a topic over a WebSocket
+//	@Description	Sends the messages of a feed as JSON WebSocket messages in the ntfy wire format. Supports the same parameters as the JSON stream.
+//	@Param			topic	path	string	true	"Topic/Feed name"
+//	@Param			since	query	string	false	"Return cached messages since a duration (10m), unix timestamp, message ID, all or latest"
+//	@Param			poll	query	bool	false	"Return cached messages and close the connection"
+//	@Success		101
+//	@Failure		403	{object}	server.ErrorResp
+//	@Router			/{topic}/ws [GET]
+func (nc *NtfyController) SubscribeWS(w http.ResponseWriter, r *http.Request) error {
+	sub, err := nc.parseSubscription(w, r)
+	if err != nil {
+		return err
+	}
+
+	conn, err := ntfyUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		nc.logger.Debug().Err(err).Str("topic", sub.topic).Msg("failed to upgrade ntfy websocket")
+		return nil
+	}
+	defer func() { _ = conn.Close() }()
+
+	// The read deadline set by the http server no longer applies, a closed
+	// connection is detected by the reader below or a failed keepalive
+	_ = conn.SetReadDeadline(time.Time{})
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	return nc.run(ctx, sub, func(e adapters.NtfyEvent) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(ntfyWriteTimeout))
+		return conn.WriteJSON(e)
+	})
+}
+
+// stream serves a subscription over a long-lived http response, encoding each
+// event with encode
+func (nc *NtfyController) stream(w http.ResponseWriter, r *http.Request, contentType string, encode func(io.Writer, adapters.NtfyEvent) error) error {
+	sub, err := nc.parseSubscription(w, r)
+	if err != nil {
+		return err
+	}
+
+	rc := http.NewResponseController(w)
+
+	// The server write timeout would end the stream
+	if !sub.poll {
+		_ = rc.SetWriteDeadline(time.Time{})
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	return nc.run(r.Context(), sub, func(e adapters.NtfyEvent) error {
+		if err := encode(w, e); err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+}
+
+// parseSubscription resolves the topic and reads the subscribe parameters. On
+// failure the error response has already been written.
+func (nc *NtfyController) parseSubscription(w http.ResponseWriter, r *http.Request) (ntfySubscription, error) {
+	topic := chi.URLParam(r, "topic")
+
+	feed, ok := nc.feedService.GetByKey(topic)
+	if !ok {
+		nc.logger.Warn().Str("topic", topic).Msg("feed not found for ntfy subscription")
+		return ntfySubscription{}, server.Error().
+			Status(http.StatusNotFound).
+			Msg("feed not found").
+			Write(r.Context(), w)
+	}
+
+	// Publishing keys are handed to devices and scripts, reading stored
+	// messages with one is only allowed for feeds that opt in
+	if !nc.feedService.NtfySubscribe(topic) {
+		nc.logger.Warn().Str("feed_id", feed.ID).Msg("ntfy subscribe is not enabled for feed")
+		return ntfySubscription{}, server.Error().
+			Status(http.StatusForbidden).
+			Msg("subscribing is not enabled for this feed").
+			Write(r.Context(), w)
+	}
+
+	query := r.URL.Query()
+	sub := ntfySubscription{
+		topic:  topic,
+		feedID: feed.ID,
+		poll:   adapters.NtfyBool(cmp.Or(adapters.GetQueryParam(query, "poll", "po"), adapters.GetHeader(r, "X-Poll", "Poll", "Po"))),
+	}
+
+	var err error
+	since := cmp.Or(adapters.GetQueryParam(query, "since", "si"), adapters.GetHeader(r, "X-Since", "Since", "Si"))
+
+	sub.since, err = adapters.ParseNtfySince(since, time.Now())
+	if err != nil {
+		return ntfySubscription{}, server.Error().
+			Status(http.StatusBadRequest).
+			Msg(err.Error()).
+			Write(r.Context(), w)
+	}
+
+	// Polling without since returns all cached messages, like ntfy
+	if sub.poll && sub.since.IsNone() {
+		sub.since.All = true
+	}
+
+	sub.filter, err = adapters.ParseNtfyFilter(r)
+	if err != nil {
+		return ntfySubscription{}, server.Error().
+			Status(http.StatusBadRequest).
+			Msg(err.Error()).
+			Write(r.Context(), w)
+	}
+
+	return sub, nil
+}
+
+// run sends the cached messages of a subscription followed, unless polling,
+// by new messages and keepalives until ctx is done or a send fails
+func (nc *NtfyController) run(ctx context.Context, sub ntfySubscription, send func(adapters.NtfyEvent) error) error {
+	// Subscribe before loading the cache so no message is missed in between
+	var live <-chan dtos.FeedMessage
+	if !sub.poll {
+		live = nc.feedMessageService.Subscribe(ctx, sub.feedID)
+	}
+
+	cached, err := nc.cached(ctx, sub)
+	if err != nil {
+		nc.logger.Error().Err(err).Str("topic", sub.topic).Msg("failed to load cached ntfy messages")
+	}
+
+	if !sub.poll {
+		if err := send(adapters.NewNtfyEvent(adapters.NtfyEventOpen, sub.topic)); err != nil {
+			return nil
+		}
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(cached))
+	for _, msg := range cached {
+		seen[msg.ID] = struct{}{}
+
+		event := adapters.NtfyMessageEvent(msg, sub.topic)
+		if !sub.filter.Match(event) {
+			continue
+		}
+
+		if err := send(event); err != nil {
+			return nil
+		}
+	}
+
+	if sub.poll {
+		return nil
+	}
+
+	ticker := time.NewTicker(ntfyKeepaliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-live:
+			if !ok {
+				return nil
+			}
+
+			if _, ok := seen[msg.ID]; ok {
+				continue
+			}
+
+			event := adapters.NtfyMessageEvent(msg, sub.topic)
+			if !sub.filter.Match(event) {
+				continue
+			}
+
+			if err := send(event); err != nil {
+				nc.logger.Debug().Err(err).Str("topic", sub.topic).Msg("ntfy subscriber disconnected")
+				return nil
+			}
+		case <-ticker.C:
+			if err := send(adapters.NewNtfyEvent(adapters.NtfyEventKeepalive, sub.topic)); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// cached returns the stored messages requested with since=, oldest first
+func (nc *NtfyController) cached(ctx context.Context, sub ntfySubscription) ([]dtos.FeedMessage, error) {
+	if sub.since.IsNone() {
+		return nil, nil
+	}
+
+	query := dtos.FeedMessageQuery{Pagination: dtos.Pagination{Limit: ntfyCacheLimit}}
+
+	var after *dtos.FeedMessage
+	switch {
+	case sub.since.Latest:
+		query.Limit = 1
+	case !sub.since.Time.IsZero():
+		query.Since = &sub.since.Time
+	case sub.since.MessageID != uuid.Nil:
+		msg, err := nc.feedMessageService.Get(ctx, sub.since.MessageID)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			// The message may have been removed by retention, return everything
+		case err != nil:
+			return nil, err
+		case msg.FeedSlug == sub.feedID:
+			after = &msg
+			query.Since = &msg.ReceivedAt
+		}
+	}
+
+	page, err := nc.feedMessageService.GetByFeedSlug(ctx, sub.feedID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Messages are returned newest first
+	msgs := page.Items
+	slices.Reverse(msgs)
+
+	if after != nil {
+		msgs = slices.DeleteFunc(msgs, func(m dtos.FeedMessage) bool {
+			return m.ID == after.ID || m.ReceivedAt.Before(after.ReceivedAt)
+		})
+	}
+
+	return msgs, nil
+}
@@ -0,0 +1,45 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/testlib"
+	"github.com/hay-kot/hookfeed/backend/internal/xapps/webapi/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NtfyController_Subscribe_Rejected(t *testing.T) {
+	feedService := services.NewFeedService(feeds.NewCache(&feeds.Config{
+		Feeds: []feeds.Feed{{ID: "alerts", Keys: []string{"alerts-key"}}},
+	}))
+
+	ctrl := handlers.NewNtfyController(testlib.Logger(t), nil, feedService, nil)
+
+	subscribe := func(topic string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/"+topic+"/json?poll=1", nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("topic", topic)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+		// The error response is written before the error is returned
+		w := httptest.NewRecorder()
+		require.Error(t, ctrl.SubscribeJSON(w, r))
+		return w
+	}
+
+	t.Run("unknown topic", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, subscribe("unknown").Code)
+	})
+
+	t.Run("feed without ntfy_subscribe", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, subscribe("alerts-key").Code)
+	})
+}
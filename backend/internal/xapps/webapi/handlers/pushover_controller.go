@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/services/adapters"
+	"github.com/hay-kot/httpkit/server"
+	"github.com/rs/zerolog"
+)
+
+type PushoverController struct {
+	logger             zerolog.Logger
+	feedMessageService *services.FeedMessageService
+	feedService        *services.FeedService
+	rateLimits         *services.RateLimitService
+}
+
+func NewPushoverController(logger zerolog.Logger, feedMessageService *services.FeedMessageService, feedService *services.FeedService, rateLimits *services.RateLimitService) *PushoverController {
+	return &PushoverController{
+		logger:             logger.With().Str("controller", "pushover").Logger(),
+		feedMessageService: feedMessageService,
+		feedService:        feedService,
+		rateLimits:         rateLimits,
+	}
+}
+
+// CreateMessage godoc
+//
+//	@Tags			Pushover
+//	@Summary		Publish Pushover-compatible message
+//	@Description	Accepts Pushover-style message requests as form, query or JSON parameters. The application token is resolved to a feed key; the user key is accepted but not validated. Priority uses the Pushover -2 to 2 scale.
+//	@Description	The token is read from the body, so bodies are limited to the default attachment limit of feeds. Requests are subject to the rate limits of the feed and of the source IP.
+//	@Accept			json,x-www-form-urlencoded,mpfd
+//	@Produce		json
+//	@Param			token		formData	string	true	"Application token (feed key)"
+//	@Param			user		formData	string	false	"User or group key"
+//	@Param			message		formData	string	true	"Message body"
+//	@Param			title		formData	string	false	"Message title"
+//	@Param			priority	formData	int		false	"Priority from -2 to 2"
+//	@Param			url			formData	string	false	"Supplementary URL"
+//	@Param			url_title	formData	string	false	"Title of the supplementary URL"
+//	@Param			html		formData	int		false	"Set to 1 for HTML formatting"
+//	@Param			monospace	formData	int		false	"Set to 1 for monospace formatting"
+//	@Param			timestamp	formData	int		false	"Unix timestamp of the message"
+//	@Success		200			{object}	dtos.PushoverResponse
+//	@Failure		400			{object}	dtos.PushoverResponse
+//	@Failure		413			{object}	dtos.PushoverResponse
+//	@Failure		429			{object}	dtos.PushoverResponse
+//	@Router			/1/messages.json [POST]
+func (pc *PushoverController) CreateMessage(w http.ResponseWriter, r *http.Request) error {
+	requestID := uuid.New().String()
+
+	// The token is part of the body, the feed limits are not known before it
+	// is read
+	r.Body = http.MaxBytesReader(w, r.Body, feeds.DefaultMaxBodySize)
+	req, err := adapters.ParsePushoverMessage(r)
+	if err != nil {
+		var perr *adapters.PushoverParamError
+		if !errors.As(err, &perr) {
+			pc.logger.Error().Err(err).Msg("failed to parse pushover message")
+		}
+		return pc.reject(w, bodyErrorStatus(err), requestID, err.Error())
+	}
+
+	if retry, ok := pc.rateLimits.Allow(req.Token, remoteIP(r)); !ok {
+		w.Header().Set("Retry-After", retryAfter(retry))
+		return pc.reject(w, http.StatusTooManyRequests, requestID, "rate limit exceeded")
+	}
+
+	// Resolve the application token to a feed
+	feed, ok := pc.feedService.GetByKey(req.Token)
+	if !ok {
+		pc.logger.Warn().Msg("feed not found for pushover token")
+		return pc.reject(w, http.StatusBadRequest, requestID, "application token is invalid")
+	}
+
+	req.Data.FeedID = feed.ID
+
+	feedMessage, err := pc.feedMessageService.Create(r.Context(), req.Data)
+	if err != nil {
+		pc.logger.Error().Err(err).Str("feed_id", feed.ID).Msg("failed to create feed message from pushover")
+		return err
+	}
+
+	pc.logger.Info().
+		Str("message_id", feedMessage.ID.String()).
+		Str("feed_slug", feedMessage.FeedSlug).
+		Msg("pushover message saved successfully")
+
+	return server.JSON(w, http.StatusOK, dtos.PushoverResponse{
+		Status:  1,
+		Request: requestID,
+	})
+}
+
+// reject writes a Pushover error response
+func (pc *PushoverController) reject(w http.ResponseWriter, status int, requestID string, msg string) error {
+	return server.JSON(w, status, dtos.PushoverResponse{
+		Status:  0,
+		Request: requestID,
+		Errors:  []string{msg},
+	})
+}
@@ -0,0 +1,51 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/hookfeed/backend/internal/core/feeds"
+	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/testlib"
+	"github.com/hay-kot/hookfeed/backend/internal/xapps/webapi/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PushoverController_CreateMessage_Rejected(t *testing.T) {
+	feedService := services.NewFeedService(feeds.NewCache(&feeds.Config{
+		RateLimit: &feeds.RateLimit{Requests: 1, Interval: time.Hour},
+		Feeds:     []feeds.Feed{{ID: "alerts", Keys: []string{"alerts-key"}}},
+	}))
+
+	ctrl := handlers.NewPushoverController(testlib.Logger(t), nil, feedService, services.NewRateLimitService(feedService))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/1/messages.json", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		require.NoError(t, ctrl.CreateMessage(w, r))
+		return w
+	}
+
+	t.Run("body over the default limit", func(t *testing.T) {
+		w := post(strings.Repeat("x", feeds.DefaultMaxBodySize+1))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		w := post(url.Values{"token": {"unknown"}, "user": {"u"}, "message": {"hi"}}.Encode())
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	// The request with the unknown token used the one request of the address
+	t.Run("over the source ip limit", func(t *testing.T) {
+		w := post(url.Values{"token": {"alerts-key"}, "user": {"u"}, "message": {"hi"}}.Encode())
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	})
+}
@@ -1,21 +1,29 @@
 package handlers
 
 import (
+	"encoding/json"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/hay-kot/hookfeed/backend/internal/data/dtos"
 	"github.com/hay-kot/hookfeed/backend/internal/services"
+	"github.com/hay-kot/hookfeed/backend/internal/services/adapters"
 	"github.com/hay-kot/hookfeed/backend/internal/web/extractors"
 	"github.com/hay-kot/httpkit/server"
 )
 
 type WebhookController struct {
 	webhookService *services.WebhookService
+	rateLimits     *services.RateLimitService
 }
 
-func NewWebhookController(webhookService *services.WebhookService) *WebhookController {
+func NewWebhookController(webhookService *services.WebhookService, rateLimits *services.RateLimitService) *WebhookController {
 	return &WebhookController{
 		webhookService: webhookService,
+		rateLimits:     rateLimits,
 	}
 }
 
@@ -23,15 +31,25 @@ func NewWebhookController(webhookService *services.WebhookService) *WebhookContr
 //
 //	@Tags			Webhooks
 //	@Summary		Receive webhook
-//	@Description	Accepts webhooks in any format and processes them according to feed configuration
+//	@Description	Accepts webhooks in any format and processes them according to feed configuration.
+//	@Description	CloudEvents in binary, structured and batch mode are stored as one message per event.
+//	@Description	HTML form posts are stored as a JSON object of their fields and redirected when the feed has a redirect_url.
+//	@Description	Files of multipart form posts are stored as attachments of the message.
+//	@Description	Requests over the rate limit of the feed or of the source IP are rejected with a Retry-After header.
 //	@Accept			json
+//	@Accept			x-www-form-urlencoded
+//	@Accept			mpfd
 //	@Produce		json
 //	@Param			key		path		string	true	"Feed key (from feed configuration)"
 //	@Param			body	body		object	true	"Webhook payload (any JSON)"
 //	@Success		202		{object}	dtos.WebhookResponse
+//	@Success		303
 //	@Failure		400		{object}	server.ErrorResp
 //	@Failure		401		{object}	server.ErrorResp
+//	@Failure		403		{object}	server.ErrorResp
 //	@Failure		404		{object}	server.ErrorResp
+//	@Failure		413		{object}	server.ErrorResp
+//	@Failure		429		{object}	server.ErrorResp
 //	@Failure		500		{object}	server.ErrorResp
 //	@Router			/hooks/{slug} [POST]
 func (wc *WebhookController) HandleWebhook(w http.ResponseWriter, r *http.Request) error {
@@ -41,8 +59,15 @@ func (wc *WebhookController) HandleWebhook(w http.ResponseWriter, r *http.Reques
 		return err
 	}
 
-	val := map[string]any{}
-	err = server.Decode(r, &val)
+	// Rejected requests are not worth reading the body of
+	if retry, ok := wc.rateLimits.Allow(key, remoteIP(r)); !ok {
+		return rateLimited(w, r, retry)
+	}
+
+	// Keep the raw bytes so middleware can verify signatures over the exact body,
+	// bodies over the attachment limit of the feed are rejected with a 413
+	r.Body = http.MaxBytesReader(w, r.Body, wc.webhookService.MaxBodySize(key))
+	raw, err := io.ReadAll(r.Body)
 	if err != nil {
 		return err
 	}
@@ -52,7 +77,44 @@ func (wc *WebhookController) HandleWebhook(w http.ResponseWriter, r *http.Reques
 		FeedKey:     key,
 		Headers:     r.Header,
 		QueryParams: r.URL.Query(),
-		Body:        val,
+		Body:        map[string]any{},
+		RawBody:     raw,
+	}
+
+	switch {
+	case adapters.IsCloudEvent(r.Header):
+		// CloudEvents batches are arrays and binary mode data may not be JSON, so
+		// their events are parsed instead of the body
+		webhookReq.CloudEvents, err = adapters.ParseCloudEvents(r.Header, raw)
+		if err != nil {
+			return server.Error().
+				Status(http.StatusBadRequest).
+				Msg(err.Error()).
+				Write(r.Context(), w)
+		}
+	case adapters.IsForm(r.Header):
+		webhookReq.Body, err = adapters.ParseForm(r.Header, raw)
+		if err != nil {
+			return server.Error().
+				Status(http.StatusBadRequest).
+				Msg(err.Error()).
+				Write(r.Context(), w)
+		}
+		webhookReq.Form = true
+		webhookReq.RemoteIP = remoteIP(r)
+
+		webhookReq.Attachments, err = adapters.ParseFormFiles(r.Header, raw)
+		if err != nil {
+			return server.Error().
+				Status(http.StatusBadRequest).
+				Msg(err.Error()).
+				Write(r.Context(), w)
+		}
+	default:
+		err = json.Unmarshal(raw, &webhookReq.Body)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Process the webhook
@@ -61,6 +123,39 @@ func (wc *WebhookController) HandleWebhook(w http.ResponseWriter, r *http.Reques
 		return err
 	}
 
+	// Browser form posts land on the feed's thank-you page
+	if response.RedirectURL != "" {
+		http.Redirect(w, r, response.RedirectURL, http.StatusSeeOther)
+		return nil
+	}
+
 	// Return 202 Accepted with the response
 	return server.JSON(w, http.StatusAccepted, response)
 }
+
+// remoteIP returns the client address of a request without its port. It is
+// the connection peer unless a trusted proxy forwarded the request, see
+// [mid.RealIP].
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimited writes the response of a request rejected by a rate limit
+func rateLimited(w http.ResponseWriter, r *http.Request, retry time.Duration) error {
+	w.Header().Set("Retry-After", retryAfter(retry))
+	return server.Error().
+		Status(http.StatusTooManyRequests).
+		Msg("rate limit exceeded").
+		Write(r.Context(), w)
+}
+
+// retryAfter returns the Retry-After header value of a delay in whole seconds,
+// rounded up so clients do not retry before the limit allows it
+func retryAfter(d time.Duration) string {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	return strconv.FormatInt(max(seconds, 1), 10)
+}
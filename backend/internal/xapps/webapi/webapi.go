@@ -5,6 +5,7 @@ import (
 	"errors"
 	"mime"
 	"net/http"
+	"net/netip"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -29,6 +30,7 @@ type Config struct {
 	IdleTimeout    time.Duration `toml:"idle_timeout"    env:"WEB_IDLE_TIMEOUT"    envDefault:"30s"`
 	ReadTimeout    time.Duration `toml:"read_timeout"    env:"WEB_READ_TIMEOUT"    envDefault:"10s"`
 	WriteTimeout   time.Duration `toml:"write_timeout"   env:"WEB_WRITE_TIMEOUT"   envDefault:"20s"`
+	TrustedProxies []string      `toml:"trusted_proxies" env:"WEB_TRUSTED_PROXIES" envDefault:""`
 }
 
 func (cfg Config) Addr() string {
@@ -58,7 +60,12 @@ func registerMimes() {
 }
 
 func (ib *WebAPI) Start(ctx context.Context) error {
-	mux := ib.routes()
+	trusted, err := mid.ParseTrustedProxies(ib.cfg.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
+	mux := ib.routes(trusted)
 
 	server := &http.Server{
 		Handler:      mux,
@@ -75,7 +82,7 @@ func (ib *WebAPI) Start(ctx context.Context) error {
 	}()
 
 	ib.l.Info().Str("docs", "http://"+ib.cfg.Addr()+"/docs/index.html").Msg("starting service")
-	err := server.ListenAndServe()
+	err = server.ListenAndServe()
 	if errors.Is(err, http.ErrServerClosed) {
 		return nil
 	}
@@ -83,12 +90,12 @@ func (ib *WebAPI) Start(ctx context.Context) error {
 	return err
 }
 
-func (ib *WebAPI) routes() chi.Router {
+func (ib *WebAPI) routes(trustedProxies []netip.Prefix) chi.Router {
 	mux := chi.NewRouter()
 	mux.Use(middleware.Recoverer)
 
 	mux.Use(
-		middleware.RealIP,
+		mid.RealIP(trustedProxies),
 		middleware.CleanPath,
 		middleware.StripSlashes,
 		mid.RequestID(),
@@ -100,7 +107,7 @@ func (ib *WebAPI) routes() chi.Router {
 	mux.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   ib.cfg.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Trace-ID"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Trace-ID", "X-Gotify-Key"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
@@ -109,8 +116,8 @@ func (ib *WebAPI) routes() chi.Router {
 	adapter := mid.ErrorHandler(ib.l)
 
 	userctrl := handlers.NewAuthController(ib.services.Users, ib.services.Passwords)
-	feedctrl := handlers.NewFeedController(ib.services.Feeds)
-	webhookctrl := handlers.NewWebhookController(ib.services.Webhooks)
+	feedctrl := handlers.NewFeedController(ib.services.Feeds, ib.services.RateLimits, ib.services.Signatures)
+	webhookctrl := handlers.NewWebhookController(ib.services.Webhooks, ib.services.RateLimits)
 
 	mux.HandleFunc("GET /docs/swagger.json", adapter.Adapt(docs.SwaggerJSON))
 	mux.HandleFunc("GET /api/v1/info", adapter.Adapt(handlers.Info(dtos.StatusResponse{Build: ib.build})))
@@ -123,10 +130,36 @@ func (ib *WebAPI) routes() chi.Router {
 	// Webhook ingestion endpoint (no auth required)
 	mux.Post("/hooks/{key}", adapter.Adapt(webhookctrl.HandleWebhook))
 
-	// Ntfy-compatible endpoint (no auth required)
-	ntfyctrl := handlers.NewNtfyController(ib.l, ib.services.FeedMessages, ib.services.Feeds)
-	mux.Post("/{topic}", adapter.Adapt(ntfyctrl.Publish))
-	mux.Put("/{topic}", adapter.Adapt(ntfyctrl.Publish))
+	// Gotify-compatible endpoint (authenticated by the feed key as app token)
+	gotifyctrl := handlers.NewGotifyController(ib.l, ib.services.FeedMessages, ib.services.Feeds, ib.services.Users, ib.services.GotifyClients, ib.services.RateLimits)
+	mux.Post("/message", adapter.Adapt(gotifyctrl.CreateMessage))
+
+	// Gotify client endpoints (authenticated by a client token issued by POST /client)
+	mux.Get("/version", adapter.Adapt(handlers.GotifyVersion(dtos.GotifyVersion{Version: ib.build})))
+	mux.Post("/client", adapter.Adapt(gotifyctrl.CreateClient))
+	mux.Get("/client", adapter.Adapt(gotifyctrl.GetClients))
+	mux.Delete("/client/{id}", adapter.Adapt(gotifyctrl.DeleteClient))
+	mux.Get("/current/user", adapter.Adapt(gotifyctrl.CurrentUser))
+	mux.Get("/application", adapter.Adapt(gotifyctrl.GetApplications))
+	mux.Get("/application/{id}/message", adapter.Adapt(gotifyctrl.GetApplicationMessages))
+	mux.Get("/message", adapter.Adapt(gotifyctrl.GetMessages))
+	mux.Delete("/message/{id}", adapter.Adapt(gotifyctrl.DeleteMessage))
+	mux.Get("/stream", adapter.Adapt(gotifyctrl.Stream))
+
+	// Pushover-compatible endpoint (authenticated by the feed key as app token)
+	pushoverctrl := handlers.NewPushoverController(ib.l, ib.services.FeedMessages, ib.services.Feeds, ib.services.RateLimits)
+	mux.Post("/1/messages.json", adapter.Adapt(pushoverctrl.CreateMessage))
+
+	// Ntfy-compatible endpoints (no auth required, subscribing is opt-in per feed)
+	if ib.services.Config.NtfyEnabled {
+		ntfyctrl := handlers.NewNtfyController(ib.l, ib.services.FeedMessages, ib.services.Feeds, ib.services.Attachments)
+		mux.Post("/{topic}", adapter.Adapt(ntfyctrl.Publish))
+		mux.Put("/{topic}", adapter.Adapt(ntfyctrl.Publish))
+		mux.Get("/{topic}/json", adapter.Adapt(ntfyctrl.SubscribeJSON))
+		mux.Get("/{topic}/sse", adapter.Adapt(ntfyctrl.SubscribeSSE))
+		mux.Get("/{topic}/raw", adapter.Adapt(ntfyctrl.SubscribeRaw))
+		mux.Get("/{topic}/ws", adapter.Adapt(ntfyctrl.SubscribeWS))
+	}
 
 	mux.Post("/api/v1/users/login", adapter.Adapt(userctrl.Authenticate))
 	mux.Post("/api/v1/users/register", adapter.Adapt(userctrl.Register))
@@ -140,6 +173,8 @@ func (ib *WebAPI) routes() chi.Router {
 		r.Patch("/api/v1/users/self", adapter.Adapt(userctrl.Update))
 
 		r.Get("/api/v1/feeds", adapter.Adapt(feedctrl.GetAll))
+		r.Get("/api/v1/feeds/rate-limits", adapter.Adapt(feedctrl.GetRateLimits))
+		r.Get("/api/v1/feeds/signature-failures", adapter.Adapt(feedctrl.GetSignatureFailures))
 
 		feedmessageCtrl := handlers.NewFeedMessageController(ib.services.FeedMessages)
 		r.HandleFunc("GET /api/v1/feed-messages", adapter.Adapt(feedmessageCtrl.Search))
@@ -149,6 +184,10 @@ func (ib *WebAPI) routes() chi.Router {
 		r.HandleFunc("DELETE /api/v1/feed-messages/{id}", adapter.Adapt(feedmessageCtrl.Delete))
 		r.HandleFunc("POST /api/v1/feeds/{feed-slug}/messages/bulk-state", adapter.Adapt(feedmessageCtrl.BulkUpdateState))
 		r.HandleFunc("POST /api/v1/feeds/{feed-slug}/messages/bulk-delete", adapter.Adapt(feedmessageCtrl.BulkDelete))
+
+		attachmentCtrl := handlers.NewAttachmentController(ib.services.Attachments)
+		r.HandleFunc("GET /api/v1/feed-messages/{id}/attachments", adapter.Adapt(attachmentCtrl.GetByMessage))
+		r.HandleFunc("GET /api/v1/attachments/{id}", adapter.Adapt(attachmentCtrl.Download))
 		// $scaffold_inject_routes
 	})
 
@@ -11,8 +11,24 @@
  * ---------------------------------------------------------------
  */
 
+export interface Attachment {
+  contentType: string;
+  createdAt: Date | string;
+  feedMessageId: string;
+  feedSlug: string;
+  id: string;
+  name: string;
+  size: number;
+}
+
+export interface AttachmentLimits {
+  maxSize: number;
+  quota: number;
+}
+
 export interface Feed {
   adapters: string[];
+  attachments: AttachmentLimits;
   category: string;
   description: string;
   id: string;
@@ -83,6 +99,14 @@ export interface FeedMessageUpdateState {
   state: "new" | "acknowledged" | "resolved" | "archived";
 }
 
+export interface GotifyMessage {
+  date: Date | string;
+  extras: Record<string, any>;
+  message: string;
+  priority: number;
+  title: string;
+}
+
 export interface PaginationResponseDtosFeedMessage {
   items: FeedMessage[];
   total: number;
@@ -98,11 +122,41 @@ export interface PasswordResetRequest {
   email: string;
 }
 
+export interface PushoverResponse {
+  errors: string[];
+  request: string;
+  status: number;
+}
+
+export interface RateLimitStats {
+  feedId: string;
+  /** nil when no request was rejected */
+  lastRejectedAt: string;
+  /** the feed has a rate limit */
+  limited: boolean;
+  /** rejected by the limit of the feed */
+  rejected: number;
+  /** rejected by the limit per source IP */
+  rejectedByIp: number;
+}
+
 export interface Retention {
   maxAgeDays: number;
   maxCount: number;
 }
 
+export interface SignatureStats {
+  feedId: string;
+  /** reason of the last rejection */
+  lastError: string;
+  /** nil when no request was rejected */
+  lastRejectedAt: string;
+  /** requests that failed verification */
+  rejected: number;
+  /** the feed has a signature or adapter secrets */
+  verified: boolean;
+}
+
 export interface StatusResponse {
   build: string;
 }
@@ -156,8 +210,12 @@ export interface UserUpdate {
 }
 
 export interface WebhookResponse {
+  /** true when middleware dropped the message */
+  aborted?: boolean;
   feedId: string;
   messageId: string;
+  /** messages of a CloudEvents request */
+  messageIds?: string[];
   success: boolean;
 }
 